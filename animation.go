@@ -0,0 +1,139 @@
+package mpb
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// CompletionAnimation returns frame of animation, which is played over the
+// bar body, when the bar completes. Body is the bar body at 100 %, with its
+// ends, markers and colors, so it may contain escape sequences. Returned frame
+// must have the same visible width, escape sequences are allowed. When ok is false, the animation has ended and body is rendered as
+// is.
+type CompletionAnimation func(frame int, body string) (string, bool)
+
+// SetCompletionAnimation sets animation, which is played over the bar body,
+// when the bar completes, advancing a frame every interval. Animations are
+// played on terminal only, and are disabled by (*Progress).SetAnimations.
+// Nil means no animation, which is default.
+func (b *Bar) SetCompletionAnimation(anim CompletionAnimation, interval time.Duration) *Bar {
+	b.operate(func(s *state) {
+		s.completionAnim = anim
+		s.animInterval = interval
+	})
+	return b
+}
+
+// SetAnimations enables completion animations of bars, see
+// (*Bar).SetCompletionAnimation. Disable them for conservative environments.
+// Default is true.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetAnimations(enabled bool) *Progress {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.animationsCh <- enabled:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// AnimationFlash flashes the bar body with ANSI SGR color, i.e. "7" for
+// inverse, for frames count
+func AnimationFlash(sgr string, frames int) CompletionAnimation {
+	return func(frame int, body string) (string, bool) {
+		if frame >= frames {
+			return body, false
+		}
+		if frame%2 == 1 {
+			return body, true
+		}
+		on := fmt.Sprintf("%c[%sm", 27, sgr)
+		// keep flashing after resets within the body, i.e. of failed color
+		body = strings.Replace(body, "\x1b[0m", "\x1b[0m"+on, -1)
+		return fmt.Sprintf("%s%s%c[0m", on, body, 27), true
+	}
+}
+
+// AnimationSweep sweeps r over the bar body, from its left to its right end,
+// in frames count
+func AnimationSweep(r rune, frames int) CompletionAnimation {
+	return func(frame int, body string) (string, bool) {
+		count := visibleRuneCount([]byte(body))
+		if frame >= frames || count < 3 {
+			return body, false
+		}
+		inner := count - 2
+		till := (frame + 1) * inner / frames
+		return mapVisible(body, func(i int, c rune) rune {
+			if i > 0 && i <= till {
+				return r
+			}
+			return c
+		}), true
+	}
+}
+
+// AnimationCheckmark collapses the fill of the bar body from both ends into
+// the checkmark in the middle, which stays
+func AnimationCheckmark() CompletionAnimation {
+	return func(frame int, body string) (string, bool) {
+		count := visibleRuneCount([]byte(body))
+		if count < 3 {
+			return body, false
+		}
+		inner := count - 2
+		steps := (inner + 1) / 2
+		if frame > steps {
+			frame = steps
+		}
+		return mapVisible(body, func(i int, c rune) rune {
+			switch {
+			case i == 0 || i == count-1:
+				return c
+			case frame == steps && i == 1+(inner-1)/2:
+				return '✓'
+			case i <= frame || i >= count-1-frame:
+				return ' '
+			}
+			return c
+		}), true
+	}
+}
+
+// animate returns bar block at the current frame of completion animation
+func (s *state) animate(barBlock []byte, now time.Time) []byte {
+	var frame int
+	if !s.completedAt.IsZero() && s.animInterval > 0 {
+		frame = int(now.Sub(s.completedAt) / s.animInterval)
+	}
+	str, ok := s.completionAnim(frame, string(barBlock))
+	if !ok || visibleRuneCount([]byte(str)) != visibleRuneCount(barBlock) {
+		return barBlock
+	}
+	return []byte(str)
+}
+
+// mapVisible maps visible runes of s with f, which gets index of the rune
+// among visible ones, keeping escape sequences intact
+func mapVisible(s string, f func(i int, r rune) rune) string {
+	b := []byte(s)
+	buf := make([]byte, 0, len(b))
+	var i int
+	for len(b) > 0 {
+		if b[0] == 27 && len(b) > 1 {
+			rest := skipEscape(b)
+			buf = append(buf, b[:len(b)-len(rest)]...)
+			b = rest
+			continue
+		}
+		r, size := utf8.DecodeRune(b)
+		buf = append(buf, string(f(i, r))...)
+		b = b[size:]
+		i++
+	}
+	return string(buf)
+}
@@ -0,0 +1,91 @@
+package mpb
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompletionAnimations(t *testing.T) {
+	body := "[=====]"
+	tests := []struct {
+		name   string
+		anim   CompletionAnimation
+		frames []string
+	}{
+		{"flash", AnimationFlash("7", 2), []string{"\x1b[7m[=====]\x1b[0m", "[=====]", "[=====]"}},
+		{"sweep", AnimationSweep('#', 2), []string{"[##===]", "[#####]", "[=====]"}},
+		{"checkmark", AnimationCheckmark(), []string{"[=====]", "[ === ]", "[  =  ]", "[  ✓  ]", "[  ✓  ]"}},
+	}
+	for _, test := range tests {
+		for frame, want := range test.frames {
+			got, ok := test.anim(frame, body)
+			if !ok {
+				got = body
+			}
+			if got != want {
+				t.Errorf("%s frame %d: want %q, got %q\n", test.name, frame, want, got)
+			}
+		}
+	}
+}
+
+func TestStateAnimate(t *testing.T) {
+	now := time.Now()
+	s := newTestState()
+	s.completionAnim = AnimationSweep('#', 5)
+	s.animInterval = 100 * time.Millisecond
+	body := []byte("[=====]")
+	// the bar hasn't exited yet
+	if got := string(s.animate(body, now)); got != "[#====]" {
+		t.Errorf("Want first frame, got %q\n", got)
+	}
+	s.completedAt = now.Add(-250 * time.Millisecond)
+	if got := string(s.animate(body, now)); got != "[###==]" {
+		t.Errorf("Want third frame, got %q\n", got)
+	}
+	// frame, which changes width, is ignored
+	s.completionAnim = func(int, string) (string, bool) { return "[==]", true }
+	if got := string(s.animate(body, now)); got != string(body) {
+		t.Errorf("Want body, got %q\n", got)
+	}
+}
+
+func TestCompletionAnimationsKeepEscapes(t *testing.T) {
+	body := "[\x1b[31m==\x1b[0m===]"
+	tests := []struct {
+		name string
+		anim CompletionAnimation
+		want string
+	}{
+		{"flash", AnimationFlash("7", 2), "\x1b[7m[\x1b[31m==\x1b[0m\x1b[7m===]\x1b[0m"},
+		{"sweep", AnimationSweep('#', 5), "[\x1b[31m#=\x1b[0m===]"},
+		{"checkmark", AnimationCheckmark(), "[\x1b[31m =\x1b[0m== ]"},
+	}
+	for _, test := range tests {
+		got, _ := test.anim(0, body)
+		if test.name == "checkmark" {
+			got, _ = test.anim(1, body)
+		}
+		if got != test.want {
+			t.Errorf("%s: want %q, got %q\n", test.name, test.want, got)
+		}
+	}
+}
+
+func TestDrawAnimatesMarkedBody(t *testing.T) {
+	prependWs := newWidthSync(nil, 1, 0)
+	appendWs := newWidthSync(nil, 1, 0)
+	s := newTestState()
+	s.width = 7
+	s.total = 10
+	s.current = 10
+	s.completed = true
+	s.markers = []float64{0.8}
+	s.markerRune = '|'
+	s.completionAnim = AnimationFlash("7", 2)
+	want := "\x1b[7m[====|]\x1b[0m"
+	if got := string(draw(s, 80, prependWs, appendWs)); !strings.Contains(got, want) {
+		t.Errorf("Want %q, got %q\n", want, got)
+	}
+}
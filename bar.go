@@ -1,11 +1,16 @@
 package mpb
 
 import (
+	"bytes"
+	"fmt"
 	"io"
 	"math"
+	"sort"
 	"sync"
 	"time"
 	"unicode/utf8"
+
+	"github.com/vbauerster/mpb/internal"
 )
 
 const (
@@ -25,26 +30,85 @@ type Bar struct {
 	widthCh       chan int
 	formatCh      chan string
 	etaAlphaCh    chan float64
+	sampleCh      chan time.Duration
 	incrCh        chan int64
 	trimLeftCh    chan bool
 	trimRightCh   chan bool
 	refillCh      chan *refill
 	decoratorCh   chan *decorator
+	operateCh     chan func(*state)
 	flushedCh     chan struct{}
 	removeReqCh   chan struct{}
 	completeReqCh chan struct{}
+	abortReqCh    chan struct{}
 	done          chan struct{}
 
+	// container, which the bar belongs to
+	p *Progress
+	// priority is owned by Progress' goroutine, see insertByPriority
+	priority int
+
 	// follawing are used after (*Bar.done) is closed
 	width int
+	// mu guards state, which is still configured by Progress, see configure
+	mu    sync.Mutex
 	state state
 }
 
 // Statistics represents statistics of the progress bar.
-// Cantains: Total, Current, TimeElapsed, TimePerItemEstimate
+// Cantains: Total, Current, TimeElapsed, TimePerItemEstimate, Priority,
+// Pinned and Updated, which is time of the last increment.
+// JSON field names are stable within SchemaVersion, durations are encoded in
+// nanoseconds.
 type Statistics struct {
-	Total, Current                   int64
-	TimeElapsed, TimePerItemEstimate time.Duration
+	Total                int64         `json:"total"`
+	Current              int64         `json:"current"`
+	TimeElapsed          time.Duration `json:"time_elapsed"`
+	TimePerItemEstimate  time.Duration `json:"time_per_item"`
+	Priority             int           `json:"priority"`
+	Pinned               bool          `json:"pinned"`
+	Updated              time.Time     `json:"updated"`
+	TimePerItemDeviation time.Duration `json:"time_per_item_deviation"`
+	RateCap              float64       `json:"rate_cap"`
+	Attempt              int           `json:"attempt"`
+	MaxAttempts          int           `json:"max_attempts"`
+	Failed               int64         `json:"failed"`
+	Secondary            int64         `json:"secondary"`
+	// RolledBack is total amount rolled back by Decr
+	RolledBack int64 `json:"rolled_back"`
+	// Aborted is set, if the bar has been aborted, see Abort
+	Aborted bool `json:"aborted"`
+	// EwmaTimePerItem is moving average of per item work time, reported by
+	// IncrBy, zero if the bar is incremented otherwise
+	EwmaTimePerItem time.Duration `json:"ewma_time_per_item"`
+	// measuring is set, when decorators are rendered only to measure width
+	measuring bool
+	// started is start time of the bar in progress, zero once it's completed
+	started time.Time
+}
+
+// elapsed returns wall clock time elapsed since the bar has started.
+// TimeElapsed is updated on increments only, so it stalls with the progress.
+func (s *Statistics) elapsed(now time.Time) time.Duration {
+	if s.started.IsZero() {
+		return s.TimeElapsed
+	}
+	return now.Sub(s.started)
+}
+
+// Measuring reports, whether decorators are rendered only to measure their
+// width, see (*Progress).SetMaxDrawers. Output is discarded then, so
+// stateful decorators, i.e. spinners, shouldn't advance.
+func (s *Statistics) Measuring() bool {
+	return s.measuring
+}
+
+// Rate returns estimated rate in items per second
+func (s *Statistics) Rate() float64 {
+	if s.TimePerItemEstimate <= 0 {
+		return 0
+	}
+	return float64(time.Second) / float64(s.TimePerItemEstimate)
 }
 
 // Eta returns exponential-weighted-moving-average ETA estimator
@@ -52,6 +116,18 @@ func (s *Statistics) Eta() time.Duration {
 	return time.Duration(s.Total-s.Current) * s.TimePerItemEstimate
 }
 
+// EtaRange returns ETA range of one standard deviation of per item time
+// around Eta
+func (s *Statistics) EtaRange() (min, max time.Duration) {
+	remaining := time.Duration(s.Total - s.Current)
+	min = remaining * (s.TimePerItemEstimate - s.TimePerItemDeviation)
+	if min < 0 {
+		min = 0
+	}
+	max = remaining * (s.TimePerItemEstimate + s.TimePerItemDeviation)
+	return min, max
+}
+
 type (
 	runeFormatElement struct {
 		char  rune
@@ -72,77 +148,204 @@ type (
 		trimRightSpace bool
 		timeElapsed    time.Duration
 		timePerItem    time.Duration
-		appendFuncs    []DecoratorFunc
-		prependFuncs   []DecoratorFunc
-		simpleSpinner  func() byte
+		appendFuncs    []BytesDecoratorFunc
+		prependFuncs   []BytesDecoratorFunc
+		simpleSpinner  func(*state) byte
+		spinnerSpeed   SpinnerSpeed
 		refill         *refill
+		minWidth       int
+		prependLayouts []Layout
+		appendLayouts  []Layout
+		priority       int
+		pinned         bool
+		completed      bool
+		sgr            string
+		etaWindow      []time.Duration
+		etaWindowPos   int
+		tpiMean        float64
+		tpiVariance    float64
+		rateCap        float64
+		timeStarted    time.Time
+		// updated is time of the last increment, or of the start
+		updated time.Time
+		// meta is copied on write, so state copies may share it
+		meta map[string]interface{}
+		// column ids of decorators, see PrependColumn
+		prependIDs []string
+		appendIDs  []string
+		// declared column order, see (*Progress).SetColumns
+		prependOrder []string
+		appendOrder  []string
+		// rate sampling, see SetSampleInterval
+		sampleInterval time.Duration
+		sampledItems   int64
+		lastSample     time.Time
+		milestones     []*milestone
+		deadlines      []*deadline
+		// retries, see SetAttempt
+		attempt     int
+		maxAttempts int
+		// failed items, see IncrFailed
+		failed    int64
+		failedSGR string
+		// secondary progress, see IncrSecondary
+		secondary     int64
+		secondaryRune rune
+		// see RemoveOnComplete
+		removeOnComplete bool
+		removeGrace      time.Duration
+		// completedAt is time, the bar has exited on completion
+		completedAt time.Time
+		// see SetCompletionAnimation
+		completionAnim CompletionAnimation
+		animInterval   time.Duration
+		// see SetNameColumn
+		nameColumn *nameColumn
+		// see SetLineHook
+		lineHook LineHook
+		// see SetDetails and Expand
+		details  DetailFunc
+		expanded bool
+		// totalPending is set, while total isn't final, see SetTotal
+		totalPending bool
+		// see Decr and SetRollbackCue
+		rolledBack   int64
+		rolledBackAt time.Time
+		rollbackTip  rune
+		rollbackCue  time.Duration
+		// see SetMarkers
+		markers    []float64
+		markerRune rune
+		// see AddSpinner
+		spinnerSet   *SpinnerSet
+		spinnerStart time.Time
+		// see SetTimeAxis
+		expectedDuration time.Duration
+		progressMarker   rune
+		// see OnComplete and SetCompletedLine
+		onComplete    []func(*Bar)
+		completedLine string
+		// see BarClearOnComplete
+		clearOnComplete bool
+		// see Abort
+		aborted bool
+		// see IncrBy
+		ewmaTimePerItem time.Duration
 	}
 )
 
-func newBar(id int, total int64, width int, format string, wg *sync.WaitGroup, cancel <-chan struct{}) *Bar {
+func newBar(p *Progress, id int, total int64, width int, format string, uwg *sync.WaitGroup, cancel <-chan struct{}) *Bar {
 	b := &Bar{
+		p:             p,
 		stateReqCh:    make(chan chan state),
 		widthCh:       make(chan int),
 		formatCh:      make(chan string),
 		etaAlphaCh:    make(chan float64),
+		sampleCh:      make(chan time.Duration),
 		incrCh:        make(chan int64, 1),
 		trimLeftCh:    make(chan bool),
 		trimRightCh:   make(chan bool),
 		refillCh:      make(chan *refill),
 		decoratorCh:   make(chan *decorator),
+		operateCh:     make(chan func(*state)),
 		flushedCh:     make(chan struct{}, 1),
 		removeReqCh:   make(chan struct{}),
 		completeReqCh: make(chan struct{}),
+		abortReqCh:    make(chan struct{}),
 		done:          make(chan struct{}),
 	}
-	go b.server(id, total, width, format, wg, cancel)
+	go b.server(id, total, width, format, p.wg, uwg, cancel)
 	return b
 }
 
 // SetWidth overrides width of individual bar
 func (b *Bar) SetWidth(n int) *Bar {
-	if n < 2 || isClosed(b.done) {
+	if n < 2 {
 		return b
 	}
-	b.widthCh <- n
+	select {
+	case b.widthCh <- n:
+	case <-b.done:
+	}
 	return b
 }
 
 // TrimLeftSpace removes space befor LeftEnd charater
 func (b *Bar) TrimLeftSpace() *Bar {
-	if isClosed(b.done) {
-		return b
+	select {
+	case b.trimLeftCh <- true:
+	case <-b.done:
 	}
-	b.trimLeftCh <- true
 	return b
 }
 
 // TrimRightSpace removes space after RightEnd charater
 func (b *Bar) TrimRightSpace() *Bar {
-	if isClosed(b.done) {
-		return b
+	select {
+	case b.trimRightCh <- true:
+	case <-b.done:
 	}
-	b.trimRightCh <- true
 	return b
 }
 
-// Format overrides format of individual bar
+// Format overrides format of individual bar. Invalid format is ignored, use
+// SetFormat to get an error instead.
 func (b *Bar) Format(format string) *Bar {
-	if utf8.RuneCountInString(format) != numFmtRunes || isClosed(b.done) {
-		return b
-	}
-	b.formatCh <- format
+	b.SetFormat(format)
 	return b
 }
 
+// SetFormat overrides format of the bar, like Format does, but returns
+// ErrInvalidFormat, if format isn't exactly 5 runes
+func (b *Bar) SetFormat(format string) error {
+	if utf8.RuneCountInString(format) != numFmtRunes {
+		return ErrInvalidFormat
+	}
+	select {
+	case b.formatCh <- format:
+	case <-b.done:
+	}
+	return nil
+}
+
 // SetEtaAlpha sets alfa for exponential-weighted-moving-average ETA estimator
 // Defaults to 0.25
 // Normally you shouldn't touch this
 func (b *Bar) SetEtaAlpha(a float64) *Bar {
-	if isClosed(b.done) {
-		return b
+	select {
+	case b.etaAlphaCh <- a:
+	case <-b.done:
+	}
+	return b
+}
+
+// SetEtaMedianWindow switches ETA estimator to median of last n per item
+// times. Unlike moving average, median rejects outliers, i.e. single increment
+// delayed by TCP stall, so ETA stays stable. Zero n switches back to
+// exponential-weighted-moving-average estimator, see SetEtaAlpha.
+func (b *Bar) SetEtaMedianWindow(n int) *Bar {
+	if n < 0 {
+		n = 0
+	}
+	b.operate(func(s *state) {
+		s.etaWindow = make([]time.Duration, 0, n)
+		s.etaWindowPos = 0
+	})
+	return b
+}
+
+// SetSampleInterval makes rate estimator sample increments every d, instead
+// of on every increment. It decouples rate estimation from frequency of
+// increments, while render rate is capped by (*Progress).RefreshRate.
+// Zero d restores estimation on every increment.
+func (b *Bar) SetSampleInterval(d time.Duration) *Bar {
+	if d < 0 {
+		d = 0
+	}
+	select {
+	case b.sampleCh <- d:
+	case <-b.done:
 	}
-	b.etaAlphaCh <- a
 	return b
 }
 
@@ -151,29 +354,195 @@ func (b *Bar) ProxyReader(r io.Reader) *Reader {
 	return &Reader{r, b}
 }
 
-// Incr increments progress bar
+// SetTotal sets total of the bar, which may be created before total is
+// known, i.e. HTTP download without Content-Length. While total isn't final,
+// the bar is rendered as spinner, it doesn't complete and total grows with
+// current. Final total, which isn't positive, means it's still unknown. Once
+// total is final, the bar completes, as soon as current reaches it, that is
+// immediately, if current has already reached it.
+func (b *Bar) SetTotal(total int64, final bool) *Bar {
+	b.operate(func(s *state) {
+		s.setTotal(total, final)
+	})
+	return b
+}
+
+// ProxyWriter wrapper for io operations, like io.Copy
+func (b *Bar) ProxyWriter(w io.Writer) *Writer {
+	return &Writer{w, b}
+}
+
+// Incr increments progress bar.
+// It is safe to call Incr from multiple goroutines.
 func (b *Bar) Incr(n int) {
-	if n < 1 || isClosed(b.done) {
+	if n < 1 {
 		return
 	}
-	b.incrCh <- int64(n)
+	select {
+	case b.incrCh <- int64(n):
+	case <-b.done:
+	}
 }
 
-// IncrWithReFill increments pb with different fill character
-func (b *Bar) IncrWithReFill(n int, r rune) {
-	if isClosed(b.done) {
+// IncrFailed increments the bar by n items, which have failed. Failed items
+// count as processed, see Failures decorator and SetFailedColor.
+func (b *Bar) IncrFailed(n int) {
+	if n < 1 {
+		return
+	}
+	b.operate(func(s *state) {
+		if s.completed {
+			return
+		}
+		current := s.current
+		s.incr(int64(n), time.Now())
+		s.failed += s.current - current
+	})
+}
+
+// IncrSecondary increments secondary progress of the bar by n, which is
+// expected to run ahead of the primary one, i.e. downloaded vs verified
+// bytes. Cells between primary and secondary fill levels are drawn with
+// secondary rune, see SetSecondaryRune.
+func (b *Bar) IncrSecondary(n int) {
+	if n < 1 {
 		return
 	}
+	b.operate(func(s *state) {
+		s.secondary += int64(n)
+		if s.total > 0 && s.secondary > s.total {
+			s.secondary = s.total
+		}
+	})
+}
+
+// SetSecondaryRune sets rune of secondary fill level, default is '+'
+func (b *Bar) SetSecondaryRune(r rune) *Bar {
+	b.operate(func(s *state) {
+		s.secondaryRune = r
+	})
+	return b
+}
+
+// Refresh renders the frame right away, instead of waiting up to refresh
+// interval, so rare but important state changes, i.e. error or phase switch,
+// appear instantly. Refreshes requested before the frame is rendered are
+// coalesced into one.
+func (b *Bar) Refresh() *Bar {
+	select {
+	case b.p.refreshCh <- struct{}{}:
+	default:
+	}
+	return b
+}
+
+// RemoveOnComplete removes the bar from the container, after it has
+// completed. The bar stays visible for grace duration, i.e. 500ms, so
+// completion is perceptible, before it disappears.
+func (b *Bar) RemoveOnComplete(grace time.Duration) *Bar {
+	b.operate(func(s *state) {
+		s.removeOnComplete = true
+		s.removeGrace = grace
+	})
+	return b
+}
+
+// LineHook gets rendered line of the bar, without trailing newline, and
+// returns line to be flushed. It may wrap the line in escape sequences, i.e.
+// background color of selected bar, but mustn't change its visible width.
+type LineHook func(s *Statistics, line []byte) []byte
+
+// SetLineHook sets hook, which decorates rendered line of the bar before
+// flush. Nil removes the hook.
+func (b *Bar) SetLineHook(hook LineHook) *Bar {
+	b.operate(func(s *state) {
+		s.lineHook = hook
+	})
+	return b
+}
+
+// SetFailedColor sets ANSI SGR color of the failed fraction of the bar body,
+// like "31" for red. The failed fraction is drawn at the start of the fill.
+func (b *Bar) SetFailedColor(sgr string) *Bar {
+	b.operate(func(s *state) {
+		s.failedSGR = sgr
+	})
+	return b
+}
+
+// Decr rolls progress back by n, i.e. on rollback or reconciliation. Current
+// doesn't go below zero, completed bar isn't rolled back. See SetRollbackCue
+// for visual cue of the rollback.
+func (b *Bar) Decr(n int) {
+	if n < 1 {
+		return
+	}
+	b.operate(func(s *state) {
+		s.decr(int64(n), time.Now())
+	})
+}
+
+// SetRollbackCue sets tip rune of the bar, i.e. '<', which is rendered for d
+// after the bar is rolled back by Decr. Zero tip means no cue, which is
+// default.
+func (b *Bar) SetRollbackCue(tip rune, d time.Duration) *Bar {
+	b.operate(func(s *state) {
+		s.rollbackTip = tip
+		s.rollbackCue = d
+	})
+	return b
+}
+
+// SetTimeAxis switches the bar to time axis mode, where fill is elapsed time
+// of expected duration, and actual progress is a marker rendered with r,
+// like '|'. It suits jobs with known planned duration, but noisy progress
+// reporting. Fill stops at the end, if the job overruns, the bar completes by
+// progress as usual. Zero expected switches the mode off.
+func (b *Bar) SetTimeAxis(expected time.Duration, r rune) *Bar {
+	b.operate(func(s *state) {
+		s.expectedDuration = expected
+		s.progressMarker = r
+	})
+	return b
+}
+
+// SetMarkers places static markers at fractions of the bar body, i.e. 0.25
+// for a quarter, rendered with r, like '|'. Markers show structural positions
+// within the task, i.e. chunk boundaries or resume point. Fractions outside
+// [0, 1] are ignored. No fractions remove markers.
+func (b *Bar) SetMarkers(r rune, fractions ...float64) *Bar {
+	var markers []float64
+	for _, f := range fractions {
+		if f >= 0 && f <= 1 {
+			markers = append(markers, f)
+		}
+	}
+	b.operate(func(s *state) {
+		s.markers = markers
+		s.markerRune = r
+	})
+	return b
+}
+
+// IncrWithReFill increments pb with different fill character
+func (b *Bar) IncrWithReFill(n int, r rune) {
 	b.Incr(n)
-	b.refillCh <- &refill{r, int64(n)}
+	select {
+	case b.refillCh <- &refill{r, int64(n)}:
+	case <-b.done:
+	}
 }
 
 // GetAppenders returns slice of appender DecoratorFunc
 func (b *Bar) GetAppenders() []DecoratorFunc {
 	s := b.getState()
-	return s.appendFuncs
+	funcs, _ := arrangeColumns(s.appendOrder, s.appendIDs, s.appendFuncs, nil)
+	return toStringFuncs(funcs)
 }
 
+// NumOfAppenders returns count of append columns.
+//
+// Deprecated: use (*Progress).ColumnSchema, which labels columns.
 func (b *Bar) NumOfAppenders() int {
 	return len(b.GetAppenders())
 }
@@ -181,9 +550,13 @@ func (b *Bar) NumOfAppenders() int {
 // GetPrependers returns slice of prepender DecoratorFunc
 func (b *Bar) GetPrependers() []DecoratorFunc {
 	s := b.getState()
-	return s.prependFuncs
+	funcs, _ := s.prependColumns()
+	return toStringFuncs(funcs)
 }
 
+// NumOfPrependers returns count of prepend columns.
+//
+// Deprecated: use (*Progress).ColumnSchema, which labels columns.
 func (b *Bar) NumOfPrependers() int {
 	return len(b.GetPrependers())
 }
@@ -207,121 +580,437 @@ func (b *Bar) InProgress() bool {
 	return !isClosed(b.done)
 }
 
-// PrependFunc prepends DecoratorFunc
-func (b *Bar) PrependFunc(f DecoratorFunc) *Bar {
-	if isClosed(b.done) {
+// SetMeta attaches arbitrary user value to the bar under the key.
+// It can be retrieved later with Meta, i.e. in BeforeRender func.
+func (b *Bar) SetMeta(key string, value interface{}) *Bar {
+	b.operate(func(s *state) {
+		meta := make(map[string]interface{}, len(s.meta)+1)
+		for k, v := range s.meta {
+			meta[k] = v
+		}
+		meta[key] = value
+		s.meta = meta
+	})
+	return b
+}
+
+// ResumeFrom restores progress of previous session, i.e. from a checkpoint.
+// Elapsed time continues from elapsed and initial rate estimate is derived
+// from it, so ETA doesn't go wild after restore.
+func (b *Bar) ResumeFrom(current int64, elapsed time.Duration) *Bar {
+	b.operate(func(s *state) {
+		s.resume(current, elapsed, time.Now())
+	})
+	return b
+}
+
+// SetAttempt sets current attempt n out of max, see Attempt decorator. Zero
+// max means unlimited attempts. When n is greater than previous attempt, the
+// bar is reset, so the retried transfer starts from zero with fresh rate
+// estimate. No-op, if the bar has completed already.
+func (b *Bar) SetAttempt(n, max int) *Bar {
+	b.operate(func(s *state) {
+		s.setAttempt(n, max, time.Now())
+	})
+	return b
+}
+
+// AddToTotal adds delta to total of the bar, for work discovered while
+// already processing, i.e. walking a filesystem. Percentage and ETA are
+// recalculated on the next render. If the bar had unknown total, it turns
+// into determinate one. No-op, if the bar has completed already.
+func (b *Bar) AddToTotal(delta int64) *Bar {
+	b.operate(func(s *state) {
+		s.addToTotal(delta)
+	})
+	return b
+}
+
+// TransferTo moves up to amount of remaining work of the bar to other bar,
+// i.e. when scheduler rebalances tasks among workers. Totals of both bars
+// are adjusted between renders, so no frame shows one without the other.
+// Returns amount actually moved, which is limited by remaining work.
+func (b *Bar) TransferTo(other *Bar, amount int64) int64 {
+	if b.p == nil || other == nil || other == b || amount <= 0 {
+		return 0
+	}
+	b.p.acquire()
+	defer b.p.release()
+	op := &operation{
+		kind:   barTransfer,
+		bar:    b,
+		to:     other,
+		amount: amount,
+		moved:  make(chan int64, 1),
+	}
+	select {
+	case b.p.operationCh <- op:
+		return <-op.moved
+	case <-b.p.done:
+		return 0
+	}
+}
+
+// OnProgress registers fn, which is called once, when the bar reaches
+// threshold percentage, i.e. 25, 50, 75 or 100. It is useful for logging
+// milestones of long jobs without per increment hooks. The fn is called in
+// its own goroutine, so it may call methods of the bar.
+func (b *Bar) OnProgress(threshold float64, fn func(*Bar)) *Bar {
+	b.operate(func(s *state) {
+		s.milestones = append(s.milestones, &milestone{threshold: threshold, fn: fn})
+	})
+	return b
+}
+
+// OnEtaExceeds registers fn, which is called, when ETA of the bar exceeds
+// limit, so batch frameworks can alert or reschedule tasks predicted to run
+// too long. The fn is called again, if ETA drops below limit and exceeds it
+// later. The fn is called in its own goroutine.
+func (b *Bar) OnEtaExceeds(limit time.Duration, fn func(*Bar)) *Bar {
+	b.operate(func(s *state) {
+		s.deadlines = append(s.deadlines, &deadline{limit: limit, fn: fn})
+	})
+	return b
+}
+
+// SetColorOnEtaExceeds changes color of the bar to sgr, while its ETA exceeds
+// limit, see SetColor
+func (b *Bar) SetColorOnEtaExceeds(limit time.Duration, sgr string) *Bar {
+	var prev string
+	b.operate(func(s *state) {
+		s.deadlines = append(s.deadlines, &deadline{
+			limit: limit,
+			style: func(s *state, exceeded bool) {
+				if exceeded {
+					prev, s.sgr = s.sgr, sgr
+				} else {
+					s.sgr = prev
+				}
+			},
+		})
+	})
+	return b
+}
+
+// SetMinWidth sets min width, the bar may be shrunk to, when the line doesn't
+// fit terminal width. See Layout for how decorators give up their width.
+func (b *Bar) SetMinWidth(n int) *Bar {
+	if n < 0 {
 		return b
 	}
-	b.decoratorCh <- &decorator{decPrepend, f}
+	b.operate(func(s *state) {
+		s.minWidth = n
+	})
+	return b
+}
+
+// SetPriority sets priority of the bar, which is used by SortByPriority, and
+// moves the bar in priority order, see (*Progress).UpdateBarPriority
+func (b *Bar) SetPriority(priority int) *Bar {
+	if !b.p.updatePriority(b, priority) {
+		// bar isn't in the container, statistics are updated anyway
+		b.operate(func(s *state) {
+			s.priority = priority
+		})
+	}
+	return b
+}
+
+// SetRateCap sets configured rate limit of the bar, in items per second.
+// It is only displayed, i.e. by SpeedCap decorator, the bar doesn't enforce it.
+func (b *Bar) SetRateCap(rate float64) *Bar {
+	b.operate(func(s *state) {
+		s.rateCap = rate
+	})
+	return b
+}
+
+// SetColor sets ANSI SGR color of the bar body, like "34" for blue.
+// Decorators aren't affected, use Color decorator for them.
+func (b *Bar) SetColor(sgr string) *Bar {
+	b.operate(func(s *state) {
+		s.sgr = sgr
+	})
+	return b
+}
+
+// SetPinned pins the bar at its position, so it is exempt from sorting
+func (b *Bar) SetPinned(pinned bool) *Bar {
+	b.operate(func(s *state) {
+		s.pinned = pinned
+	})
+	return b
+}
+
+// Meta returns user value, attached with SetMeta, or nil if there is none
+func (b *Bar) Meta(key string) interface{} {
+	s := b.getState()
+	return s.meta[key]
+}
+
+// PrependFunc prepends DecoratorFunc
+func (b *Bar) PrependFunc(f DecoratorFunc) *Bar {
+	b.sendDecorator(&decorator{kind: decPrepend, f: BytesFunc(f)})
+	return b
+}
+
+// PrependBytesFunc prepends BytesDecoratorFunc
+func (b *Bar) PrependBytesFunc(f BytesDecoratorFunc) *Bar {
+	b.sendDecorator(&decorator{kind: decPrepend, f: f})
 	return b
 }
 
 // RemoveAllPrependers removes all prepend functions
 func (b *Bar) RemoveAllPrependers() {
-	if isClosed(b.done) {
-		return
-	}
-	b.decoratorCh <- &decorator{decPrependZero, nil}
+	b.sendDecorator(&decorator{kind: decPrependZero})
 }
 
 // AppendFunc appends DecoratorFunc
 func (b *Bar) AppendFunc(f DecoratorFunc) *Bar {
-	if isClosed(b.done) {
-		return b
-	}
-	b.decoratorCh <- &decorator{decAppend, f}
+	b.sendDecorator(&decorator{kind: decAppend, f: BytesFunc(f)})
+	return b
+}
+
+// AppendBytesFunc appends BytesDecoratorFunc
+func (b *Bar) AppendBytesFunc(f BytesDecoratorFunc) *Bar {
+	b.sendDecorator(&decorator{kind: decAppend, f: f})
 	return b
 }
 
 // RemoveAllAppenders removes all append functions
 func (b *Bar) RemoveAllAppenders() {
-	if isClosed(b.done) {
-		return
-	}
-	b.decoratorCh <- &decorator{decAppendZero, nil}
+	b.sendDecorator(&decorator{kind: decAppendZero})
 }
 
 // Completed signals to the bar, that process has been completed.
 // You should call this method when total is unknown and you've reached the point
 // of process completion.
 func (b *Bar) Completed() {
-	if isClosed(b.done) {
+	select {
+	case b.completeReqCh <- struct{}{}:
+	case <-b.done:
+	}
+}
+
+// Abort terminates the bar early, i.e. when its task has failed. If drop is
+// true, the bar is removed from the container, otherwise it is frozen in its
+// current state and keeps being rendered, see OnAbort decorator to mark it
+// visibly. Abort of completed bar is no-op, even if its final state isn't
+// rendered yet.
+func (b *Bar) Abort(drop bool) {
+	if drop {
+		if s := b.getState(); !s.completed {
+			b.p.TryRemoveBar(b)
+		}
 		return
 	}
-	b.completeReqCh <- struct{}{}
+	select {
+	case b.abortReqCh <- struct{}{}:
+		// round trip returns, once aborted bar is done, or right away, if
+		// the bar is completed and keeps running till flush
+		b.operate(func(*state) {})
+	case <-b.done:
+	}
+}
+
+func (b *Bar) sendDecorator(d *decorator) {
+	select {
+	case b.decoratorCh <- d:
+	case <-b.done:
+	}
+}
+
+func (b *Bar) setColumns(columns [2][]string) {
+	b.configure(func(s *state) {
+		s.prependOrder, s.appendOrder = columns[0], columns[1]
+	})
+}
+
+// operate runs f in bar's goroutine, reports false if bar is done
+func (b *Bar) operate(f func(*state)) bool {
+	select {
+	case b.operateCh <- f:
+		return true
+	case <-b.done:
+		return false
+	}
+}
+
+// configure is operate, which applies f to the final state, if the bar is
+// done. Done bar is still rendered, so settings of Progress, which change
+// decorators of every bar, i.e. columns, must apply to it as well. It's called
+// by Progress' goroutine only.
+func (b *Bar) configure(f func(*state)) {
+	if b.operate(f) {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	f(&b.state)
 }
 
 func (b *Bar) getState() state {
-	if isClosed(b.done) {
+	ch := make(chan state, 1)
+	select {
+	case b.stateReqCh <- ch:
+		return <-ch
+	case <-b.done:
+		b.mu.Lock()
+		defer b.mu.Unlock()
 		return b.state
 	}
-	ch := make(chan state)
-	b.stateReqCh <- ch
-	return <-ch
 }
 
-func (b *Bar) server(id int, total int64, width int, format string, wg *sync.WaitGroup, cancel <-chan struct{}) {
-	var completed bool
+func (b *Bar) server(id int, total int64, width int, format string, wg *barCounter, uwg *sync.WaitGroup, cancel <-chan struct{}) {
 	timeStarted := time.Now()
-	prevStartTime := timeStarted
-	var blockStartTime time.Time
 	barState := state{
-		id:       id,
-		width:    width,
-		format:   barFmtRunes{'[', '=', '>', '-', ']'},
-		etaAlpha: 0.25,
-		total:    total,
+		id:          id,
+		width:       width,
+		format:      barFmtRunes{'[', '=', '>', '-', ']'},
+		etaAlpha:    0.25,
+		total:       total,
+		timeStarted: timeStarted,
+		updated:     timeStarted,
 	}
 	if total <= 0 {
 		barState.simpleSpinner = getSpinner()
 	} else {
 		barState.updateFormat(format)
 	}
+	handles := make(map[*DecoratorHandle]int)
+	var sampleTicker *time.Ticker
+	var sampleC <-chan time.Time
 	defer func() {
+		if sampleTicker != nil {
+			sampleTicker.Stop()
+		}
 		b.stop(&barState, width)
+		kind := BarAborted
+		if barState.completed {
+			kind = BarCompleted
+		}
+		b.p.events.emit(kind, b, id)
 		wg.Done()
+		if uwg != nil {
+			uwg.Done()
+		}
 	}()
+	var started bool
+	// emitStarted emits BarStarted on the first progress, whichever way the
+	// bar is incremented, i.e. by IncrBy or ResumeFrom
+	emitStarted := func() {
+		if !started && barState.current > 0 {
+			started = true
+			b.p.events.emit(BarStarted, b, id)
+		}
+	}
 	for {
 		select {
 		case i := <-b.incrCh:
-			blockStartTime = time.Now()
-			n := barState.current + i
-			if total > 0 && n > total {
-				barState.current = total
-				completed = true
-				break // break out of select
+			barState.incr(i, time.Now())
+			emitStarted()
+			barState.fireMilestones(b)
+			barState.fireCompletion(b)
+			barState.checkDeadlines(b)
+		case d := <-b.sampleCh:
+			if sampleTicker != nil {
+				sampleTicker.Stop()
+				sampleTicker, sampleC = nil, nil
 			}
-			barState.timeElapsed = time.Since(timeStarted)
-			barState.timePerItem = calcTimePerItemEstimate(barState.timePerItem, prevStartTime, barState.etaAlpha, i)
-			if n == total {
-				completed = true
+			now := time.Now()
+			barState.sample(now)
+			barState.sampleInterval = d
+			barState.lastSample = now
+			if d > 0 {
+				sampleTicker = time.NewTicker(d)
+				sampleC = sampleTicker.C
 			}
-			barState.current = n
-			prevStartTime = blockStartTime
+		case now := <-sampleC:
+			barState.sample(now)
 		case d := <-b.decoratorCh:
 			switch d.kind {
 			case decAppend:
+				if d.handle != nil {
+					handles[d.handle] = len(barState.appendFuncs)
+				}
 				barState.appendFuncs = append(barState.appendFuncs, d.f)
+				barState.appendLayouts = append(barState.appendLayouts, Layout{})
+				barState.appendIDs = appendID(barState.appendIDs, len(barState.appendFuncs), d.column)
 			case decAppendZero:
 				barState.appendFuncs = nil
+				barState.appendLayouts = nil
+				barState.appendIDs = nil
+				for h := range handles {
+					if !h.prepend {
+						delete(handles, h)
+					}
+				}
 			case decPrepend:
+				if d.handle != nil {
+					handles[d.handle] = len(barState.prependFuncs)
+				}
 				barState.prependFuncs = append(barState.prependFuncs, d.f)
+				barState.prependLayouts = append(barState.prependLayouts, Layout{})
+				barState.prependIDs = appendID(barState.prependIDs, len(barState.prependFuncs), d.column)
 			case decPrependZero:
 				barState.prependFuncs = nil
+				barState.prependLayouts = nil
+				barState.prependIDs = nil
+				for h := range handles {
+					if h.prepend {
+						delete(handles, h)
+					}
+				}
+			case decReplace:
+				i, ok := handles[d.handle]
+				if !ok {
+					break
+				}
+				// copy on write, as previous state may be in use by drawer
+				if d.handle.prepend {
+					barState.prependFuncs = replaceFunc(barState.prependFuncs, i, d.f)
+				} else {
+					barState.appendFuncs = replaceFunc(barState.appendFuncs, i, d.f)
+				}
+			case decLayout:
+				i, ok := handles[d.handle]
+				if !ok {
+					break
+				}
+				if d.handle.prepend {
+					barState.prependLayouts = replaceLayout(barState.prependLayouts, i, d.layout)
+				} else {
+					barState.appendLayouts = replaceLayout(barState.appendLayouts, i, d.layout)
+				}
 			}
+		case f := <-b.operateCh:
+			f(&barState)
+			emitStarted()
+			barState.fireMilestones(b)
+			barState.fireCompletion(b)
+			barState.checkDeadlines(b)
 		case ch := <-b.stateReqCh:
 			ch <- barState
 		case format := <-b.formatCh:
 			barState.updateFormat(format)
 		case barState.width = <-b.widthCh:
+		case barState.etaAlpha = <-b.etaAlphaCh:
 		case barState.refill = <-b.refillCh:
 		case barState.trimLeftSpace = <-b.trimLeftCh:
 		case barState.trimRightSpace = <-b.trimRightCh:
 		case <-b.flushedCh:
-			if completed {
+			if barState.completed {
 				return
 			}
 		case <-b.completeReqCh:
+			barState.completedAt = time.Now()
+			return
+		case <-b.abortReqCh:
+			if barState.completed {
+				// exits on flush, see flushedCh
+				break
+			}
+			barState.aborted = true
 			return
 		case <-b.removeReqCh:
 			return
@@ -332,23 +1021,41 @@ func (b *Bar) server(id int, total int64, width int, format string, wg *sync.Wai
 }
 
 func (b *Bar) stop(s *state, width int) {
+	if s.completed {
+		s.completedAt = time.Now()
+	}
 	b.state = *s
 	b.width = width
 	close(b.done)
 }
 
 func (b *Bar) flushed() {
-	if isClosed(b.done) {
-		return
+	select {
+	case b.flushedCh <- struct{}{}:
+	case <-b.done:
+	default:
 	}
-	b.flushedCh <- struct{}{}
 }
 
 func (b *Bar) remove() {
-	if isClosed(b.done) {
-		return
+	select {
+	case b.removeReqCh <- struct{}{}:
+	case <-b.done:
 	}
-	b.removeReqCh <- struct{}{}
+}
+
+func replaceFunc(funcs []BytesDecoratorFunc, i int, f BytesDecoratorFunc) []BytesDecoratorFunc {
+	result := make([]BytesDecoratorFunc, len(funcs))
+	copy(result, funcs)
+	result[i] = f
+	return result
+}
+
+func replaceLayout(layouts []Layout, i int, l Layout) []Layout {
+	result := make([]Layout, len(layouts))
+	copy(result, layouts)
+	result[i] = l
+	return result
 }
 
 func (s *state) updateFormat(format string) {
@@ -361,70 +1068,286 @@ func (s *state) updateFormat(format string) {
 	}
 }
 
-func (b *Bar) bytes(termWidth int, prependWs, appendWs *widthSync) []byte {
-	s := b.getState()
-	return draw(&s, termWidth, prependWs, appendWs)
-}
-
 func draw(s *state, termWidth int, prependWs, appendWs *widthSync) []byte {
-	if len(s.prependFuncs) != len(prependWs.listen) || len(s.appendFuncs) != len(appendWs.listen) {
+	prependFuncs, prependLayouts := s.prependColumns()
+	appendFuncs, appendLayouts := arrangeColumns(s.appendOrder, s.appendIDs, s.appendFuncs, s.appendLayouts)
+	if len(prependFuncs) != len(prependWs.listen) || len(appendFuncs) != len(appendWs.listen) {
 		return []byte{}
 	}
 	if termWidth <= 0 {
 		termWidth = s.width
 	}
+	if termWidth < 0 {
+		termWidth = 0
+	}
 
 	stat := newStatistics(s)
 
+	shared := decorBufPool.Get().(*[]byte)
+	defer putDecorBuf(shared)
 	// render prepend functions to the left of the bar
-	var prependBlock []byte
-	for i, f := range s.prependFuncs {
-		prependBlock = append(prependBlock,
-			[]byte(f(stat, prependWs.listen[i], prependWs.result[i]))...)
-	}
-
+	prepends := renderDecorators(shared, prependFuncs, prependLayouts, stat, prependWs)
 	// render append functions to the right of the bar
-	var appendBlock []byte
-	for i, f := range s.appendFuncs {
-		appendBlock = append(appendBlock,
-			[]byte(f(stat, appendWs.listen[i], appendWs.result[i]))...)
-	}
+	appends := renderDecorators(shared, appendFuncs, appendLayouts, stat, appendWs)
 
-	prependCount := utf8.RuneCount(prependBlock)
-	appendCount := utf8.RuneCount(appendBlock)
+	// decorators are rendered anyway, as their width is synced with other bars
+	if s.completed && s.clearOnComplete {
+		return []byte{}
+	}
+	if s.completed && s.completedLine != "" {
+		return []byte(truncateVisible(s.completedLine, termWidth))
+	}
 
 	var leftSpace, rightSpace []byte
 	space := []byte{' '}
 
+	spaceCount := 0
 	if !s.trimLeftSpace {
-		prependCount++
+		spaceCount++
 		leftSpace = space
 	}
 	if !s.trimRightSpace {
-		appendCount++
+		spaceCount++
 		rightSpace = space
 	}
 
 	var barBlock []byte
-	buf := make([]byte, 0, termWidth)
 	fmtBytes := convertFmtRunesToBytes(s.format)
+	if s.rollbackTip != 0 && time.Since(s.rolledBackAt) < s.rollbackCue {
+		fmtBytes[rTip] = []byte(string(s.rollbackTip))
+	}
 
-	if s.simpleSpinner != nil {
-		for _, block := range [...][]byte{fmtBytes[rLeft], []byte{s.simpleSpinner()}, fmtBytes[rRight]} {
+	// spinner body isn't a fill, so fill effects don't apply to it
+	spinner := s.simpleSpinner != nil || s.spinnerSet != nil
+	if s.spinnerSet != nil {
+		barBlock = []byte(s.spinnerFrame(time.Now()))
+	} else if s.simpleSpinner != nil {
+		for _, block := range [...][]byte{fmtBytes[rLeft], []byte{s.simpleSpinner(s)}, fmtBytes[rRight]} {
 			barBlock = append(barBlock, block...)
 		}
-		return concatenateBlocks(buf, prependBlock, leftSpace, barBlock, rightSpace, appendBlock)
+	} else {
+		barBlock = s.fill(s.width, fmtBytes, time.Now())
 	}
 
-	barBlock = fillBar(s.total, s.current, s.width, fmtBytes, s.refill)
 	barCount := utf8.RuneCount(barBlock)
-	totalCount := prependCount + barCount + appendCount
-	if totalCount > termWidth {
-		newWidth := termWidth - prependCount - appendCount
-		barBlock = fillBar(s.total, s.current, newWidth, fmtBytes, s.refill)
+	overflow := decoratorsWidth(prepends) + decoratorsWidth(appends) + spaceCount + barCount - termWidth
+
+	// shrink the bar first, down to its min width
+	if overflow > 0 && !spinner && barCount > s.minWidth {
+		newWidth := barCount - overflow
+		if newWidth < s.minWidth {
+			newWidth = s.minWidth
+		}
+		barBlock = s.fill(newWidth, fmtBytes, time.Now())
+		newCount := utf8.RuneCount(barBlock)
+		overflow -= barCount - newCount
+	}
+
+	// then take width from decorators, lowest priority first
+	if overflow > 0 {
+		overflow = fitDecorators(append(prepends, appends...), overflow)
+	}
+
+	if s.secondary > s.current && !spinner {
+		r := s.secondaryRune
+		if r == 0 {
+			r = '+'
+		}
+		barBlock = fillSecondary(barBlock, s.total, s.current, s.secondary, r)
+	}
+
+	if len(s.markers) > 0 && !spinner {
+		barBlock = placeMarkers(barBlock, s.markers, s.markerRune)
 	}
 
-	return concatenateBlocks(buf, prependBlock, leftSpace, barBlock, rightSpace, appendBlock)
+	if s.failedSGR != "" && s.failed > 0 && !spinner {
+		barBlock = colorFailed(barBlock, s.total, s.failed, s.failedSGR, s.sgr)
+	}
+
+	// animate last, over the final body, so that escape sequences of the
+	// animation frame aren't cut by the above
+	if s.completionAnim != nil && s.completed && !spinner {
+		barBlock = s.animate(barBlock, time.Now())
+	}
+
+	if s.sgr != "" && len(barBlock) > 0 {
+		barBlock = []byte(fmt.Sprintf("%c[%sm%s%c[0m", 27, s.sgr, barBlock, 27))
+	}
+
+	buf := make([]byte, 0, termWidth)
+	for _, d := range prepends {
+		buf = append(buf, d.buf...)
+	}
+	buf = concatenateBlocks(buf, leftSpace, barBlock, rightSpace)
+	for _, d := range appends {
+		buf = append(buf, d.buf...)
+	}
+
+	// finally, never let the line wrap
+	if overflow > 0 {
+		buf = []byte(truncateVisible(string(buf), termWidth))
+	}
+	if s.lineHook != nil {
+		buf = s.lineHook(stat, buf)
+	}
+	return buf
+}
+
+// measureDecorators renders decorators of s, so their widths are reported
+// to prependWs and appendWs. Bar body isn't drawn and stateful decorators
+// don't advance, see Measuring.
+func measureDecorators(s *state, prependWs, appendWs *widthSync) {
+	prependFuncs, prependLayouts := s.prependColumns()
+	appendFuncs, appendLayouts := arrangeColumns(s.appendOrder, s.appendIDs, s.appendFuncs, s.appendLayouts)
+	if len(prependFuncs) != len(prependWs.listen) || len(appendFuncs) != len(appendWs.listen) {
+		return
+	}
+	stat := newStatistics(s)
+	stat.measuring = true
+	shared := decorBufPool.Get().(*[]byte)
+	defer putDecorBuf(shared)
+	renderDecorators(shared, prependFuncs, prependLayouts, stat, prependWs)
+	renderDecorators(shared, appendFuncs, appendLayouts, stat, appendWs)
+}
+
+// Layout defines how decorator gives up its width, when the line doesn't fit
+// terminal width. The bar itself is shrunk first, down to its min width, see
+// (*Bar).SetMinWidth.
+type Layout struct {
+	// Priority: decorators with lower priority give up width first.
+	// On equal priority, the rightmost decorator gives up first.
+	Priority int
+	// MinWidth: decorator is truncated down to MinWidth, before it's dropped.
+	// Zero means it's dropped without truncation.
+	MinWidth int
+	// MaxWidth: decorator is always truncated to MaxWidth.
+	// Zero means no limit.
+	MaxWidth int
+}
+
+type decoratorOutput struct {
+	buf    []byte
+	width  int
+	layout Layout
+}
+
+// decorBufPool keeps shared buffers of renderDecorators across frames
+var decorBufPool = sync.Pool{New: func() interface{} {
+	return new([]byte)
+}}
+
+func putDecorBuf(shared *[]byte) {
+	*shared = (*shared)[:0]
+	decorBufPool.Put(shared)
+}
+
+// renderDecorators renders funcs into shared buffer, appending to it, outputs
+// refer to its parts. Shared buffer is reused across frames, see
+// decorBufPool, so outputs are valid until it's put back.
+func renderDecorators(shared *[]byte, funcs []BytesDecoratorFunc, layouts []Layout, stat *Statistics, ws *widthSync) []*decoratorOutput {
+	outputs := make([]*decoratorOutput, len(funcs))
+	for i, f := range funcs {
+		start := len(*shared)
+		*shared = f(*shared, stat, ws.listen[i], ws.result[i])
+		d := &decoratorOutput{buf: (*shared)[start:len(*shared):len(*shared)]}
+		if i < len(layouts) {
+			d.layout = layouts[i]
+		}
+		if d.layout.MaxWidth > 0 {
+			d.buf = truncateVisibleBytes(d.buf, d.layout.MaxWidth)
+		}
+		d.width = visibleRuneCount(d.buf)
+		outputs[i] = d
+	}
+	return outputs
+}
+
+// truncateVisibleBytes is truncateVisible for bytes, b is returned as is, if
+// it fits
+func truncateVisibleBytes(b []byte, width int) []byte {
+	if visibleRuneCount(b) <= width {
+		return b
+	}
+	return []byte(truncateVisible(string(b), width))
+}
+
+// arrangeColumns returns funcs and layouts in declared column order. Column,
+// which the bar doesn't have, is filled with blank decorator. Decorators
+// without declared column aren't rendered. Nil order means insertion order.
+func arrangeColumns(order, ids []string, funcs []BytesDecoratorFunc, layouts []Layout) ([]BytesDecoratorFunc, []Layout) {
+	if order == nil {
+		return funcs, layouts
+	}
+	arrangedFuncs := make([]BytesDecoratorFunc, len(order))
+	arrangedLayouts := make([]Layout, len(order))
+	for i, column := range order {
+		arrangedFuncs[i] = blankColumn
+		for j, id := range ids {
+			if id != column || j >= len(funcs) {
+				continue
+			}
+			arrangedFuncs[i] = funcs[j]
+			if j < len(layouts) {
+				arrangedLayouts[i] = layouts[j]
+			}
+			break
+		}
+	}
+	return arrangedFuncs, arrangedLayouts
+}
+
+// blankColumn fills column, which the bar doesn't have, keeping width sync
+func blankColumn(dst []byte, s *Statistics, myWidth chan<- int, maxWidth <-chan int) []byte {
+	return AppendPadded(dst, nil, 0, DwidthSync, myWidth, maxWidth)
+}
+
+// appendID appends column id, keeping ids aligned with n decorators
+func appendID(ids []string, n int, id string) []string {
+	for len(ids) < n-1 {
+		ids = append(ids, "")
+	}
+	return append(ids, id)
+}
+
+func decoratorsWidth(outputs []*decoratorOutput) int {
+	var n int
+	for _, d := range outputs {
+		n += d.width
+	}
+	return n
+}
+
+// fitDecorators truncates or drops decorators, until overflow is eliminated.
+// Returns remaining overflow.
+func fitDecorators(outputs []*decoratorOutput, overflow int) int {
+	for overflow > 0 {
+		var victim *decoratorOutput
+		for _, d := range outputs {
+			if d.width == 0 {
+				continue
+			}
+			if victim == nil || d.layout.Priority <= victim.layout.Priority {
+				victim = d
+			}
+		}
+		if victim == nil {
+			break
+		}
+		if victim.width > victim.layout.MinWidth && victim.layout.MinWidth > 0 {
+			cut := victim.width - victim.layout.MinWidth
+			if cut > overflow {
+				cut = overflow
+			}
+			victim.width -= cut
+			victim.buf = truncateVisibleBytes(victim.buf, victim.width)
+			overflow -= cut
+			continue
+		}
+		overflow -= victim.width
+		victim.width = 0
+		victim.buf = nil
+	}
+	return overflow
 }
 
 func concatenateBlocks(buf []byte, blocks ...[]byte) []byte {
@@ -442,13 +1365,17 @@ func fillBar(total, current int64, width int, fmtBytes barFmtBytes, rf *refill)
 	// bar width without leftEnd and rightEnd runes
 	barWidth := width - 2
 
-	completedWidth := percentage(total, current, barWidth)
+	completedWidth := internal.Percentage(total, current, barWidth)
 
 	buf := make([]byte, 0, width)
 	buf = append(buf, fmtBytes[rLeft]...)
 
 	if rf != nil {
-		till := percentage(total, rf.till, barWidth)
+		till := internal.Percentage(total, rf.till, barWidth)
+		// progress may have been rolled back below refill
+		if till > completedWidth {
+			till = completedWidth
+		}
 		rbytes := make([]byte, utf8.RuneLen(rf.char))
 		utf8.EncodeRune(rbytes, rf.char)
 		// append refill rune
@@ -479,12 +1406,121 @@ func fillBar(total, current int64, width int, fmtBytes barFmtBytes, rf *refill)
 	return buf
 }
 
+// fillSecondary replaces empty cells of the bar block between current and
+// secondary fill levels with r
+func fillSecondary(barBlock []byte, total, current, secondary int64, r rune) []byte {
+	runes := []rune(string(barBlock))
+	if len(runes) < 2 {
+		return barBlock
+	}
+	barWidth := len(runes) - 2
+	for i := internal.Percentage(total, current, barWidth); i < internal.Percentage(total, secondary, barWidth); i++ {
+		runes[i+1] = r
+	}
+	return []byte(string(runes))
+}
+
+// colorFailed colors cells of the failed fraction of the bar block, which
+// follow its left end. Color of the bar, if any, is restored after them.
+func colorFailed(barBlock []byte, total, failed int64, sgr, barSGR string) []byte {
+	runes := []rune(string(barBlock))
+	if len(runes) < 2 {
+		return barBlock
+	}
+	failedWidth := internal.Percentage(total, failed, len(runes)-2)
+	if failedWidth == 0 {
+		return barBlock
+	}
+	restore := fmt.Sprintf("%c[0m", 27)
+	if barSGR != "" {
+		restore += fmt.Sprintf("%c[%sm", 27, barSGR)
+	}
+	return []byte(fmt.Sprintf("%c%c[%sm%s%s%s", runes[0], 27, sgr,
+		string(runes[1:failedWidth+1]), restore, string(runes[failedWidth+1:])))
+}
+
+// visibleRuneCount counts runes in b, skipping ANSI escape sequences
+func visibleRuneCount(b []byte) int {
+	var n int
+	for len(b) > 0 {
+		if b[0] == 27 && len(b) > 1 {
+			b = skipEscape(b)
+			continue
+		}
+		_, size := utf8.DecodeRune(b)
+		b = b[size:]
+		n++
+	}
+	return n
+}
+
+// stripEscapes returns b without ANSI escape sequences
+func stripEscapes(b []byte) []byte {
+	if bytes.IndexByte(b, 27) < 0 {
+		return b
+	}
+	buf := make([]byte, 0, len(b))
+	for len(b) > 0 {
+		if b[0] == 27 && len(b) > 1 {
+			b = skipEscape(b)
+			continue
+		}
+		buf = append(buf, b[0])
+		b = b[1:]
+	}
+	return buf
+}
+
+// skipEscape skips escape sequence at the beginning of b
+func skipEscape(b []byte) []byte {
+	switch b[1] {
+	case '[':
+		// CSI: parameters, then final byte in range 0x40-0x7E
+		for i := 2; i < len(b); i++ {
+			if b[i] >= 0x40 && b[i] <= 0x7E {
+				return b[i+1:]
+			}
+		}
+		return nil
+	case ']':
+		// OSC: terminated by BEL or ST
+		for i := 2; i < len(b); i++ {
+			if b[i] == 7 {
+				return b[i+1:]
+			}
+			if b[i] == 27 && i+1 < len(b) && b[i+1] == '\\' {
+				return b[i+2:]
+			}
+		}
+		return nil
+	default:
+		return b[2:]
+	}
+}
+
 func newStatistics(s *state) *Statistics {
+	var started time.Time
+	if !s.completed {
+		started = s.timeStarted
+	}
 	return &Statistics{
-		Total:               s.total,
-		Current:             s.current,
-		TimeElapsed:         s.timeElapsed,
-		TimePerItemEstimate: s.timePerItem,
+		Total:                s.total,
+		Current:              s.current,
+		TimeElapsed:          s.timeElapsed,
+		TimePerItemEstimate:  s.timePerItem,
+		Priority:             s.priority,
+		Pinned:               s.pinned,
+		Updated:              s.updated,
+		TimePerItemDeviation: time.Duration(math.Sqrt(s.tpiVariance)),
+		RateCap:              s.rateCap,
+		Attempt:              s.attempt,
+		MaxAttempts:          s.maxAttempts,
+		Failed:               s.failed,
+		Secondary:            s.secondary,
+		RolledBack:           s.rolledBack,
+		Aborted:              s.aborted,
+		EwmaTimePerItem:      s.ewmaTimePerItem,
+		started:              started,
 	}
 }
 
@@ -498,36 +1534,376 @@ func convertFmtRunesToBytes(format barFmtRunes) barFmtBytes {
 	return fmtBytes
 }
 
-func calcTimePerItemEstimate(tpie time.Duration, blockStartTime time.Time, alpha float64, items int64) time.Duration {
-	lastBlockTime := time.Since(blockStartTime)
+// incr increments current by n. Durations are computed as differences of
+// time.Time values, obtained in the same process, so they're based on
+// monotonic clock and aren't affected by system clock changes.
+func (s *state) incr(n int64, now time.Time) {
+	prevStartTime := s.updated
+	s.updated = now
+	current := s.current + n
+	if s.totalPending && current > s.total {
+		// total grows, until it's final
+		s.total = current
+	}
+	if s.total > 0 && current > s.total {
+		s.current = s.total
+		s.completed = true
+		return
+	}
+	s.timeElapsed = now.Sub(s.timeStarted)
+	if s.sampleInterval > 0 {
+		// estimated on the next sample
+		s.sampledItems += n
+	} else {
+		s.estimate(now.Sub(prevStartTime), n)
+	}
+	if current == s.total && !s.totalPending {
+		s.completed = true
+	}
+	s.current = current
+}
+
+// fill renders bar body of width. In time axis mode fill is elapsed time
+// of expected duration, and progress is a marker.
+func (s *state) fill(width int, fmtBytes barFmtBytes, now time.Time) []byte {
+	if s.expectedDuration <= 0 {
+		return fillBar(s.total, s.current, width, fmtBytes, s.refill)
+	}
+	elapsed := now.Sub(s.timeStarted)
+	if s.completed {
+		elapsed = s.timeElapsed
+	}
+	if elapsed > s.expectedDuration {
+		elapsed = s.expectedDuration
+	}
+	barBlock := fillBar(int64(s.expectedDuration), int64(elapsed), width, fmtBytes, nil)
+	if s.total > 0 && s.progressMarker != 0 {
+		fraction := float64(s.current) / float64(s.total)
+		barBlock = placeMarkers(barBlock, []float64{math.Min(fraction, 1)}, s.progressMarker)
+	}
+	return barBlock
+}
+
+// placeMarkers replaces runes of the bar body, between its edges, at
+// fractions with r
+func placeMarkers(barBlock []byte, fractions []float64, r rune) []byte {
+	runes := []rune(string(barBlock))
+	inner := len(runes) - 2
+	if inner <= 0 {
+		return barBlock
+	}
+	for _, f := range fractions {
+		i := int(f * float64(inner))
+		if i == inner {
+			i--
+		}
+		runes[i+1] = r
+	}
+	return []byte(string(runes))
+}
+
+// decr rolls current back by n, see Decr
+func (s *state) decr(n int64, now time.Time) {
+	if s.completed {
+		return
+	}
+	if n > s.current {
+		n = s.current
+	}
+	s.current -= n
+	s.rolledBack += n
+	s.rolledBackAt = now
+	if s.failed > s.current {
+		s.failed = s.current
+	}
+}
+
+// setTotal sets total, see SetTotal
+func (s *state) setTotal(total int64, final bool) {
+	if s.completed {
+		return
+	}
+	s.total = total
+	s.totalPending = !final
+	if !final {
+		if s.total < s.current {
+			s.total = s.current
+		}
+		if s.simpleSpinner == nil {
+			s.simpleSpinner = getSpinner()
+		}
+		return
+	}
+	if s.total <= 0 {
+		// still unknown
+		return
+	}
+	s.simpleSpinner = nil
+	if s.current >= s.total {
+		s.current = s.total
+		s.completed = true
+	}
+}
+
+type milestone struct {
+	threshold float64
+	fn        func(*Bar)
+	fired     bool
+}
+
+// fireMilestones calls fn of reached milestones, in order of registration
+func (s *state) fireMilestones(b *Bar) {
+	if len(s.milestones) == 0 || s.total <= 0 {
+		return
+	}
+	percent := float64(s.current) * 100 / float64(s.total)
+	var fired []func(*Bar)
+	for _, m := range s.milestones {
+		if !m.fired && percent >= m.threshold {
+			m.fired = true
+			fired = append(fired, m.fn)
+		}
+	}
+	if len(fired) == 0 {
+		return
+	}
+	go func() {
+		for _, fn := range fired {
+			fn(b)
+		}
+	}()
+}
+
+type deadline struct {
+	limit    time.Duration
+	fn       func(*Bar)
+	style    func(s *state, exceeded bool)
+	exceeded bool
+}
+
+// checkDeadlines triggers deadlines, which ETA has crossed
+func (s *state) checkDeadlines(b *Bar) {
+	if len(s.deadlines) == 0 || s.total <= 0 || s.timePerItem <= 0 {
+		return
+	}
+	eta := time.Duration(s.total-s.current) * s.timePerItem
+	for _, d := range s.deadlines {
+		exceeded := eta > d.limit && !s.completed
+		if exceeded == d.exceeded {
+			continue
+		}
+		d.exceeded = exceeded
+		if d.style != nil {
+			d.style(s, exceeded)
+		}
+		if exceeded && d.fn != nil {
+			go d.fn(b)
+		}
+	}
+}
+
+// estimate updates per item time estimate with items done in lastBlockTime
+func (s *state) estimate(lastBlockTime time.Duration, items int64) {
+	s.updateVariance(lastBlockTime, items)
+	if cap(s.etaWindow) > 0 {
+		s.timePerItem = s.medianTimePerItem(lastBlockTime, items)
+	} else {
+		s.timePerItem = calcTimePerItemEstimate(s.timePerItem, lastBlockTime, s.etaAlpha, items)
+	}
+}
+
+// sample feeds items, accumulated since the last sample, to the estimator
+func (s *state) sample(now time.Time) {
+	if s.sampledItems == 0 {
+		return
+	}
+	s.estimate(now.Sub(s.lastSample), s.sampledItems)
+	s.sampledItems = 0
+	s.lastSample = now
+}
+
+// takeRemaining takes up to amount of remaining work off the bar
+func (s *state) takeRemaining(amount int64) int64 {
+	if s.completed || s.total <= 0 {
+		return 0
+	}
+	if remaining := s.total - s.current; amount > remaining {
+		amount = remaining
+	}
+	s.total -= amount
+	s.completed = s.current >= s.total
+	return amount
+}
+
+// acceptWork adds amount to total, unless the bar has completed
+func (s *state) acceptWork(amount int64) bool {
+	if s.completed {
+		return false
+	}
+	s.addToTotal(amount)
+	return true
+}
+
+func (s *state) addToTotal(delta int64) {
+	if s.completed {
+		return
+	}
+	s.total += delta
+	if s.total <= 0 {
+		return
+	}
+	s.simpleSpinner = nil
+	if s.current >= s.total {
+		s.current = s.total
+		s.completed = true
+	}
+}
+
+// resume restores progress of previous session, so rate isn't computed
+// across the gap between sessions
+func (s *state) resume(current int64, elapsed time.Duration, now time.Time) {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	if s.total > 0 && current >= s.total {
+		current = s.total
+		s.completed = true
+	}
+	s.current = current
+	s.timeElapsed = elapsed
+	s.timeStarted = now.Add(-elapsed)
+	s.updated = now
+	if current > 0 {
+		s.timePerItem = elapsed / time.Duration(current)
+	}
+}
+
+func (s *state) setAttempt(n, max int, now time.Time) {
+	if s.completed {
+		return
+	}
+	if n > s.attempt && s.attempt > 0 {
+		s.current = 0
+		s.failed = 0
+		s.secondary = 0
+		s.refill = nil
+		s.timePerItem = 0
+		s.tpiMean, s.tpiVariance = 0, 0
+		s.etaWindow = make([]time.Duration, 0, cap(s.etaWindow))
+		s.etaWindowPos = 0
+		s.sampledItems = 0
+		s.lastSample = now
+		s.updated = now
+	}
+	s.attempt, s.maxAttempts = n, max
+}
+
+func calcTimePerItemEstimate(tpie, lastBlockTime time.Duration, alpha float64, items int64) time.Duration {
+	if lastBlockTime < 0 {
+		lastBlockTime = 0
+	}
 	lastItemEstimate := float64(lastBlockTime) / float64(items)
 	return time.Duration((alpha * lastItemEstimate) + (1-alpha)*float64(tpie))
 }
 
-func percentage(total, current int64, ratio int) int {
-	if current > total {
+// updateVariance updates exponential-weighted variance of per item time
+func (s *state) updateVariance(lastBlockTime time.Duration, items int64) {
+	if lastBlockTime < 0 {
+		lastBlockTime = 0
+	}
+	x := float64(lastBlockTime) / float64(items)
+	if s.tpiMean == 0 && s.tpiVariance == 0 {
+		s.tpiMean = x
+		return
+	}
+	diff := x - s.tpiMean
+	incr := s.etaAlpha * diff
+	s.tpiMean += incr
+	s.tpiVariance = (1 - s.etaAlpha) * (s.tpiVariance + diff*incr)
+}
+
+// medianTimePerItem adds per item time of the last block to the window and
+// returns median of the window
+func (s *state) medianTimePerItem(lastBlockTime time.Duration, items int64) time.Duration {
+	if lastBlockTime < 0 {
+		lastBlockTime = 0
+	}
+	d := lastBlockTime / time.Duration(items)
+	if len(s.etaWindow) < cap(s.etaWindow) {
+		s.etaWindow = append(s.etaWindow, d)
+	} else {
+		s.etaWindow[s.etaWindowPos] = d
+		s.etaWindowPos = (s.etaWindowPos + 1) % len(s.etaWindow)
+	}
+	sorted := make(durations, len(s.etaWindow))
+	copy(sorted, s.etaWindow)
+	sort.Sort(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+type durations []time.Duration
+
+func (d durations) Len() int           { return len(d) }
+func (d durations) Less(i, j int) bool { return d[i] < d[j] }
+func (d durations) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+
+// SpinnerSpeed maps observed activity of the bar, in increments per second,
+// to spinner speed in frames per second
+type SpinnerSpeed func(rate float64) float64
+
+// ProportionalSpinnerSpeed advances spinner one frame per n increments, so
+// the spinner stops, when the bar is idle
+func ProportionalSpinnerSpeed(n float64) SpinnerSpeed {
+	return func(rate float64) float64 {
+		return rate / n
+	}
+}
+
+// SetSpinnerSpeed makes spinner of the bar, with unknown total, advance
+// according to observed activity instead of one frame per refresh.
+// Nil restores the default.
+func (b *Bar) SetSpinnerSpeed(fn SpinnerSpeed) *Bar {
+	b.operate(func(s *state) {
+		s.spinnerSpeed = fn
+	})
+	return b
+}
+
+// rate returns recent increments per second, which decays, while the bar
+// is idle
+func (s *state) rate(now time.Time) float64 {
+	if s.timePerItem <= 0 {
 		return 0
 	}
-	num := float64(ratio) * float64(current) / float64(total)
-	ceil := math.Ceil(num)
-	diff := ceil - num
-	// num = 2.34 will return 2
-	// num = 2.44 will return 3
-	if math.Max(diff, 0.6) == diff {
-		return int(num)
+	tpi := s.timePerItem
+	if idle := now.Sub(s.updated); idle > tpi {
+		tpi = idle
 	}
-	return int(ceil)
+	return float64(time.Second) / float64(tpi)
 }
 
-func getSpinner() func() byte {
+func getSpinner() func(*state) byte {
 	chars := []byte(`-\|/`)
-	repeat := len(chars) - 1
-	index := repeat
-	return func() byte {
-		if index == repeat {
-			index = -1
-		}
-		index++
-		return chars[index]
+	var phase float64
+	var last time.Time
+	return func(s *state) byte {
+		if s.spinnerSpeed == nil {
+			phase++
+		} else {
+			now := time.Now()
+			if !last.IsZero() {
+				fps := s.spinnerSpeed(s.rate(now))
+				if fps > 0 && !math.IsInf(fps, 1) {
+					phase += fps * now.Sub(last).Seconds()
+				}
+			}
+			last = now
+		}
+		phase = math.Mod(phase, float64(len(chars)))
+		return chars[int(phase)]
 	}
 }
@@ -0,0 +1,19 @@
+//+build go1.7
+
+package mpb
+
+import "context"
+
+type barContextKey struct{}
+
+// NewContext returns a copy of ctx, which carries bar. Use it to pass the bar
+// down to deep call stacks, like http middlewares or pipeline stages.
+func NewContext(ctx context.Context, bar *Bar) context.Context {
+	return context.WithValue(ctx, barContextKey{}, bar)
+}
+
+// FromContext returns the bar stored in ctx by NewContext, or nil
+func FromContext(ctx context.Context) *Bar {
+	bar, _ := ctx.Value(barContextKey{}).(*Bar)
+	return bar
+}
@@ -0,0 +1,23 @@
+//+build go1.7
+
+package mpb
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+)
+
+func TestBarContext(t *testing.T) {
+	if bar := FromContext(context.Background()); bar != nil {
+		t.Errorf("Want: nil, Got: %v\n", bar)
+	}
+	p := New().SetOut(ioutil.Discard)
+	bar := p.AddBar(10)
+	ctx := NewContext(context.Background(), bar)
+	if got := FromContext(ctx); got != bar {
+		t.Errorf("Want: %p, Got: %p\n", bar, got)
+	}
+	bar.Completed()
+	p.Stop()
+}
@@ -1,8 +1,16 @@
 package mpb
 
 import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/vbauerster/mpb/internal"
 )
 
 func TestFillBar(t *testing.T) {
@@ -109,3 +117,724 @@ func newTestState() *state {
 		trimRightSpace: true,
 	}
 }
+
+func BenchmarkDraw(b *testing.B) {
+	prependWs := newWidthSync(nil, 1, 0)
+	appendWs := newWidthSync(nil, 1, 0)
+	s := newTestState()
+	s.width = 80
+	s.total = 100
+	s.current = 40
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		draw(s, 100, prependWs, appendWs)
+	}
+}
+
+func BenchmarkDrawDecorated(b *testing.B) {
+	s := newTestState()
+	s.width = 80
+	s.total = 100
+	s.current = 40
+	s.prependFuncs = toBytesFuncs([]DecoratorFunc{
+		func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+			return "Bar#1:"
+		},
+	})
+	s.appendFuncs = toBytesFuncs([]DecoratorFunc{
+		func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+			return fmt.Sprintf("%d %%", internal.Percentage(s.Total, s.Current, 100))
+		},
+	})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		draw(s, 100, presetWidthSync([]int{0}), presetWidthSync([]int{0}))
+	}
+}
+
+func BenchmarkDrawStockDecorators(b *testing.B) {
+	s := newTestState()
+	s.width = 80
+	s.total = 100
+	s.current = 40
+	s.prependFuncs = []BytesDecoratorFunc{NameBytes("Bar#1:", 0, 0)}
+	s.appendFuncs = []BytesDecoratorFunc{PercentageBytes(5, 0), ETABytes(0, 0)}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		draw(s, 100, presetWidthSync([]int{0}), presetWidthSync([]int{0, 0}))
+	}
+}
+
+func BenchmarkDrawBytesDecorated(b *testing.B) {
+	s := newTestState()
+	s.width = 80
+	s.total = 100
+	s.current = 40
+	s.prependFuncs = []BytesDecoratorFunc{
+		func(dst []byte, s *Statistics, myWidth chan<- int, maxWidth <-chan int) []byte {
+			return append(dst, "Bar#1:"...)
+		},
+	}
+	s.appendFuncs = []BytesDecoratorFunc{
+		func(dst []byte, s *Statistics, myWidth chan<- int, maxWidth <-chan int) []byte {
+			dst = strconv.AppendInt(dst, int64(internal.Percentage(s.Total, s.Current, 100)), 10)
+			return append(dst, " %"...)
+		},
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		draw(s, 100, presetWidthSync([]int{0}), presetWidthSync([]int{0}))
+	}
+}
+
+func TestBytesDecorator(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetWidth(12)
+	bar := p.AddBar(10).PrependBytesFunc(func(dst []byte, s *Statistics, myWidth chan<- int, maxWidth <-chan int) []byte {
+		return AppendPadded(dst, []byte("ab"), 4, DidentRight, myWidth, maxWidth)
+	}).AppendBytesFunc(BytesFunc(Name("cd", 3, 0)))
+	if n := len(bar.GetPrependers()); n != 1 {
+		t.Errorf("Want 1 prepender, got: %d\n", n)
+	}
+	bar.Incr(10)
+	p.Stop()
+	want := "ab   [=]  cd\n"
+	if !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("Want suffix %q, got: %q\n", want, buf.String())
+	}
+}
+
+func TestAppendPadded(t *testing.T) {
+	tests := []struct {
+		minWidth int
+		conf     byte
+		want     string
+	}{
+		{0, 0, ">ab"},
+		{4, 0, ">  ab"},
+		{4, DidentRight, ">ab  "},
+		{0, DwidthSync, ">   ab"},
+		{0, DwidthSync | DextraSpace | DidentRight, ">ab    "},
+	}
+	for _, test := range tests {
+		myWidth, maxWidth := make(chan int, 1), make(chan int, 1)
+		maxWidth <- 5
+		got := string(AppendPadded([]byte(">"), []byte("ab"), test.minWidth, test.conf, myWidth, maxWidth))
+		if got != test.want {
+			t.Errorf("Want: %q, Got: %q\n", test.want, got)
+		}
+	}
+}
+
+func TestDecoratorHandle(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	bar := p.AddBar(10)
+	h := bar.PrependHandle(Name("foo", 0, 0))
+	bar.PrependName("bar", 0, 0)
+	h.Set(Name("baz", 0, 0))
+	hb := bar.AppendBytesHandle(NameBytes("foo", 0, 0))
+	hb.SetBytes(NameBytes("quux", 0, 0))
+
+	prependers := bar.GetPrependers()
+	if len(prependers) != 2 {
+		t.Fatalf("Prependers want: %d, got: %d\n", 2, len(prependers))
+	}
+	if got := prependers[0](bar.GetStatistics(), nil, nil); got != "baz" {
+		t.Errorf("Want: %q, Got: %q\n", "baz", got)
+	}
+	appenders := bar.GetAppenders()
+	if len(appenders) != 1 {
+		t.Fatalf("Appenders want: %d, got: %d\n", 1, len(appenders))
+	}
+	if got := appenders[0](bar.GetStatistics(), nil, nil); got != "quux" {
+		t.Errorf("Want: %q, Got: %q\n", "quux", got)
+	}
+
+	bar.RemoveAllPrependers()
+	h.Set(Name("qux", 0, 0))
+	if n := bar.NumOfPrependers(); n != 0 {
+		t.Errorf("Prependers want: %d, got: %d\n", 0, n)
+	}
+	bar.Completed()
+	p.Stop()
+}
+
+func TestBarMeta(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	bar := p.AddBar(10).SetMeta("name", "foo").SetMeta("priority", 2)
+	if got := bar.Meta("name"); got != "foo" {
+		t.Errorf("Want: %q, Got: %v\n", "foo", got)
+	}
+	if got := bar.Meta("priority"); got != 2 {
+		t.Errorf("Want: %d, Got: %v\n", 2, got)
+	}
+	if got := bar.Meta("none"); got != nil {
+		t.Errorf("Want: nil, Got: %v\n", got)
+	}
+	bar.Completed()
+	p.Stop()
+	if got := bar.Meta("name"); got != "foo" {
+		t.Errorf("After stop want: %q, Got: %v\n", "foo", got)
+	}
+}
+
+func TestDrawLayout(t *testing.T) {
+	tests := []struct {
+		termWidth int
+		want      string
+	}{
+		{40, "name-long[========>---------]50 %"},
+		{20, "name-long[===>----]"},
+		{14, "name[===>----]"},
+		{12, "[===>----]"},
+		{6, "[===>-"},
+	}
+	for _, test := range tests {
+		s := newTestState()
+		s.width = 20
+		s.minWidth = 10
+		s.total = 100
+		s.current = 50
+		s.prependFuncs = toBytesFuncs([]DecoratorFunc{Name("name-long", 0, 0)})
+		s.prependLayouts = []Layout{{Priority: 1, MinWidth: 4}}
+		s.appendFuncs = toBytesFuncs([]DecoratorFunc{Percentage(0, 0)})
+		got := draw(s, test.termWidth, presetWidthSync([]int{0}), presetWidthSync([]int{0}))
+		if string(got) != test.want {
+			t.Errorf("termWidth %d: Want: %q, Got: %q\n", test.termWidth, test.want, got)
+		}
+	}
+}
+
+func TestStateResume(t *testing.T) {
+	now := time.Now()
+	s := newTestState()
+	s.total = 100
+	s.etaAlpha = 0.25
+	s.resume(40, 40*time.Second, now)
+	if s.current != 40 || s.timeElapsed != 40*time.Second {
+		t.Errorf("Want: 40 40s, Got: %d %v\n", s.current, s.timeElapsed)
+	}
+	if s.timePerItem != time.Second {
+		t.Errorf("Want timePerItem: %v, Got: %v\n", time.Second, s.timePerItem)
+	}
+
+	// wall clock went backwards, negative block time must be ignored
+	s.incr(1, now.Add(-time.Hour))
+	if s.timePerItem <= 0 || s.timePerItem > time.Second {
+		t.Errorf("Unexpected timePerItem: %v\n", s.timePerItem)
+	}
+
+	s.resume(200, time.Minute, now)
+	if !s.completed || s.current != 100 {
+		t.Errorf("Want completed at 100, Got: %v %d\n", s.completed, s.current)
+	}
+}
+
+func TestSpinnerSpeed(t *testing.T) {
+	s := newTestState()
+	spinner := getSpinner()
+	if a, b := spinner(s), spinner(s); a == b {
+		t.Errorf("Default spinner doesn't advance: %q %q\n", a, b)
+	}
+
+	// idle bar, spinner must freeze
+	s.spinnerSpeed = ProportionalSpinnerSpeed(1)
+	spinner = getSpinner()
+	first := spinner(s)
+	for i := 0; i < 3; i++ {
+		time.Sleep(time.Millisecond)
+		if c := spinner(s); c != first {
+			t.Errorf("Idle spinner advanced: %q -> %q\n", first, c)
+		}
+	}
+
+	s.updated = time.Now()
+	s.timePerItem = time.Millisecond
+	time.Sleep(2 * time.Millisecond)
+	if c := spinner(s); c == first {
+		t.Errorf("Active spinner didn't advance: %q\n", c)
+	}
+}
+
+func TestEtaMedianWindow(t *testing.T) {
+	s := newTestState()
+	s.total = 100
+	s.etaWindow = make([]time.Duration, 0, 5)
+	now := time.Now()
+	s.updated = now
+	blocks := []time.Duration{
+		10 * time.Millisecond,
+		10 * time.Millisecond,
+		5 * time.Second, // stall
+		10 * time.Millisecond,
+		10 * time.Millisecond,
+		10 * time.Millisecond,
+	}
+	for _, d := range blocks {
+		now = now.Add(d)
+		s.incr(1, now)
+	}
+	if s.timePerItem != 10*time.Millisecond {
+		t.Errorf("Want: %v, Got: %v\n", 10*time.Millisecond, s.timePerItem)
+	}
+}
+
+func TestStateAddToTotal(t *testing.T) {
+	s := newTestState()
+	s.simpleSpinner = getSpinner()
+	s.incr(5, time.Now())
+	s.addToTotal(10)
+	if s.total != 10 || s.simpleSpinner != nil {
+		t.Errorf("Want determinate total 10, Got: %d %v\n", s.total, s.simpleSpinner != nil)
+	}
+	if p := internal.Percentage(s.total, s.current, 100); p != 50 {
+		t.Errorf("Want: 50 %%, Got: %d %%\n", p)
+	}
+	s.addToTotal(-6)
+	if !s.completed || s.current != 4 {
+		t.Errorf("Want completed at 4, Got: %v %d\n", s.completed, s.current)
+	}
+	s.addToTotal(10)
+	if s.total != 4 {
+		t.Errorf("Completed bar total changed: %d\n", s.total)
+	}
+}
+
+func TestArrangeColumns(t *testing.T) {
+	p := New().SetOut(ioutil.Discard).SetColumns([]string{"name", "size"}, nil)
+	bars := []*Bar{
+		p.AddBar(10).PrependColumn("name", Name("a", 0, 0)).PrependColumn("size", Name("1K", 0, 0)),
+		p.AddBar(10).PrependColumn("size", Name("2K", 0, 0)).PrependColumn("name", Name("b", 0, 0)),
+		p.AddBar(10).PrependBytesColumn("name", NameBytes("c", 0, 0)),
+	}
+	wants := []string{"a1K [", "b2K [", "c ["}
+	for i, bar := range bars {
+		if n := bar.NumOfPrependers(); n != 2 {
+			t.Errorf("Bar %d: want prependers: %d, got: %d\n", i, 2, n)
+		}
+		s := bar.getState()
+		buf := draw(&s, 80, newWidthSync(nil, 1, 2), newWidthSync(nil, 1, 0))
+		if !bytes.HasPrefix(buf, []byte(wants[i])) {
+			t.Errorf("Bar %d: want prefix %q, got: %q\n", i, wants[i], buf)
+		}
+	}
+	for _, bar := range bars {
+		bar.Completed()
+	}
+	p.Stop()
+}
+
+func TestStateSample(t *testing.T) {
+	now := time.Now()
+	s := newTestState()
+	s.total = 100
+	s.etaAlpha = 1
+	s.sampleInterval = time.Second
+	s.lastSample = now
+	for i := 1; i <= 10; i++ {
+		s.incr(1, now.Add(time.Duration(i)*time.Millisecond))
+	}
+	if s.current != 10 || s.timePerItem != 0 {
+		t.Errorf("Want 10 items and no estimate yet, Got: %d %v\n", s.current, s.timePerItem)
+	}
+	s.sample(now.Add(time.Second))
+	if want := 100 * time.Millisecond; s.timePerItem != want {
+		t.Errorf("Want: %v, Got: %v\n", want, s.timePerItem)
+	}
+}
+
+func TestOnProgress(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	fired := make(chan float64, 10)
+	bar := p.AddBar(100)
+	for _, threshold := range []float64{25, 50, 75, 100} {
+		threshold := threshold
+		bar.OnProgress(threshold, func(*Bar) {
+			fired <- threshold
+		})
+	}
+	for i := 0; i < 60; i++ {
+		bar.Incr(1)
+	}
+	// callbacks of different increments may run in any order
+	expect := func(wants ...float64) {
+		got := map[float64]bool{<-fired: true, <-fired: true}
+		for _, want := range wants {
+			if !got[want] {
+				t.Errorf("Want %v fired, Got: %v\n", want, got)
+			}
+		}
+	}
+	expect(25, 50)
+	bar.Incr(40)
+	expect(75, 100)
+	p.Stop()
+	select {
+	case got := <-fired:
+		t.Errorf("Milestone %v fired twice\n", got)
+	default:
+	}
+}
+
+func TestCheckDeadlines(t *testing.T) {
+	s := newTestState()
+	s.total = 100
+	s.sgr = "32"
+	fired := make(chan *Bar, 2)
+	s.deadlines = []*deadline{
+		{limit: time.Minute, fn: func(b *Bar) { fired <- b }},
+		{limit: time.Minute, style: func(s *state, exceeded bool) {
+			if exceeded {
+				s.sgr = "31"
+			} else {
+				s.sgr = "32"
+			}
+		}},
+	}
+	s.timePerItem = time.Second
+	s.checkDeadlines(nil)
+	if s.sgr != "31" {
+		t.Errorf("Want color: %q, Got: %q\n", "31", s.sgr)
+	}
+	<-fired
+	// still exceeded, mustn't fire again
+	s.checkDeadlines(nil)
+	s.timePerItem = time.Millisecond
+	s.checkDeadlines(nil)
+	if s.sgr != "32" {
+		t.Errorf("Want color restored: %q, Got: %q\n", "32", s.sgr)
+	}
+	select {
+	case <-fired:
+		t.Error("Deadline fired twice")
+	default:
+	}
+}
+
+func TestStateSetAttempt(t *testing.T) {
+	now := time.Now()
+	s := newTestState()
+	s.total = 100
+	s.setAttempt(1, 3, now)
+	s.resume(40, 40*time.Second, now)
+	s.setAttempt(1, 3, now)
+	if s.current != 40 {
+		t.Errorf("Same attempt reset the bar: %d\n", s.current)
+	}
+	s.setAttempt(2, 3, now)
+	if s.current != 0 || s.timePerItem != 0 || s.attempt != 2 {
+		t.Errorf("Want reset at attempt 2, Got: %d %v %d\n", s.current, s.timePerItem, s.attempt)
+	}
+	if s.timeElapsed != 40*time.Second {
+		t.Errorf("Want elapsed kept: %v, Got: %v\n", 40*time.Second, s.timeElapsed)
+	}
+}
+
+func TestStateSetAttemptFailures(t *testing.T) {
+	now := time.Now()
+	s := newTestState()
+	s.total = 100
+	s.setAttempt(1, 3, now)
+	s.incr(40, now)
+	s.failed = 17
+	s.secondary = 60
+	s.setAttempt(2, 3, now)
+	if s.failed != 0 || s.secondary != 0 {
+		t.Errorf("Want failed and secondary reset, Got: %d %d\n", s.failed, s.secondary)
+	}
+	s.incr(10, now)
+	got := Failures(0, 0)(newStatistics(s), nil, nil)
+	if want := "10 ok / 0 failed"; got != want {
+		t.Errorf("Want: %q, Got: %q\n", want, got)
+	}
+}
+
+func TestColorFailed(t *testing.T) {
+	tests := []struct {
+		failed      int64
+		sgr, barSGR string
+		want        string
+	}{
+		{0, "31", "", "[====]"},
+		{50, "31", "", "[\x1b[31m==\x1b[0m==]"},
+		{25, "31", "34", "[\x1b[31m=\x1b[0m\x1b[34m===]"},
+	}
+	for _, test := range tests {
+		got := string(colorFailed([]byte("[====]"), 100, test.failed, test.sgr, test.barSGR))
+		if got != test.want {
+			t.Errorf("Want: %q, Got: %q\n", test.want, got)
+		}
+	}
+}
+
+func TestFillSecondary(t *testing.T) {
+	tests := []struct {
+		current, secondary int64
+		want               string
+	}{
+		{0, 50, "[+++---]"},
+		{50, 100, "[==>+++]"},
+		{50, 50, "[==>---]"},
+	}
+	fmtBytes := convertFmtRunesToBytes(barFmtRunes{'[', '=', '>', '-', ']'})
+	for _, test := range tests {
+		barBlock := fillBar(100, test.current, 8, fmtBytes, nil)
+		got := string(fillSecondary(barBlock, 100, test.current, test.secondary, '+'))
+		if got != test.want {
+			t.Errorf("Want: %q, Got: %q\n", test.want, got)
+		}
+	}
+}
+
+func TestLineHook(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetWidth(10)
+	bar := p.AddBar(10).TrimLeftSpace().TrimRightSpace()
+	bar.SetLineHook(func(s *Statistics, line []byte) []byte {
+		return []byte(fmt.Sprintf("<%d:%s>", s.Current, line))
+	})
+	bar.Incr(10)
+	p.Stop()
+	if want := "<10:[========]>"; !strings.Contains(buf.String(), want) {
+		t.Errorf("Want %q in output, got: %q\n", want, buf.String())
+	}
+}
+
+func TestStateSetTotal(t *testing.T) {
+	now := time.Now()
+	s := &state{timeStarted: now, updated: now}
+	s.setTotal(10, false)
+	if s.simpleSpinner == nil {
+		t.Error("Bar with pending total isn't rendered as spinner")
+	}
+	s.incr(10, now)
+	if s.completed {
+		t.Error("Bar with pending total is completed")
+	}
+	s.incr(5, now)
+	if s.total != 15 || s.current != 15 {
+		t.Errorf("Want total/current: 15/15, got: %d/%d\n", s.total, s.current)
+	}
+	s.setTotal(20, true)
+	if s.simpleSpinner != nil || s.completed {
+		t.Error("Bar with final total is still indeterminate")
+	}
+	s.incr(5, now)
+	if !s.completed {
+		t.Error("Bar isn't completed on final total")
+	}
+
+	s = &state{current: 30}
+	s.setTotal(20, true)
+	if !s.completed || s.current != 20 {
+		t.Errorf("Bar isn't completed immediately: %d/%d\n", s.current, s.total)
+	}
+}
+
+func TestSetTotal(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	bar := p.AddBar(0).SetTotal(0, false)
+	bar.Incr(100)
+	bar.SetTotal(100, true)
+	p.Stop()
+	if stat := bar.GetStatistics(); stat.Total != 100 || stat.Current != 100 {
+		t.Errorf("Want total/current: 100/100, got: %d/%d\n", stat.Total, stat.Current)
+	}
+}
+
+func TestStateDecr(t *testing.T) {
+	now := time.Now()
+	s := &state{total: 100, current: 50, failed: 40}
+	s.decr(20, now)
+	if s.current != 30 || s.failed != 30 || s.rolledBack != 20 {
+		t.Errorf("Want current/failed/rolled back: 30/30/20, got: %d/%d/%d\n", s.current, s.failed, s.rolledBack)
+	}
+	s.decr(50, now)
+	if s.current != 0 || s.rolledBack != 50 {
+		t.Errorf("Want current/rolled back: 0/50, got: %d/%d\n", s.current, s.rolledBack)
+	}
+	s = &state{total: 100, current: 100, completed: true}
+	s.decr(10, now)
+	if s.current != 100 {
+		t.Errorf("Completed bar is rolled back to %d\n", s.current)
+	}
+}
+
+func TestFillBarRefillAboveCurrent(t *testing.T) {
+	fmtBytes := convertFmtRunesToBytes(barFmtRunes{'[', '=', '>', '-', ']'})
+	got := string(fillBar(100, 25, 10, fmtBytes, &refill{'+', 75}))
+	if want := "[+>------]"; got != want {
+		t.Errorf("Want: %q, Got: %q\n", want, got)
+	}
+}
+
+func TestRollbackCue(t *testing.T) {
+	s := &state{
+		total:       100,
+		current:     50,
+		width:       10,
+		format:      barFmtRunes{'[', '=', '>', '-', ']'},
+		rollbackTip: '<',
+		rollbackCue: time.Hour,
+	}
+	s.decr(10, time.Now())
+	got := string(draw(s, 10, presetWidthSync(nil), presetWidthSync(nil)))
+	if want := " [=<----] "; got != want {
+		t.Errorf("Want: %q, Got: %q\n", want, got)
+	}
+}
+
+func TestPlaceMarkers(t *testing.T) {
+	tests := []struct {
+		fractions []float64
+		want      string
+	}{
+		{[]float64{0.5}, "[=====|==--]"},
+		{[]float64{0, 1}, "[|=======-|]"},
+		{[]float64{0.25, 0.75}, "[==|====|--]"},
+	}
+	for _, test := range tests {
+		got := string(placeMarkers([]byte("[========--]"), test.fractions, '|'))
+		if got != test.want {
+			t.Errorf("Want: %q, Got: %q\n", test.want, got)
+		}
+	}
+}
+
+func TestTimeAxisFill(t *testing.T) {
+	start := time.Now()
+	s := &state{
+		total:            100,
+		current:          75,
+		timeStarted:      start,
+		expectedDuration: 10 * time.Second,
+		progressMarker:   '|',
+	}
+	fmtBytes := convertFmtRunesToBytes(barFmtRunes{'[', '=', '>', '-', ']'})
+	tests := []struct {
+		elapsed time.Duration
+		want    string
+	}{
+		{2 * time.Second, "[=>-----|--]"},
+		{20 * time.Second, "[=======|==]"},
+	}
+	for _, test := range tests {
+		got := string(s.fill(12, fmtBytes, start.Add(test.elapsed)))
+		if got != test.want {
+			t.Errorf("Elapsed %v: want %q, got %q\n", test.elapsed, test.want, got)
+		}
+	}
+}
+
+func TestOnComplete(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetWidth(20)
+	fired := make(chan int64, 2)
+	bar := p.AddBar(10).OnComplete(func(b *Bar) {
+		fired <- b.GetStatistics().Current
+	}).SetCompletedLine("done ✓")
+	bar.Incr(5)
+	bar.Incr(5)
+	select {
+	case n := <-fired:
+		if n != 10 {
+			t.Errorf("Want current 10, got: %d\n", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnComplete func not called")
+	}
+	p.Stop()
+	if len(fired) != 0 {
+		t.Error("OnComplete func called more than once")
+	}
+	if !strings.HasSuffix(buf.String(), "done ✓\n") {
+		t.Errorf("Completed line not found in: %q\n", buf.String())
+	}
+}
+
+// notifyWriter notifies about every write
+type notifyWriter struct {
+	bytes.Buffer
+	written chan struct{}
+}
+
+func (w *notifyWriter) Write(p []byte) (int, error) {
+	n, err := w.Buffer.Write(p)
+	select {
+	case w.written <- struct{}{}:
+	default:
+	}
+	return n, err
+}
+
+func TestRefresh(t *testing.T) {
+	w := &notifyWriter{written: make(chan struct{}, 1)}
+	p := New().SetOut(w).SetWidth(12).RefreshRate(time.Hour)
+	bar := p.AddBar(10)
+	bar.Incr(5)
+	for bar.GetStatistics().Current != 5 {
+		time.Sleep(time.Millisecond)
+	}
+	bar.Refresh()
+	select {
+	case <-w.written:
+	case <-time.After(time.Second):
+		t.Error("Frame isn't rendered on Refresh")
+	}
+	bar.Incr(5)
+	for bar.GetStatistics().Current != 10 {
+		time.Sleep(time.Millisecond)
+	}
+	// completed bar exits on flush, which isn't due within an hour
+	bar.Refresh()
+	p.Stop()
+	if !strings.HasPrefix(w.String(), " [===>----] \n") {
+		t.Errorf("Refreshed frame not found in: %q\n", w.String())
+	}
+}
+
+func TestAbort(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetWidth(12)
+	frozen := p.AddBarWithID(1, 10)
+	frozen.AppendFunc(OnAbort(Name("", 0, 0), "aborted", 0))
+	dropped := p.AddBarWithID(2, 10)
+	frozen.Incr(5)
+	for frozen.GetStatistics().Current != 5 {
+		time.Sleep(time.Millisecond)
+	}
+	frozen.Abort(false)
+	dropped.Abort(true)
+	if frozen.InProgress() || !frozen.GetStatistics().Aborted {
+		t.Error("Bar isn't aborted")
+	}
+	if n := p.BarCount(); n != 1 {
+		t.Errorf("Want 1 bar, got: %d\n", n)
+	}
+	p.Wait()
+	if !strings.HasSuffix(buf.String(), "] aborted\n") {
+		t.Errorf("Aborted marker not found in: %q\n", buf.String())
+	}
+}
+
+func TestAbortCompleted(t *testing.T) {
+	p := New().SetOut(ioutil.Discard).RefreshRate(time.Hour)
+	kept := p.AddBar(10)
+	dropped := p.AddBar(10)
+	kept.Incr(10)
+	dropped.Incr(10)
+	// completed, but not flushed yet, as the next frame is an hour away
+	for !isCompleted(kept.GetStatistics()) || !isCompleted(dropped.GetStatistics()) {
+		time.Sleep(time.Millisecond)
+	}
+	kept.Abort(false)
+	dropped.Abort(true)
+	if kept.GetStatistics().Aborted {
+		t.Error("Completed bar is aborted")
+	}
+	if n := p.BarCount(); n != 2 {
+		t.Errorf("Want 2 bars, got: %d\n", n)
+	}
+	p.RefreshRate(10 * time.Millisecond)
+	p.Wait()
+}
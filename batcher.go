@@ -0,0 +1,93 @@
+package mpb
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// IncrBatcher accumulates increments from many goroutines and flushes them to
+// the bar every interval, or as soon as maxCount increments are accumulated.
+// It is useful for workloads, where thousands of goroutines add tiny
+// increments, as (*Bar).Incr costs a channel send per call.
+type IncrBatcher struct {
+	// accessed atomically, must be 64-bit aligned
+	pending int64
+	count   int64
+
+	bar      *Bar
+	maxCount int64
+	flushCh  chan struct{}
+	quit     chan struct{}
+	done     chan struct{}
+}
+
+// NewIncrBatcher creates IncrBatcher, which flushes to the bar every interval,
+// or when maxCount increments are accumulated. Zero maxCount means flush by
+// interval only. Stop should be called, when there is nothing to add.
+func (b *Bar) NewIncrBatcher(interval time.Duration, maxCount int) *IncrBatcher {
+	if interval <= 0 {
+		panic("non-positive interval")
+	}
+	ib := &IncrBatcher{
+		bar:      b,
+		maxCount: int64(maxCount),
+		flushCh:  make(chan struct{}, 1),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go ib.server(interval)
+	return ib
+}
+
+// Add accumulates n. It is safe to call Add from multiple goroutines.
+func (ib *IncrBatcher) Add(n int) {
+	if n < 1 {
+		return
+	}
+	atomic.AddInt64(&ib.pending, int64(n))
+	if ib.maxCount > 0 && atomic.AddInt64(&ib.count, 1) >= ib.maxCount {
+		select {
+		case ib.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Stop flushes remaining increments and stops batcher's goroutine.
+// Add must not be called after Stop.
+func (ib *IncrBatcher) Stop() {
+	select {
+	case <-ib.quit:
+	default:
+		close(ib.quit)
+	}
+	<-ib.done
+}
+
+func (ib *IncrBatcher) server(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer func() {
+		t.Stop()
+		ib.flush()
+		close(ib.done)
+	}()
+	for {
+		select {
+		case <-t.C:
+			ib.flush()
+		case <-ib.flushCh:
+			ib.flush()
+		case <-ib.bar.done:
+			return
+		case <-ib.quit:
+			return
+		}
+	}
+}
+
+func (ib *IncrBatcher) flush() {
+	atomic.StoreInt64(&ib.count, 0)
+	if n := atomic.SwapInt64(&ib.pending, 0); n > 0 {
+		ib.bar.Incr(int(n))
+	}
+}
@@ -0,0 +1,30 @@
+package mpb
+
+import (
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIncrBatcher(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	bar := p.AddBar(1000)
+	ib := bar.NewIncrBatcher(10*time.Millisecond, 50)
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				ib.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	ib.Stop()
+	p.Stop()
+	if current := bar.GetStatistics().Current; current != 1000 {
+		t.Errorf("Current want: %d, got: %d\n", 1000, current)
+	}
+}
@@ -0,0 +1,98 @@
+package mpb
+
+import "time"
+
+// SetFrameBudget limits time spent on drawing bars per refresh. Bars are
+// drawn round-robin, as many as fit in budget, and the rest keep their
+// previous lines till the next refresh, so UI stays responsive with extreme
+// bar counts. Bar, which has never been drawn, is always drawn. Columns are
+// synced to max widths seen so far. Zero budget draws all bars every refresh,
+// which is default.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetFrameBudget(d time.Duration) *Progress {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.frameBudgetCh <- d:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// budgetDrawer draws bars within frame budget, see SetFrameBudget
+type budgetDrawer struct {
+	budget time.Duration
+	// next is index of bar, which is drawn first on the next frame
+	next  int
+	lines map[*Bar]indexedBarBuffer
+	// max widths of columns, seen so far
+	prependMax []int
+	appendMax  []int
+}
+
+func (bd *budgetDrawer) drawBars(bars []*Bar, termWidth int, animate bool) <-chan indexedBarBuffer {
+	ibbCh := make(chan indexedBarBuffer)
+	go func() {
+		defer close(ibbCh)
+		for _, ibb := range bd.draw(bars, termWidth, animate, time.Now) {
+			ibbCh <- ibb
+		}
+	}()
+	return ibbCh
+}
+
+func (bd *budgetDrawer) draw(bars []*Bar, termWidth int, animate bool, now func() time.Time) []indexedBarBuffer {
+	deadline := now().Add(bd.budget)
+	lines := make(map[*Bar]indexedBarBuffer, len(bars))
+	ibbs := make([]indexedBarBuffer, len(bars))
+	var drawn int
+	for k := range bars {
+		i := (bd.next + k) % len(bars)
+		b := bars[i]
+		ibb, ok := bd.lines[b]
+		if !ok || k == 0 || now().Before(deadline) {
+			ibb = bd.drawBar(b, termWidth, animate)
+			drawn++
+		}
+		ibb.index = i
+		ibbs[i] = ibb
+		lines[b] = ibb
+	}
+	if len(bars) > 0 {
+		bd.next = (bd.next + drawn) % len(bars)
+	}
+	bd.lines = lines
+	return ibbs
+}
+
+func (bd *budgetDrawer) drawBar(b *Bar, termWidth int, animate bool) indexedBarBuffer {
+	s := b.getState()
+	if !animate {
+		s.completionAnim = nil
+	}
+	prependFuncs, _ := s.prependColumns()
+	appendFuncs, _ := arrangeColumns(s.appendOrder, s.appendIDs, s.appendFuncs, nil)
+	bd.prependMax = growWidths(bd.prependMax, len(prependFuncs))
+	bd.appendMax = growWidths(bd.appendMax, len(appendFuncs))
+	prependWs := presetWidthSync(bd.prependMax)
+	appendWs := presetWidthSync(bd.appendMax)
+	buf := draw(&s, termWidth, prependWs, appendWs)
+	bd.prependMax = maxPerColumn([][]int{bd.prependMax, prependWs.widths()}, len(bd.prependMax))
+	bd.appendMax = maxPerColumn([][]int{bd.appendMax, appendWs.widths()}, len(bd.appendMax))
+	return indexedBarBuffer{
+		buf:     append(buf, '\n'),
+		total:   s.total,
+		current: s.current,
+		name:    barName(&s),
+		details: detailLines(&s, termWidth, false),
+	}
+}
+
+// growWidths returns widths with at least n columns
+func growWidths(widths []int, n int) []int {
+	for len(widths) < n {
+		widths = append(widths, 0)
+	}
+	return widths
+}
@@ -0,0 +1,78 @@
+package mpb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBudgetDrawerRoundRobin(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	bars := make([]*Bar, 4)
+	for i := range bars {
+		bars[i] = p.AddBar(10).PrependName("b", 0, 0).AppendPercentage(0, 0)
+	}
+	var clock time.Time
+	// every call to now moves clock by 1ms, budget fits 2 calls
+	now := func() time.Time {
+		clock = clock.Add(time.Millisecond)
+		return clock
+	}
+	bd := &budgetDrawer{budget: 2 * time.Millisecond}
+
+	// never drawn bars are drawn regardless of budget
+	ibbs := bd.draw(bars, 80, false, now)
+	if bd.next != 0 {
+		t.Errorf("Expected next: 0, got: %d\n", bd.next)
+	}
+	for i, ibb := range ibbs {
+		if ibb.index != i || !strings.Contains(string(ibb.buf), "0 %") {
+			t.Errorf("Unexpected line %d: %q\n", i, ibb.buf)
+		}
+	}
+
+	for _, b := range bars {
+		b.Incr(5)
+	}
+	for bars[3].GetStatistics().Current != 5 {
+		time.Sleep(time.Millisecond)
+	}
+	ibbs = bd.draw(bars, 80, false, now)
+	if bd.next != 2 {
+		t.Errorf("Expected next: 2, got: %d\n", bd.next)
+	}
+	for i, ibb := range ibbs {
+		updated := strings.Contains(string(ibb.buf), "50 %")
+		if updated != (i < 2) {
+			t.Errorf("Line %d updated: %v, got: %q\n", i, updated, ibb.buf)
+		}
+	}
+
+	ibbs = bd.draw(bars, 80, false, now)
+	if bd.next != 0 {
+		t.Errorf("Expected next: 0, got: %d\n", bd.next)
+	}
+	for i, ibb := range ibbs {
+		if !strings.Contains(string(ibb.buf), "50 %") {
+			t.Errorf("Line %d isn't updated: %q\n", i, ibb.buf)
+		}
+	}
+	for _, b := range bars {
+		b.Completed()
+	}
+	p.Stop()
+}
+
+func TestSetFrameBudget(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetFrameBudget(time.Millisecond).
+		RefreshRate(10 * time.Millisecond)
+	bar := p.AddBar(10).AppendPercentage(0, 0)
+	bar.Incr(10)
+	p.Stop()
+	if !strings.Contains(buf.String(), "100 %") {
+		t.Errorf("Expected 100 %% in output, got: %q\n", buf.String())
+	}
+}
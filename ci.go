@@ -0,0 +1,74 @@
+package mpb
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+)
+
+// CIProvider identifies log grouping syntax of CI provider
+type CIProvider int
+
+const (
+	// CINone disables log grouping
+	CINone CIProvider = iota
+	// CIGitHub uses ::group:: and ::endgroup:: workflow commands
+	CIGitHub
+	// CIGitLab uses section_start and section_end markers
+	CIGitLab
+)
+
+// DetectCI detects CI provider by environment variables
+func DetectCI() CIProvider {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return CIGitHub
+	case os.Getenv("GITLAB_CI") != "":
+		return CIGitLab
+	default:
+		return CINone
+	}
+}
+
+func (ci CIProvider) writeStart(buf *bytes.Buffer, now time.Time, section, title string) {
+	switch ci {
+	case CIGitHub:
+		fmt.Fprintf(buf, "::group::%s\n", title)
+	case CIGitLab:
+		fmt.Fprintf(buf, "\x1b[0Ksection_start:%d:%s[collapsed=true]\r\x1b[0K%s\n",
+			now.Unix(), section, title)
+	}
+}
+
+func (ci CIProvider) writeEnd(buf *bytes.Buffer, now time.Time, section string) {
+	switch ci {
+	case CIGitHub:
+		buf.WriteString("::endgroup::\n")
+	case CIGitLab:
+		fmt.Fprintf(buf, "\x1b[0Ksection_end:%d:%s\r\x1b[0K\n", now.Unix(), section)
+	}
+}
+
+func groupTitle(index int, name string) string {
+	if name == "" {
+		return fmt.Sprintf("bar #%d", index)
+	}
+	return name
+}
+
+// sectionID returns GitLab section id, which may contain only
+// letters, digits, '_', '.' and '-'
+func sectionID(index int, name string) string {
+	id := []byte(fmt.Sprintf("bar_%d_", index))
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_', c == '.', c == '-':
+			id = append(id, c)
+		default:
+			id = append(id, '_')
+		}
+	}
+	return string(id)
+}
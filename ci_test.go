@@ -0,0 +1,36 @@
+package mpb
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCIGroups(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	bars := []*Bar{new(Bar)}
+	tests := []struct {
+		ci   CIProvider
+		want string
+	}{
+		{CIGitHub, "::group::up load\nup load 0\nup load 100\n::endgroup::\n"},
+		{CIGitLab, "\x1b[0Ksection_start:1500000000:bar_0_up_load[collapsed=true]\r\x1b[0Kup load\nup load 0\n" +
+			"up load 100\n\x1b[0Ksection_end:1500000000:bar_0_up_load\r\x1b[0K\n"},
+	}
+	for _, test := range tests {
+		pt := new(plainThrottle)
+		var got string
+		for _, current := range []int64{0, 0, 100} {
+			ibbs := []indexedBarBuffer{{
+				buf:     []byte(fmt.Sprintf("up load %d\n", current)),
+				total:   100,
+				current: current,
+				name:    "up load",
+			}}
+			got += string(pt.lines(now, bars, ibbs, nil, test.ci))
+		}
+		if got != test.want {
+			t.Errorf("Provider %d: want %q, got %q\n", test.ci, test.want, got)
+		}
+	}
+}
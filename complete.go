@@ -0,0 +1,35 @@
+package mpb
+
+// OnComplete registers fn, which is called once, when the bar completes, i.e.
+// reaches its total. Unlike OnProgress(100, fn), it isn't called, while total
+// isn't final, see SetTotal. The fn is called in its own goroutine, so it may
+// call methods of the bar.
+func (b *Bar) OnComplete(fn func(*Bar)) *Bar {
+	b.operate(func(s *state) {
+		s.onComplete = append(s.onComplete, fn)
+	})
+	return b
+}
+
+// SetCompletedLine swaps rendered line of the bar for msg, i.e. "done ✓",
+// once the bar completes. Empty msg restores the default.
+func (b *Bar) SetCompletedLine(msg string) *Bar {
+	b.operate(func(s *state) {
+		s.completedLine = msg
+	})
+	return b
+}
+
+// fireCompletion calls OnComplete funcs, if the bar has completed
+func (s *state) fireCompletion(b *Bar) {
+	if !s.completed || len(s.onComplete) == 0 {
+		return
+	}
+	fired := s.onComplete
+	s.onComplete = nil
+	go func() {
+		for _, fn := range fired {
+			fn(b)
+		}
+	}()
+}
@@ -0,0 +1,123 @@
+package mpb
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// config is a snapshot of Progress' resolved configuration
+type config struct {
+	width        int
+	format       string
+	refreshRate  time.Duration
+	maxDrawers   int
+	minTermWidth int
+	term         termInfo
+	writeFailed  bool
+	sorted       bool
+	bottomUp     bool
+	columns      [2][]string
+	header       bool
+	footer       bool
+	reporter     bool
+	bars         []*Bar
+}
+
+// DumpConfig prints resolved configuration of the container and its bars
+// to w, followed by warnings about conflicting options. Use it to debug, why
+// bars don't look as expected.
+// Pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) DumpConfig(w io.Writer) {
+	p.acquire()
+	defer p.release()
+	ch := make(chan *config, 1)
+	select {
+	case p.configReqCh <- ch:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	cfg := <-ch
+	cfg.width = p.width
+	states := make([]state, len(cfg.bars))
+	for i, b := range cfg.bars {
+		states[i] = b.getState()
+	}
+
+	renderer := "terminal"
+	switch {
+	case cfg.term.accessible:
+		renderer = "accessible"
+	case cfg.term.cr:
+		renderer = "carriage return"
+	case !cfg.term.tty:
+		renderer = "plain"
+	}
+	if cfg.writeFailed {
+		renderer = "none (write failed)"
+	}
+	format := cfg.format
+	if format == "" {
+		format = "[=>-]"
+	}
+	fmt.Fprintln(w, "progress:")
+	fmt.Fprintf(w, "  width: %d\n", cfg.width)
+	fmt.Fprintf(w, "  format: %q\n", format)
+	fmt.Fprintf(w, "  refresh rate: %v\n", cfg.refreshRate)
+	fmt.Fprintf(w, "  renderer: %s, color: %t\n", renderer, cfg.term.color)
+	fmt.Fprintf(w, "  max drawers: %d\n", cfg.maxDrawers)
+	fmt.Fprintf(w, "  min term width: %d\n", cfg.minTermWidth)
+	fmt.Fprintf(w, "  sorted: %t, bottom up: %t, header: %t, footer: %t, reporter: %t\n",
+		cfg.sorted, cfg.bottomUp, cfg.header, cfg.footer, cfg.reporter)
+	fmt.Fprintf(w, "bars: %d\n", len(states))
+	for i := range states {
+		s := &states[i]
+		fmt.Fprintf(w, "  #%d id: %d", i, s.id)
+		if name, ok := s.meta["name"]; ok {
+			fmt.Fprintf(w, ", name: %q", name)
+		}
+		fmt.Fprintf(w, ", total: %d, current: %d, width: %d, min width: %d, format: %q\n",
+			s.total, s.current, s.width, s.minWidth, string(s.format[:]))
+		fmt.Fprintf(w, "    prependers: %d, appenders: %d, trim: %t/%t, priority: %d, pinned: %t, color: %q\n",
+			len(s.prependFuncs), len(s.appendFuncs), s.trimLeftSpace, s.trimRightSpace,
+			s.priority, s.pinned, s.sgr)
+		estimator := fmt.Sprintf("ewma(%g)", s.etaAlpha)
+		if cap(s.etaWindow) > 0 {
+			estimator = fmt.Sprintf("median(%d)", cap(s.etaWindow))
+		}
+		fmt.Fprintf(w, "    eta: %s\n", estimator)
+	}
+	for _, warning := range validateConfig(cfg, states) {
+		fmt.Fprintf(w, "warning: %s\n", warning)
+	}
+}
+
+// validateConfig reports conflicting options
+func validateConfig(cfg *config, states []state) []string {
+	var warnings []string
+	if cfg.maxDrawers < 0 {
+		warnings = append(warnings, fmt.Sprintf("negative max drawers %d", cfg.maxDrawers))
+	}
+	var numPrepend, numAppend int
+	for i := range states {
+		s := &states[i]
+		prependFuncs, _ := s.prependColumns()
+		appendFuncs, _ := arrangeColumns(s.appendOrder, s.appendIDs, s.appendFuncs, nil)
+		if i == 0 {
+			numPrepend, numAppend = len(prependFuncs), len(appendFuncs)
+		} else if len(prependFuncs) != numPrepend || len(appendFuncs) != numAppend {
+			warnings = append(warnings, fmt.Sprintf(
+				"bar #%d has %d/%d prependers/appenders, bar #0 has %d/%d: bar #%d won't be rendered",
+				i, len(prependFuncs), len(appendFuncs), numPrepend, numAppend, i))
+		}
+		if s.minWidth > s.width {
+			warnings = append(warnings, fmt.Sprintf(
+				"bar #%d min width %d exceeds its width %d", i, s.minWidth, s.width))
+		}
+		if s.pinned && !cfg.sorted {
+			warnings = append(warnings, fmt.Sprintf(
+				"bar #%d is pinned, but bars aren't sorted", i))
+		}
+	}
+	return warnings
+}
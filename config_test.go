@@ -0,0 +1,33 @@
+package mpb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestDumpConfig(t *testing.T) {
+	p := New().SetOut(ioutil.Discard).SetWidth(40)
+	a := p.AddBar(10).SetMeta("name", "a")
+	b := p.AddBar(10).PrependName("b", 0, 0).SetMinWidth(50).SetPinned(true)
+	var buf bytes.Buffer
+	p.DumpConfig(&buf)
+	out := buf.String()
+	for _, want := range []string{
+		"width: 40",
+		"renderer: plain, color: false",
+		"bars: 2",
+		`name: "a"`,
+		"warning: bar #1 has 1/0 prependers/appenders, bar #0 has 0/0",
+		"warning: bar #1 min width 50 exceeds its width 40",
+		"warning: bar #1 is pinned, but bars aren't sorted",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Want %q in:\n%s", want, out)
+		}
+	}
+	a.Completed()
+	b.Completed()
+	p.Stop()
+}
@@ -0,0 +1,19 @@
+// Package cwriter implements a buffered writer, which redraws a live region
+// of lines in terminal. It is used by mpb to render bars, but is usable on its
+// own for any multi-line live output.
+//
+// Text written to the Writer is buffered until Flush, which erases lines of
+// the previous flush and writes the buffer in their place. Lines are counted
+// by newline characters, so every line of the region should end with one.
+// Lines longer than terminal width wrap and can't be erased properly, use
+// GetWriterSize to fit them.
+//
+// On posix systems lines are erased with cursor up and EL (erase in line)
+// ANSI sequences. On Windows console API is used, falling back to the same
+// ANSI sequences, if output isn't a console.
+//
+// Log lines, written with WriteLog, are placed above the region and are never
+// erased by later flushes.
+//
+// Writer isn't safe for concurrent use.
+package cwriter
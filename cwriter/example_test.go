@@ -0,0 +1,19 @@
+package cwriter_test
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/vbauerster/mpb/cwriter"
+)
+
+func Example() {
+	w := cwriter.New(os.Stdout)
+	for i := 0; i <= 100; i += 25 {
+		fmt.Fprintf(w, "download: %d%%\n", i)
+		fmt.Fprintf(w, "upload:   %d%%\n", i/2)
+		w.Flush()
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,58 @@
+package cwriter
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// SizeCache caches terminal size of a writer, so it isn't queried by syscall
+// on every call. Cached size is invalidated, when ttl elapses or, on posix
+// systems, when SIGWINCH is received.
+type SizeCache struct {
+	w       io.Writer
+	ttl     time.Duration
+	width   int
+	height  int
+	err     error
+	expires time.Time
+	resized chan os.Signal
+}
+
+// NewSizeCache returns a new SizeCache of w. Call Stop, once cache isn't
+// needed anymore, to release signal notification.
+func NewSizeCache(w io.Writer, ttl time.Duration) *SizeCache {
+	c := &SizeCache{
+		w:       w,
+		ttl:     ttl,
+		resized: make(chan os.Signal, 1),
+	}
+	notifyResize(c.resized)
+	return c
+}
+
+// Size returns the dimensions of terminal, which w refers to, as reported by
+// GetWriterSize
+func (c *SizeCache) Size() (width, height int, err error) {
+	return c.size(time.Now())
+}
+
+func (c *SizeCache) size(now time.Time) (width, height int, err error) {
+	select {
+	case <-c.resized:
+		c.expires = time.Time{}
+	default:
+	}
+	if now.Before(c.expires) {
+		return c.width, c.height, c.err
+	}
+	c.width, c.height, c.err = GetWriterSize(c.w)
+	c.expires = now.Add(c.ttl)
+	return c.width, c.height, c.err
+}
+
+// Stop releases signal notification
+func (c *SizeCache) Stop() {
+	signal.Stop(c.resized)
+}
@@ -0,0 +1,43 @@
+package cwriter
+
+import (
+	"bytes"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type fakeTerm struct {
+	bytes.Buffer
+	calls int
+}
+
+func (f *fakeTerm) Fd() uintptr {
+	f.calls++
+	// invalid fd, so size is queried, but fails
+	return ^uintptr(0)
+}
+
+func TestSizeCache(t *testing.T) {
+	w := new(fakeTerm)
+	c := NewSizeCache(w, time.Second)
+	defer c.Stop()
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, _, err := c.size(now); err == nil {
+			t.Fatal("want error for invalid fd")
+		}
+	}
+	if w.calls != 1 {
+		t.Errorf("want %d queries, got %d", 1, w.calls)
+	}
+	c.size(now.Add(time.Second))
+	if w.calls != 2 {
+		t.Errorf("want %d queries after ttl, got %d", 2, w.calls)
+	}
+	c.resized <- syscall.Signal(0)
+	c.size(now.Add(time.Second))
+	if w.calls != 3 {
+		t.Errorf("want %d queries after resize, got %d", 3, w.calls)
+	}
+}
@@ -2,18 +2,50 @@ package cwriter
 
 import (
 	"bytes"
+	"errors"
 	"io"
 )
 
 // ESC is the ASCII code for escape character
 const ESC = 27
 
-// Writer is a buffered the writer that updates the terminal.
+// Cursor visibility sequences
+const (
+	HideCursor = "\x1b[?25l"
+	ShowCursor = "\x1b[?25h"
+)
+
+// ErrNotTerminal is returned by GetWriterSize, if writer isn't a terminal
+var ErrNotTerminal = errors.New("not a terminal")
+
+// FdWriter is a writer with a file descriptor.
+type FdWriter interface {
+	io.Writer
+	Fd() uintptr
+}
+
+// IsTerminal reports whether w is a terminal
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(FdWriter)
+	return ok && isTerminal(f.Fd())
+}
+
+// GetWriterSize returns the dimensions of terminal, which w refers to
+func GetWriterSize(w io.Writer) (width, height int, err error) {
+	f, ok := w.(FdWriter)
+	if !ok {
+		return -1, -1, ErrNotTerminal
+	}
+	return getTermSize(f.Fd())
+}
+
+// Writer is a buffered writer that updates the terminal.
 // The contents of writer will be flushed when Flush is called.
 type Writer struct {
 	out io.Writer
 
 	buf       bytes.Buffer
+	logBuf    bytes.Buffer
 	lineCount int
 }
 
@@ -24,20 +56,66 @@ func New(w io.Writer) *Writer {
 	}
 }
 
-// Flush flushes the underlying buffer
+// Flush flushes the underlying buffer. Log lines, if any, are written in
+// place of previously flushed lines, followed by the buffer. Only lines of
+// the buffer are cleared by the next Flush, so log lines are never
+// overwritten.
 func (w *Writer) Flush() error {
 	// Do nothing if buffer is empty
-	if w.buf.Len() == 0 {
+	if w.buf.Len() == 0 && w.logBuf.Len() == 0 {
 		return nil
 	}
 	w.clearLines()
 	w.lineCount = bytes.Count(w.buf.Bytes(), []byte("\n"))
-	_, err := w.out.Write(w.buf.Bytes())
+	var err error
+	if w.logBuf.Len() > 0 {
+		w.logBuf.Write(w.buf.Bytes())
+		_, err = w.out.Write(w.logBuf.Bytes())
+		w.logBuf.Reset()
+	} else {
+		_, err = w.out.Write(w.buf.Bytes())
+	}
 	w.buf.Reset()
 	return err
 }
 
-// Write save the contents of b to its buffers. The only errors returned are ones encountered while writing to the underlying buffer.
+// WriteLog buffers log line, which is written above the buffer on the next
+// Flush. Newline is appended, if b doesn't end with one.
+func (w *Writer) WriteLog(b []byte) {
+	w.logBuf.Write(b)
+	if len(b) == 0 || b[len(b)-1] != '\n' {
+		w.logBuf.WriteByte('\n')
+	}
+}
+
+// HasLog reports whether there are log lines, which aren't flushed yet
+func (w *Writer) HasLog() bool {
+	return w.logBuf.Len() > 0
+}
+
+// Write saves the contents of b to its buffer. The only errors returned are
+// ones encountered while writing to the underlying buffer.
 func (w *Writer) Write(b []byte) (n int, err error) {
 	return w.buf.Write(b)
 }
+
+// Lines returns count of lines written by the last Flush, i.e lines which
+// the next Flush erases
+func (w *Writer) Lines() int {
+	return w.lineCount
+}
+
+// Reset forgets lines written by the last Flush, so the next Flush doesn't
+// erase them. Use it, when the region has been cleared or overwritten by
+// something else, i.e. the screen was cleared, to repaint it from scratch.
+func (w *Writer) Reset() {
+	w.lineCount = 0
+}
+
+// Clear erases lines written by the last Flush, so the next Flush starts at
+// the top of the region. It is useful to remove the region, once live updates
+// are done.
+func (w *Writer) Clear() {
+	w.clearLines()
+	w.lineCount = 0
+}
@@ -4,6 +4,8 @@ package cwriter
 
 import (
 	"fmt"
+	"os"
+	"os/signal"
 	"syscall"
 	"unsafe"
 )
@@ -23,9 +25,22 @@ func (w *Writer) clearLines() {
 // GetTermSize returns the dimensions of the given terminal.
 // the code is stolen from "golang.org/x/crypto/ssh/terminal"
 func GetTermSize() (width, height int, err error) {
+	return getTermSize(uintptr(syscall.Stdout))
+}
+
+func notifyResize(c chan<- os.Signal) {
+	signal.Notify(c, syscall.SIGWINCH)
+}
+
+func isTerminal(fd uintptr) bool {
+	_, _, err := getTermSize(fd)
+	return err == nil
+}
+
+func getTermSize(fd uintptr) (width, height int, err error) {
 	var dimensions [4]uint16
 
-	if _, _, err := syscall.Syscall6(syscall.SYS_IOCTL, uintptr(syscall.Stdout), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&dimensions)), 0, 0, 0); err != 0 {
+	if _, _, err := syscall.Syscall6(syscall.SYS_IOCTL, fd, uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&dimensions)), 0, 0, 0); err != 0 {
 		return -1, -1, err
 	}
 	return int(dimensions[1]), int(dimensions[0]), nil
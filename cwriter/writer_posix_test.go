@@ -5,6 +5,8 @@ package cwriter_test
 import (
 	"bytes"
 	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/vbauerster/mpb/cwriter"
@@ -34,3 +36,107 @@ func TestWriterPosix(t *testing.T) {
 		}
 	}
 }
+
+// emulate replays output on a virtual terminal, which understands cursor up
+// and clear line sequences, and returns its lines including scrollback
+func emulate(out string) []string {
+	cursorUp := fmt.Sprintf("%c[%dA", 27, 1)
+	clearLine := fmt.Sprintf("%c[2K", 27)
+	lines := []string{""}
+	var row, col int
+	for i := 0; i < len(out); {
+		switch {
+		case strings.HasPrefix(out[i:], cursorUp):
+			if row > 0 {
+				row--
+			}
+			i += len(cursorUp)
+			continue
+		case strings.HasPrefix(out[i:], clearLine):
+			lines[row] = ""
+			i += len(clearLine)
+			continue
+		}
+		switch c := out[i]; c {
+		case '\n':
+			row++
+			col = 0
+			if row == len(lines) {
+				lines = append(lines, "")
+			}
+		case '\r':
+			col = 0
+		default:
+			line := []byte(lines[row])
+			for len(line) <= col {
+				line = append(line, ' ')
+			}
+			line[col] = c
+			lines[row] = string(line)
+			col++
+		}
+		i++
+	}
+	return lines
+}
+
+// TestWriteLog checks that log lines are never overwritten by redraws
+func TestWriteLog(t *testing.T) {
+	out := new(bytes.Buffer)
+	w := cwriter.New(out)
+
+	fmt.Fprint(w, "bar1 10%\nbar2 10%\n")
+	w.Flush()
+	w.WriteLog([]byte("log 1"))
+	fmt.Fprint(w, "bar1 50%\nbar2 20%\n")
+	w.Flush()
+	w.WriteLog([]byte("log 2\nlog 3\n"))
+	fmt.Fprint(w, "bar1 90%\nbar2 30%\n")
+	w.Flush()
+	fmt.Fprint(w, "bar1 100%\nbar2 40%\n")
+	w.Flush()
+
+	want := []string{"log 1", "log 2", "log 3", "bar1 100%", "bar2 40%", ""}
+	got := emulate(out.String())
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
+
+func TestClear(t *testing.T) {
+	out := new(bytes.Buffer)
+	w := cwriter.New(out)
+
+	fmt.Fprint(w, "line 1\nline 2\n")
+	w.Flush()
+	if w.Lines() != 2 {
+		t.Fatalf("want %d lines, got %d", 2, w.Lines())
+	}
+	w.Clear()
+	if w.Lines() != 0 {
+		t.Fatalf("want %d lines, got %d", 0, w.Lines())
+	}
+	fmt.Fprint(w, "done\n")
+	w.Flush()
+
+	want := []string{"done", "", ""}
+	got := emulate(out.String())
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
+
+func TestReset(t *testing.T) {
+	out := new(bytes.Buffer)
+	w := cwriter.New(out)
+
+	fmt.Fprint(w, "line 1\nline 2\n")
+	w.Flush()
+	w.Reset()
+	out.Reset()
+	fmt.Fprint(w, "line 1\nline 2\n")
+	w.Flush()
+	if got, want := out.String(), "line 1\nline 2\n"; got != want {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
@@ -18,3 +18,13 @@ func TestWriter(t *testing.T) {
 		t.Fatalf("want %q, got %q", want, b.String())
 	}
 }
+
+func TestIsTerminal(t *testing.T) {
+	b := new(bytes.Buffer)
+	if IsTerminal(b) {
+		t.Error("bytes.Buffer reported as terminal")
+	}
+	if _, _, err := GetWriterSize(b); err != ErrNotTerminal {
+		t.Errorf("want %v, got %v", ErrNotTerminal, err)
+	}
+}
@@ -4,7 +4,7 @@ package cwriter
 
 import (
 	"fmt"
-	"io"
+	"os"
 	"syscall"
 	"unsafe"
 
@@ -44,15 +44,9 @@ type (
 	}
 )
 
-// FdWriter is a writer with a file descriptor.
-type FdWriter interface {
-	io.Writer
-	Fd() uintptr
-}
-
 func (w *Writer) clearLines() {
 	f, ok := w.out.(FdWriter)
-	if ok && !isatty.IsTerminal(f.Fd()) {
+	if !ok || !isatty.IsTerminal(f.Fd()) {
 		for i := 0; i < w.lineCount; i++ {
 			fmt.Fprintf(w.out, "%c[%dA", ESC, 1) // move the cursor up
 			fmt.Fprintf(w.out, "%c[2K\r", ESC)   // clear the line
@@ -81,8 +75,19 @@ func (w *Writer) clearLines() {
 // GetTermSize returns the dimensions of the given terminal.
 // the code is stolen from "golang.org/x/crypto/ssh/terminal"
 func GetTermSize() (width, height int, err error) {
+	return getTermSize(uintptr(syscall.Stdout))
+}
+
+// notifyResize is noop, as there is no resize signal on Windows
+func notifyResize(c chan<- os.Signal) {}
+
+func isTerminal(fd uintptr) bool {
+	return isatty.IsTerminal(fd)
+}
+
+func getTermSize(fd uintptr) (width, height int, err error) {
 	var info consoleScreenBufferInfo
-	_, _, e := syscall.Syscall(procGetConsoleScreenBufferInfo.Addr(), 2, uintptr(syscall.Stdout), uintptr(unsafe.Pointer(&info)), 0)
+	_, _, e := syscall.Syscall(procGetConsoleScreenBufferInfo.Addr(), 2, fd, uintptr(unsafe.Pointer(&info)), 0)
 	if e != 0 {
 		return 0, 0, error(e)
 	}
@@ -0,0 +1,32 @@
+package mpb
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SetDebugOverlay toggles debug overlay, a line at the bottom right corner of
+// the block, showing frame number, render duration of the previous frame and
+// term size. It's meant to aid diagnosing flicker and slowness reports.
+// Default is false.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetDebugOverlay(enabled bool) *Progress {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.debugCh <- enabled:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// debugLine renders debug overlay line, right aligned to termWidth, if known
+func debugLine(frame int, render time.Duration, termWidth, termHeight int) []byte {
+	str := fmt.Sprintf("frame %d | %v | %dx%d", frame, render, termWidth, termHeight)
+	if pad := termWidth - len(str); pad > 0 {
+		str = strings.Repeat(" ", pad) + str
+	}
+	return line(str)
+}
@@ -0,0 +1,31 @@
+package mpb
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDebugLine(t *testing.T) {
+	want := "  frame 3 | 1.5ms | 25x10\n"
+	if got := string(debugLine(3, 1500*time.Microsecond, 25, 10)); got != want {
+		t.Errorf("Want: %q, Got: %q\n", want, got)
+	}
+	want = "frame 1 | 0s | 0x0\n"
+	if got := string(debugLine(1, 0, 0, 0)); got != want {
+		t.Errorf("Want: %q, Got: %q\n", want, got)
+	}
+}
+
+func TestDebugOverlay(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).RefreshRate(10 * time.Millisecond).SetDebugOverlay(true)
+	bar := p.AddBar(10)
+	time.Sleep(50 * time.Millisecond)
+	bar.Incr(10)
+	p.Stop()
+	if !strings.Contains(buf.String(), "frame 1 | 0s | ") {
+		t.Errorf("Debug overlay not found in: %q\n", buf.String())
+	}
+}
@@ -0,0 +1,78 @@
+// Package decor provides ready-made decorators of mpb bars. They are
+// attached at AddBar time by mpb.PrependDecorators and mpb.AppendDecorators.
+package decor
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// Formatting flags of decorators, mpb's flags of the same name are aliases
+// of these
+const (
+	// DidentRight aligns decorator to the left, padding it on the right
+	DidentRight = 1 << iota
+
+	// DwidthSync syncs width of decorator with its column
+	DwidthSync
+
+	// DextraSpace adds extra space, which makes sense with DwidthSync only
+	DextraSpace
+)
+
+// Statistics is a snapshot of bar's progress, which is rendered by
+// decorators
+type Statistics struct {
+	Completed                        bool
+	Total, Current                   int64
+	TimeElapsed, TimePerItemEstimate time.Duration
+}
+
+// Decorator renders a column of the bar. Decorator, which syncs its width,
+// sends its width to myWidth and pads itself to width received from
+// maxWidth.
+type Decorator interface {
+	Decor(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string
+}
+
+// DecoratorFunc is an adapter to use ordinary function as Decorator
+type DecoratorFunc func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string
+
+// Decor calls f(s, myWidth, maxWidth)
+func (f DecoratorFunc) Decor(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+	return f(s, myWidth, maxWidth)
+}
+
+// WC is width config of decorator. W is min width, C is conf bits, i.e.
+// DidentRight|DwidthSync.
+type WC struct {
+	W int
+	C byte
+}
+
+// FormatMsg pads msg according to wc, syncing width if DwidthSync is set
+func (wc WC) FormatMsg(msg string, myWidth chan<- int, maxWidth <-chan int) string {
+	format := "%%"
+	if (wc.C & DidentRight) != 0 {
+		format += "-"
+	}
+	format += "%ds"
+	width := wc.W
+	if (wc.C & DwidthSync) != 0 {
+		myWidth <- utf8.RuneCountInString(msg)
+		width = <-maxWidth
+		if (wc.C & DextraSpace) != 0 {
+			width++
+		}
+	}
+	return fmt.Sprintf(fmt.Sprintf(format, width), msg)
+}
+
+// firstWC returns the first of optional width configs, zero WC if none
+func firstWC(wcs []WC) WC {
+	if len(wcs) == 0 {
+		return WC{}
+	}
+	return wcs[0]
+}
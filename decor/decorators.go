@@ -0,0 +1,109 @@
+package decor
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/vbauerster/mpb/internal"
+)
+
+const (
+	_          = iota
+	bytesInKiB = 1 << (iota * 10)
+	bytesInMiB
+	bytesInGiB
+	bytesInTiB
+)
+
+// Units of AverageSpeed
+const (
+	// UnitNone renders plain number of items
+	UnitNone = iota
+	// UnitKiB renders number of bytes in KiB, MiB and so on
+	UnitKiB
+)
+
+// CounterKiB is number of bytes, which is formatted as "1.5MiB"
+type CounterKiB int64
+
+func (c CounterKiB) String() string {
+	n := int64(c)
+	switch {
+	case n >= bytesInTiB:
+		return fmt.Sprintf("%.1fTiB", float64(n)/bytesInTiB)
+	case n >= bytesInGiB:
+		return fmt.Sprintf("%.1fGiB", float64(n)/bytesInGiB)
+	case n >= bytesInMiB:
+		return fmt.Sprintf("%.1fMiB", float64(n)/bytesInMiB)
+	case n >= bytesInKiB:
+		return fmt.Sprintf("%.1fKiB", float64(n)/bytesInKiB)
+	default:
+		return fmt.Sprintf("%db", n)
+	}
+}
+
+// Percentage returns percentage decorator, like "42 %"
+func Percentage(wc ...WC) Decorator {
+	w := firstWC(wc)
+	return DecoratorFunc(func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		percent := internal.Percentage(s.Total, s.Current, 100)
+		return w.FormatMsg(fmt.Sprintf("%d %%", percent), myWidth, maxWidth)
+	})
+}
+
+// CountersKibiByte returns current/total counters decorator in KiB, MiB and
+// so on. The pairFormat gets two %s verbs, i.e. "%s / %s".
+func CountersKibiByte(pairFormat string, wc ...WC) Decorator {
+	w := firstWC(wc)
+	return DecoratorFunc(func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		msg := fmt.Sprintf(pairFormat, CounterKiB(s.Current), CounterKiB(s.Total))
+		return w.FormatMsg(msg, myWidth, maxWidth)
+	})
+}
+
+// AverageETA returns ETA decorator, which is based on average rate since
+// start, so it is steadier than moving average one. It is zero on completion.
+func AverageETA(wc ...WC) Decorator {
+	w := firstWC(wc)
+	return DecoratorFunc(func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		var eta time.Duration
+		if s.Current > 0 && s.Total > s.Current && !s.Completed {
+			perItem := float64(s.TimeElapsed) / float64(s.Current)
+			eta = time.Duration(perItem * float64(s.Total-s.Current))
+		}
+		return w.FormatMsg(fmt.Sprint(roundSeconds(eta)), myWidth, maxWidth)
+	})
+}
+
+// AverageSpeed returns decorator of average speed since start, formatted by
+// format with one %s verb, i.e. "%s/s". Speed is rendered in unit, which is
+// UnitNone or UnitKiB.
+func AverageSpeed(unit int, format string, wc ...WC) Decorator {
+	w := firstWC(wc)
+	return DecoratorFunc(func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		var speed float64
+		if s.TimeElapsed > 0 {
+			speed = float64(s.Current) / s.TimeElapsed.Seconds()
+		}
+		var str string
+		if unit == UnitKiB {
+			str = CounterKiB(speed + 0.5).String()
+		} else {
+			str = strconv.FormatFloat(speed, 'f', 2, 64)
+		}
+		return w.FormatMsg(fmt.Sprintf(format, str), myWidth, maxWidth)
+	})
+}
+
+// Elapsed returns decorator of time elapsed since start, like "1m5s"
+func Elapsed(wc ...WC) Decorator {
+	w := firstWC(wc)
+	return DecoratorFunc(func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		return w.FormatMsg(fmt.Sprint(roundSeconds(s.TimeElapsed)), myWidth, maxWidth)
+	})
+}
+
+func roundSeconds(d time.Duration) time.Duration {
+	return time.Duration(d.Seconds()) * time.Second
+}
@@ -0,0 +1,79 @@
+package decor
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDecorators(t *testing.T) {
+	s := &Statistics{
+		Total:       4 * 1024 * 1024,
+		Current:     1024 * 1024,
+		TimeElapsed: 10 * time.Second,
+	}
+	tests := []struct {
+		name string
+		d    Decorator
+		want string
+	}{
+		{"Percentage", Percentage(), "25 %"},
+		{"PercentageWidth", Percentage(WC{W: 6}), "  25 %"},
+		{"PercentageRight", Percentage(WC{W: 6, C: DidentRight}), "25 %  "},
+		{"CountersKibiByte", CountersKibiByte("%s / %s"), "1.0MiB / 4.0MiB"},
+		{"AverageETA", AverageETA(), "30s"},
+		{"AverageSpeed", AverageSpeed(UnitKiB, "%s/s"), "102.4KiB/s"},
+		{"AverageSpeedNone", AverageSpeed(UnitNone, "%s/s"), "104857.60/s"},
+		{"Elapsed", Elapsed(), "10s"},
+	}
+	for _, test := range tests {
+		if got := test.d.Decor(s, nil, nil); got != test.want {
+			t.Errorf("%s: want %q, got %q\n", test.name, test.want, got)
+		}
+	}
+}
+
+func TestWidthSync(t *testing.T) {
+	myWidth := make(chan int, 1)
+	maxWidth := make(chan int, 1)
+	maxWidth <- 6
+	got := Percentage(WC{C: DwidthSync | DextraSpace}).Decor(&Statistics{Total: 10, Current: 5}, myWidth, maxWidth)
+	if w := <-myWidth; w != 4 {
+		t.Errorf("Want reported width: 4, got: %d\n", w)
+	}
+	if want := "   50 %"; got != want {
+		t.Errorf("Want: %q, Got: %q\n", want, got)
+	}
+}
+
+func TestPercentageRounding(t *testing.T) {
+	tests := []struct {
+		total, current int64
+		want           string
+	}{
+		{200, 51, "26 %"},
+		{math.MaxInt64, math.MaxInt64 / 2, "50 %"},
+	}
+	for _, test := range tests {
+		s := &Statistics{Total: test.total, Current: test.current}
+		if got := Percentage().Decor(s, nil, nil); got != test.want {
+			t.Errorf("%d/%d: want %q, got %q\n", test.current, test.total, test.want, got)
+		}
+	}
+}
+
+func TestCounterKiB(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{512, "512b"},
+		{1536, "1.5KiB"},
+		{3 * bytesInGiB, "3.0GiB"},
+	}
+	for _, test := range tests {
+		if got := CounterKiB(test.n).String(); got != test.want {
+			t.Errorf("Want: %q, Got: %q\n", test.want, got)
+		}
+	}
+}
@@ -2,23 +2,31 @@ package mpb
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 	"unicode/utf8"
+
+	"github.com/vbauerster/mpb/decor"
+	"github.com/vbauerster/mpb/internal"
 )
 
 const (
 	// DidentRight specifies identation direction.
 	// |   foo|     b| Without DidentRight
 	// |foo   |b     | With DidentRight
-	DidentRight = 1 << iota
+	DidentRight = decor.DidentRight
 
 	// DwidthSync will auto sync max width
-	DwidthSync
+	DwidthSync = decor.DwidthSync
 
 	// DextraSpace adds extra space, makes sence with DwidthSync only.
 	// When DidentRight bit set, the space will be added to the right,
 	// otherwise to the left.
-	DextraSpace
+	DextraSpace = decor.DextraSpace
 )
 
 type decoratorOperation uint
@@ -28,183 +36,831 @@ const (
 	decPrepend
 	decAppendZero
 	decPrependZero
+	decReplace
+	decLayout
 )
 
 // DecoratorFunc is a function that can be prepended and appended to the progress bar
 type DecoratorFunc func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string
 
+// BytesDecoratorFunc is a decorator, which appends its output to dst and
+// returns the extended slice, like strconv.AppendInt. It must not modify
+// dst[:len(dst)]. Decorators are rendered into a shared buffer, so it
+// eliminates per frame string allocation of the decorator. It is the contract
+// decorators are rendered with, DecoratorFunc is adapted by BytesFunc.
+type BytesDecoratorFunc func(dst []byte, s *Statistics, myWidth chan<- int, maxWidth <-chan int) []byte
+
+// BytesFunc adapts DecoratorFunc to BytesDecoratorFunc
+func BytesFunc(f DecoratorFunc) BytesDecoratorFunc {
+	return func(dst []byte, s *Statistics, myWidth chan<- int, maxWidth <-chan int) []byte {
+		return append(dst, f(s, myWidth, maxWidth)...)
+	}
+}
+
+// StringFunc adapts BytesDecoratorFunc to DecoratorFunc, i.e. to wrap it
+// with Transform or Trunc
+func StringFunc(f BytesDecoratorFunc) DecoratorFunc {
+	return func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		return string(f(nil, s, myWidth, maxWidth))
+	}
+}
+
+// AppendPadded appends str to dst, padded as by conf, see formatDecorator,
+// without allocation. It is meant for BytesDecoratorFunc.
+func AppendPadded(dst, str []byte, minWidth int, conf byte, myWidth chan<- int, maxWidth <-chan int) []byte {
+	return padTail(append(dst, str...), len(dst), minWidth, conf, myWidth, maxWidth)
+}
+
+// padTail pads dst[start:], which is just appended by the decorator, as
+// AppendPadded does. Left padding shifts it in place, so the decorator
+// formats its output right into dst, without intermediate string.
+func padTail(dst []byte, start, minWidth int, conf byte, myWidth chan<- int, maxWidth <-chan int) []byte {
+	count := utf8.RuneCount(dst[start:])
+	width := minWidth
+	if (conf & DwidthSync) != 0 {
+		myWidth <- count
+		width = <-maxWidth
+		if (conf & DextraSpace) != 0 {
+			width++
+		}
+	}
+	pad := width - count
+	if pad <= 0 {
+		return dst
+	}
+	end := len(dst)
+	for i := 0; i < pad; i++ {
+		dst = append(dst, ' ')
+	}
+	if (conf & DidentRight) == 0 {
+		copy(dst[start+pad:], dst[start:end])
+		for i := start; i < start+pad; i++ {
+			dst[i] = ' '
+		}
+	}
+	return dst
+}
+
+// appendSeconds appends d truncated to seconds, formatted as by
+// time.Duration's String, i.e. "1h2m3s"
+func appendSeconds(dst []byte, d time.Duration) []byte {
+	secs := int64(d / time.Second)
+	if secs < 0 {
+		dst = append(dst, '-')
+		secs = -secs
+	}
+	if h := secs / 3600; h > 0 {
+		dst = strconv.AppendInt(dst, h, 10)
+		dst = append(dst, 'h')
+	}
+	if secs >= 60 {
+		dst = strconv.AppendInt(dst, secs/60%60, 10)
+		dst = append(dst, 'm')
+	}
+	dst = strconv.AppendInt(dst, secs%60, 10)
+	return append(dst, 's')
+}
+
+// bytesWriter appends written bytes to itself, so fmt.Fprintf formats right
+// into the decorator's dst
+type bytesWriter []byte
+
+func (w *bytesWriter) Write(p []byte) (int, error) {
+	*w = append(*w, p...)
+	return len(p), nil
+}
+
+// toBytesFuncs adapts funcs, see BytesFunc
+func toBytesFuncs(funcs []DecoratorFunc) []BytesDecoratorFunc {
+	if funcs == nil {
+		return nil
+	}
+	result := make([]BytesDecoratorFunc, len(funcs))
+	for i, f := range funcs {
+		result[i] = BytesFunc(f)
+	}
+	return result
+}
+
+// toStringFuncs adapts funcs, see StringFunc
+func toStringFuncs(funcs []BytesDecoratorFunc) []DecoratorFunc {
+	if funcs == nil {
+		return nil
+	}
+	result := make([]DecoratorFunc, len(funcs))
+	for i, f := range funcs {
+		result[i] = StringFunc(f)
+	}
+	return result
+}
+
 type decorator struct {
-	kind decoratorOperation
-	f    DecoratorFunc
+	kind   decoratorOperation
+	f      BytesDecoratorFunc
+	handle *DecoratorHandle
+	layout Layout
+	column string
 }
 
-// PrependName prepends name argument to the bar.
+// DecoratorHandle refers to a decorator attached to the bar.
+// It allows to replace the decorator at runtime, i.e. to change its unit,
+// precision or label, without disturbing column's width sync.
+type DecoratorHandle struct {
+	bar     *Bar
+	prepend bool
+}
+
+// Set replaces the decorator, which handle refers to, with f.
+// It is no-op, if the decorator has been removed by RemoveAllPrependers or
+// RemoveAllAppenders.
+func (h *DecoratorHandle) Set(f DecoratorFunc) {
+	h.SetBytes(BytesFunc(f))
+}
+
+// SetBytes is Set for BytesDecoratorFunc
+func (h *DecoratorHandle) SetBytes(f BytesDecoratorFunc) {
+	h.bar.sendDecorator(&decorator{kind: decReplace, f: f, handle: h})
+}
+
+// SetLayout sets Layout of the decorator, which handle refers to
+func (h *DecoratorHandle) SetLayout(l Layout) {
+	h.bar.sendDecorator(&decorator{kind: decLayout, handle: h, layout: l})
+}
+
+// PrependHandle prepends DecoratorFunc and returns its handle
+func (b *Bar) PrependHandle(f DecoratorFunc) *DecoratorHandle {
+	return b.PrependBytesHandle(BytesFunc(f))
+}
+
+// PrependBytesHandle prepends BytesDecoratorFunc and returns its handle
+func (b *Bar) PrependBytesHandle(f BytesDecoratorFunc) *DecoratorHandle {
+	h := &DecoratorHandle{bar: b, prepend: true}
+	b.sendDecorator(&decorator{kind: decPrepend, f: f, handle: h})
+	return h
+}
+
+// AppendHandle appends DecoratorFunc and returns its handle
+func (b *Bar) AppendHandle(f DecoratorFunc) *DecoratorHandle {
+	return b.AppendBytesHandle(BytesFunc(f))
+}
+
+// AppendBytesHandle appends BytesDecoratorFunc and returns its handle
+func (b *Bar) AppendBytesHandle(f BytesDecoratorFunc) *DecoratorHandle {
+	h := &DecoratorHandle{bar: b}
+	b.sendDecorator(&decorator{kind: decAppend, f: f, handle: h})
+	return h
+}
+
+// PrependColumn prepends f as column with identity id. If column order is
+// declared by (*Progress).SetColumns, decorators are rendered and synced by
+// column id, rather than by attachment order.
+func (b *Bar) PrependColumn(id string, f DecoratorFunc) *Bar {
+	return b.PrependBytesColumn(id, BytesFunc(f))
+}
+
+// PrependBytesColumn is PrependColumn for BytesDecoratorFunc
+func (b *Bar) PrependBytesColumn(id string, f BytesDecoratorFunc) *Bar {
+	b.sendDecorator(&decorator{kind: decPrepend, f: f, column: id})
+	return b
+}
+
+// AppendColumn appends f as column with identity id, see PrependColumn
+func (b *Bar) AppendColumn(id string, f DecoratorFunc) *Bar {
+	return b.AppendBytesColumn(id, BytesFunc(f))
+}
+
+// AppendBytesColumn is AppendColumn for BytesDecoratorFunc
+func (b *Bar) AppendBytesColumn(id string, f BytesDecoratorFunc) *Bar {
+	b.sendDecorator(&decorator{kind: decAppend, f: f, column: id})
+	return b
+}
+
+// Name returns name decorator.
 // The conf argument defines the formatting properties
-func (b *Bar) PrependName(name string, minWidth int, conf byte) *Bar {
-	format := "%%"
-	if (conf & DidentRight) != 0 {
-		format += "-"
+func Name(name string, minWidth int, conf byte) DecoratorFunc {
+	return StringFunc(NameBytes(name, minWidth, conf))
+}
+
+// NameBytes is append style Name, see BytesDecoratorFunc
+func NameBytes(name string, minWidth int, conf byte) BytesDecoratorFunc {
+	str := []byte(name)
+	return func(dst []byte, s *Statistics, myWidth chan<- int, maxWidth <-chan int) []byte {
+		return AppendPadded(dst, str, minWidth, conf, myWidth, maxWidth)
 	}
-	format += "%ds"
-	b.PrependFunc(func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
-		if (conf & DwidthSync) != 0 {
-			myWidth <- utf8.RuneCountInString(name)
-			max := <-maxWidth
-			if (conf & DextraSpace) != 0 {
-				max++
+}
+
+// Counters returns current/total counters decorator, formatted by pairFormat
+// in provided unit
+func Counters(pairFormat string, unit Units, minWidth int, conf byte) DecoratorFunc {
+	return StringFunc(CountersBytes(pairFormat, unit, minWidth, conf))
+}
+
+// CountersBytes is append style Counters, see BytesDecoratorFunc
+func CountersBytes(pairFormat string, unit Units, minWidth int, conf byte) BytesDecoratorFunc {
+	return func(dst []byte, s *Statistics, myWidth chan<- int, maxWidth <-chan int) []byte {
+		start := len(dst)
+		w := bytesWriter(dst)
+		fmt.Fprintf(&w, pairFormat, Format(s.Current).To(unit), Format(s.Total).To(unit))
+		return padTail(w, start, minWidth, conf, myWidth, maxWidth)
+	}
+}
+
+// Remaining returns decorator of remaining amount, total minus current,
+// formatted by format, i.e. "%s left", in provided unit. It is blank, while
+// total is unknown.
+func Remaining(format string, unit Units, minWidth int, conf byte) DecoratorFunc {
+	return func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		var str string
+		if s.Total > 0 {
+			remaining := s.Total - s.Current
+			if remaining < 0 {
+				remaining = 0
 			}
-			return fmt.Sprintf(fmt.Sprintf(format, max), name)
+			str = fmt.Sprintf(format, Format(remaining).To(unit))
 		}
-		return fmt.Sprintf(fmt.Sprintf(format, minWidth), name)
-	})
-	return b
+		return formatDecorator(str, minWidth, conf, myWidth, maxWidth)
+	}
 }
 
-func (b *Bar) PrependCounters(pairFormat string, unit Units, minWidth int, conf byte) *Bar {
-	format := "%%"
-	if (conf & DidentRight) != 0 {
-		format += "-"
+// ETA returns ETA decorator
+func ETA(minWidth int, conf byte) DecoratorFunc {
+	return StringFunc(ETABytes(minWidth, conf))
+}
+
+// ETABytes is append style ETA, see BytesDecoratorFunc
+func ETABytes(minWidth int, conf byte) BytesDecoratorFunc {
+	return func(dst []byte, s *Statistics, myWidth chan<- int, maxWidth <-chan int) []byte {
+		start := len(dst)
+		dst = appendSeconds(dst, s.Eta())
+		return padTail(dst, start, minWidth, conf, myWidth, maxWidth)
 	}
-	format += "%ds"
-	b.PrependFunc(func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
-		current := Format(s.Current).To(unit)
-		total := Format(s.Total).To(unit)
-		str := fmt.Sprintf(pairFormat, current, total)
-		if (conf & DwidthSync) != 0 {
-			myWidth <- utf8.RuneCountInString(str)
-			max := <-maxWidth
-			if (conf & DextraSpace) != 0 {
-				max++
-			}
-			return fmt.Sprintf(fmt.Sprintf(format, max), str)
+}
+
+// ETARange returns ETA range decorator, like "3-5 min", which is derived from
+// variance of the rate. It is more honest than ETA for highly variable
+// workloads.
+func ETARange(minWidth int, conf byte) DecoratorFunc {
+	return func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		str := formatDurationRange(s.EtaRange())
+		return formatDecorator(str, minWidth, conf, myWidth, maxWidth)
+	}
+}
+
+// formatDurationRange formats range in the largest unit, which fits max.
+// Min is rounded down and max is rounded up.
+func formatDurationRange(min, max time.Duration) string {
+	unit, name := time.Second, "s"
+	if max >= time.Hour {
+		unit, name = time.Hour, "h"
+	} else if max >= time.Minute {
+		unit, name = time.Minute, "min"
+	}
+	lo := min / unit
+	hi := (max + unit - 1) / unit
+	if lo == hi {
+		return fmt.Sprintf("%d %s", hi, name)
+	}
+	return fmt.Sprintf("%d–%d %s", lo, hi, name)
+}
+
+// SpeedCap returns decorator of actual speed versus configured cap, like
+// "4.2/10 MiB/s", so it is visible whether the limiter or the source is
+// the bottleneck. The cap is set by (*Bar).SetRateCap. Without cap, only
+// actual speed is displayed.
+func SpeedCap(unit Units, minWidth int, conf byte) DecoratorFunc {
+	return func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		str := formatSpeedCap(s.Rate(), s.RateCap, unit)
+		return formatDecorator(str, minWidth, conf, myWidth, maxWidth)
+	}
+}
+
+// formatSpeedCap formats both rates in the unit, which fits the cap
+func formatSpeedCap(rate, rateCap float64, unit Units) string {
+	scale, suffix := 1.0, "/s"
+	if unit == UnitBytes {
+		ref := rateCap
+		if ref <= 0 {
+			ref = rate
 		}
-		return fmt.Sprintf(fmt.Sprintf(format, minWidth), str)
-	})
-	return b
+		switch {
+		case ref >= bytesInGiB:
+			scale, suffix = bytesInGiB, " GiB/s"
+		case ref >= bytesInMiB:
+			scale, suffix = bytesInMiB, " MiB/s"
+		case ref >= bytesInKiB:
+			scale, suffix = bytesInKiB, " KiB/s"
+		default:
+			suffix = " b/s"
+		}
+	}
+	if rateCap <= 0 {
+		return fmt.Sprintf("%.1f%s", rate/scale, suffix)
+	}
+	capStr := strconv.FormatFloat(rateCap/scale, 'f', -1, 64)
+	return fmt.Sprintf("%.1f/%s%s", rate/scale, capStr, suffix)
 }
 
-func (b *Bar) PrependETA(minWidth int, conf byte) *Bar {
-	format := "%%"
-	if (conf & DidentRight) != 0 {
-		format += "-"
+// Speed returns speed decorator, like "4.2 MiB/s", with its own smoothing
+// window. Short window makes responsive "current speed", while the bar's
+// estimator, used by ETA, may be smoothed heavily. Zero window uses the
+// bar's estimate. Decorator keeps its state, so it must not be shared
+// between bars.
+func Speed(unit Units, window time.Duration, minWidth int, conf byte) DecoratorFunc {
+	rw := &rateWindow{window: window}
+	return func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		str := formatSpeedCap(rw.rate(time.Now(), s), 0, unit)
+		return formatDecorator(str, minWidth, conf, myWidth, maxWidth)
 	}
-	format += "%ds"
-	b.PrependFunc(func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
-		str := fmt.Sprint(time.Duration(s.Eta().Seconds()) * time.Second)
-		if (conf & DwidthSync) != 0 {
-			myWidth <- utf8.RuneCountInString(str)
-			max := <-maxWidth
-			if (conf & DextraSpace) != 0 {
-				max++
-			}
-			return fmt.Sprintf(fmt.Sprintf(format, max), str)
+}
+
+// ETAWindow returns ETA decorator, which is derived from rate over its own
+// smoothing window, see Speed. Until the rate is known, the bar's estimate
+// is used. Decorator keeps its state, so it must not be shared between bars.
+func ETAWindow(window time.Duration, minWidth int, conf byte) DecoratorFunc {
+	rw := &rateWindow{window: window}
+	return func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		eta := s.Eta()
+		if rate := rw.rate(time.Now(), s); rate > 0 {
+			eta = time.Duration(float64(s.Total-s.Current) / rate * float64(time.Second))
 		}
-		return fmt.Sprintf(fmt.Sprintf(format, minWidth), str)
-	})
-	return b
+		str := fmt.Sprint(time.Duration(eta.Seconds()) * time.Second)
+		return formatDecorator(str, minWidth, conf, myWidth, maxWidth)
+	}
 }
 
-func (b *Bar) AppendETA(minWidth int, conf byte) *Bar {
-	format := "%%"
-	if (conf & DidentRight) != 0 {
-		format += "-"
+// rateWindow computes rate over samples of the recent window
+type rateWindow struct {
+	window  time.Duration
+	samples []rateSample
+}
+
+type rateSample struct {
+	time    time.Time
+	current int64
+}
+
+func (rw *rateWindow) rate(now time.Time, s *Statistics) float64 {
+	if rw.window <= 0 {
+		return s.Rate()
 	}
-	format += "%ds"
-	b.AppendFunc(func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
-		str := fmt.Sprint(time.Duration(s.Eta().Seconds()) * time.Second)
-		if (conf & DwidthSync) != 0 {
-			myWidth <- utf8.RuneCountInString(str)
-			max := <-maxWidth
-			if (conf & DextraSpace) != 0 {
-				max++
-			}
-			return fmt.Sprintf(fmt.Sprintf(format, max), str)
+	if n := len(rw.samples); n == 0 || rw.samples[n-1].current != s.Current {
+		t := s.Updated
+		if t.IsZero() {
+			t = now
 		}
-		return fmt.Sprintf(fmt.Sprintf(format, minWidth), str)
-	})
-	return b
+		rw.samples = append(rw.samples, rateSample{t, s.Current})
+	}
+	// keep the last sample before the window as a baseline
+	cutoff := now.Add(-rw.window)
+	for len(rw.samples) > 1 && !rw.samples[1].time.After(cutoff) {
+		rw.samples = rw.samples[1:]
+	}
+	if len(rw.samples) < 2 {
+		return 0
+	}
+	base := rw.samples[0]
+	d := now.Sub(base.time)
+	if d <= 0 {
+		return 0
+	}
+	return float64(s.Current-base.current) / d.Seconds()
 }
 
-func (b *Bar) PrependElapsed(minWidth int, conf byte) *Bar {
-	format := "%%"
-	if (conf & DidentRight) != 0 {
-		format += "-"
+// Elapsed returns elapsed time decorator
+func Elapsed(minWidth int, conf byte) DecoratorFunc {
+	return StringFunc(ElapsedBytes(minWidth, conf))
+}
+
+// ElapsedBytes is append style Elapsed, see BytesDecoratorFunc
+func ElapsedBytes(minWidth int, conf byte) BytesDecoratorFunc {
+	return func(dst []byte, s *Statistics, myWidth chan<- int, maxWidth <-chan int) []byte {
+		start := len(dst)
+		dst = appendSeconds(dst, s.TimeElapsed)
+		return padTail(dst, start, minWidth, conf, myWidth, maxWidth)
 	}
-	format += "%ds"
-	b.PrependFunc(func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
-		str := fmt.Sprint(time.Duration(s.TimeElapsed.Seconds()) * time.Second)
-		if (conf & DwidthSync) != 0 {
-			myWidth <- utf8.RuneCountInString(str)
-			max := <-maxWidth
-			if (conf & DextraSpace) != 0 {
-				max++
-			}
-			return fmt.Sprintf(fmt.Sprintf(format, max), str)
+}
+
+// TimeBudget returns decorator of time budget consumed, elapsed time in
+// percents of budget, formatted by format, i.e. "%d%% of time budget used".
+// It is independent of progress and may exceed 100, which is useful for
+// time-boxed jobs. Elapsed time is wall clock, so it runs on, while the bar
+// stalls.
+func TimeBudget(budget time.Duration, format string, minWidth int, conf byte) DecoratorFunc {
+	return func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		var used int64
+		if budget > 0 {
+			used = int64(s.elapsed(time.Now()) * 100 / budget)
 		}
-		return fmt.Sprintf(fmt.Sprintf(format, minWidth), str)
-	})
-	return b
+		str := fmt.Sprintf(format, used)
+		return formatDecorator(str, minWidth, conf, myWidth, maxWidth)
+	}
 }
 
-func (b *Bar) AppendElapsed(minWidth int, conf byte) *Bar {
-	format := "%%"
-	if (conf & DidentRight) != 0 {
-		format += "-"
+// Percentage returns percentage decorator
+func Percentage(minWidth int, conf byte) DecoratorFunc {
+	return StringFunc(PercentageBytes(minWidth, conf))
+}
+
+// PercentageBytes is append style Percentage, see BytesDecoratorFunc
+func PercentageBytes(minWidth int, conf byte) BytesDecoratorFunc {
+	return func(dst []byte, s *Statistics, myWidth chan<- int, maxWidth <-chan int) []byte {
+		start := len(dst)
+		dst = strconv.AppendInt(dst, int64(internal.Percentage(s.Total, s.Current, 100)), 10)
+		dst = append(dst, " %"...)
+		return padTail(dst, start, minWidth, conf, myWidth, maxWidth)
 	}
-	format += "%ds"
-	b.AppendFunc(func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
-		str := fmt.Sprint(time.Duration(s.TimeElapsed.Seconds()) * time.Second)
-		if (conf & DwidthSync) != 0 {
-			myWidth <- utf8.RuneCountInString(str)
-			max := <-maxWidth
-			if (conf & DextraSpace) != 0 {
-				max++
+}
+
+// PercentageScale is scale of PercentageScaled decorator
+type PercentageScale int
+
+const (
+	// ScalePercent renders "42 %"
+	ScalePercent PercentageScale = iota
+	// ScalePermille renders "421 ‰"
+	ScalePermille
+	// ScaleBasisPoints renders "4213 bp"
+	ScaleBasisPoints
+	// ScaleFraction renders "0.421", fraction of 1
+	ScaleFraction
+)
+
+// PercentageScaled returns percentage decorator in alternative scale, for
+// cases, where percent granularity is insufficient
+func PercentageScaled(scale PercentageScale, minWidth int, conf byte) DecoratorFunc {
+	return func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		var str string
+		switch scale {
+		case ScalePermille:
+			str = fmt.Sprintf("%d ‰", internal.Percentage(s.Total, s.Current, 1000))
+		case ScaleBasisPoints:
+			str = fmt.Sprintf("%d bp", internal.Percentage(s.Total, s.Current, 10000))
+		case ScaleFraction:
+			var fraction float64
+			if s.Total > 0 && s.Current > 0 {
+				fraction = math.Min(float64(s.Current)/float64(s.Total), 1)
 			}
-			return fmt.Sprintf(fmt.Sprintf(format, max), str)
+			str = strconv.FormatFloat(fraction, 'f', 3, 64)
+		default:
+			str = fmt.Sprintf("%d %%", internal.Percentage(s.Total, s.Current, 100))
 		}
-		return fmt.Sprintf(fmt.Sprintf(format, minWidth), str)
-	})
-	return b
+		return formatDecorator(str, minWidth, conf, myWidth, maxWidth)
+	}
 }
 
-func (b *Bar) AppendPercentage(minWidth int, conf byte) *Bar {
-	format := "%%"
-	if (conf & DidentRight) != 0 {
-		format += "-"
+// Attempt returns decorator of current attempt, like "attempt 2/5", which is
+// fed by (*Bar).SetAttempt. It is empty, until the first attempt is set.
+func Attempt(minWidth int, conf byte) DecoratorFunc {
+	return func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		var str string
+		switch {
+		case s.Attempt <= 0:
+		case s.MaxAttempts > 0:
+			str = fmt.Sprintf("attempt %d/%d", s.Attempt, s.MaxAttempts)
+		default:
+			str = fmt.Sprintf("attempt %d", s.Attempt)
+		}
+		return formatDecorator(str, minWidth, conf, myWidth, maxWidth)
 	}
-	format += "%ds"
-	b.AppendFunc(func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
-		str := fmt.Sprintf("%d %%", percentage(s.Total, s.Current, 100))
-		if (conf & DwidthSync) != 0 {
-			myWidth <- utf8.RuneCountInString(str)
-			max := <-maxWidth
-			if (conf & DextraSpace) != 0 {
-				max++
+}
+
+// Failures returns decorator of processed items, like "1203 ok / 17 failed",
+// where failed items are recorded by (*Bar).IncrFailed
+func Failures(minWidth int, conf byte) DecoratorFunc {
+	return func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		str := fmt.Sprintf("%d ok / %d failed", s.Current-s.Failed, s.Failed)
+		return formatDecorator(str, minWidth, conf, myWidth, maxWidth)
+	}
+}
+
+// Gauge returns decorator of external value v, like "pending: 12", which is
+// loaded atomically on every render, so shared counters, i.e. queue depth,
+// don't need BeforeRender glue. The v may be shared by bars, header and
+// footer, see GaugeFunc. Format has one integer verb.
+func Gauge(format string, v *int64, minWidth int, conf byte) DecoratorFunc {
+	f := GaugeFunc(format, v)
+	return func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		return formatDecorator(f(), minWidth, conf, myWidth, maxWidth)
+	}
+}
+
+// GaugeFunc returns func of external value v, suitable for
+// (*Progress).SetHeader and SetFooter, see Gauge
+func GaugeFunc(format string, v *int64) func() string {
+	return func() string {
+		return fmt.Sprintf(format, atomic.LoadInt64(v))
+	}
+}
+
+// MiniBar returns compact textual bar decorator of fixed width, like "▰▰▰▱▱".
+// Useful, when the main bar is replaced by a message, but small progress hint
+// is still wanted.
+func MiniBar(width int, fill, empty rune) DecoratorFunc {
+	return func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		if width <= 0 {
+			return ""
+		}
+		completed := internal.Percentage(s.Total, s.Current, width)
+		if isCompleted(s) {
+			completed = width
+		}
+		runes := make([]rune, width)
+		for i := range runes {
+			if i < completed {
+				runes[i] = fill
+			} else {
+				runes[i] = empty
 			}
-			return fmt.Sprintf(fmt.Sprintf(format, max), str)
 		}
-		return fmt.Sprintf(fmt.Sprintf(format, minWidth), str)
-	})
-	return b
+		return string(runes)
+	}
+}
+
+// Spinner returns decorator, which advances a frame on every render, while
+// the bar is being incremented, and freezes, when the bar has been idle for
+// longer than idle. Combined with determinate bar it gives liveness hint in
+// addition to percentage. Spinner keeps its state, so it must not be shared
+// between bars.
+func Spinner(frames string, idle time.Duration) DecoratorFunc {
+	runes := []rune(frames)
+	var index int
+	return func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		if len(runes) == 0 {
+			return ""
+		}
+		if !s.Measuring() && !isCompleted(s) && time.Since(s.Updated) < idle {
+			index = (index + 1) % len(runes)
+		}
+		return string(runes[index])
+	}
+}
+
+// PrependName prepends name argument to the bar.
+// The conf argument defines the formatting properties
+func (b *Bar) PrependName(name string, minWidth int, conf byte) *Bar {
+	return b.PrependBytesFunc(NameBytes(name, minWidth, conf))
+}
+
+func (b *Bar) PrependCounters(pairFormat string, unit Units, minWidth int, conf byte) *Bar {
+	return b.PrependBytesFunc(CountersBytes(pairFormat, unit, minWidth, conf))
+}
+
+func (b *Bar) PrependRemaining(format string, unit Units, minWidth int, conf byte) *Bar {
+	return b.PrependFunc(Remaining(format, unit, minWidth, conf))
+}
+
+func (b *Bar) AppendRemaining(format string, unit Units, minWidth int, conf byte) *Bar {
+	return b.AppendFunc(Remaining(format, unit, minWidth, conf))
+}
+
+func (b *Bar) PrependETA(minWidth int, conf byte) *Bar {
+	return b.PrependBytesFunc(ETABytes(minWidth, conf))
+}
+
+func (b *Bar) AppendETA(minWidth int, conf byte) *Bar {
+	return b.AppendBytesFunc(ETABytes(minWidth, conf))
+}
+
+func (b *Bar) PrependETARange(minWidth int, conf byte) *Bar {
+	return b.PrependFunc(ETARange(minWidth, conf))
+}
+
+func (b *Bar) AppendETARange(minWidth int, conf byte) *Bar {
+	return b.AppendFunc(ETARange(minWidth, conf))
+}
+
+func (b *Bar) AppendSpeedCap(unit Units, minWidth int, conf byte) *Bar {
+	return b.AppendFunc(SpeedCap(unit, minWidth, conf))
+}
+
+func (b *Bar) AppendSpeed(unit Units, window time.Duration, minWidth int, conf byte) *Bar {
+	return b.AppendFunc(Speed(unit, window, minWidth, conf))
+}
+
+func (b *Bar) AppendETAWindow(window time.Duration, minWidth int, conf byte) *Bar {
+	return b.AppendFunc(ETAWindow(window, minWidth, conf))
+}
+
+func (b *Bar) PrependElapsed(minWidth int, conf byte) *Bar {
+	return b.PrependBytesFunc(ElapsedBytes(minWidth, conf))
+}
+
+func (b *Bar) AppendElapsed(minWidth int, conf byte) *Bar {
+	return b.AppendBytesFunc(ElapsedBytes(minWidth, conf))
+}
+
+// PrependSpinner prepends Spinner decorator to the bar
+func (b *Bar) PrependSpinner(frames string, idle time.Duration) *Bar {
+	return b.PrependFunc(Spinner(frames, idle))
+}
+
+// AppendSpinner appends Spinner decorator to the bar
+func (b *Bar) AppendSpinner(frames string, idle time.Duration) *Bar {
+	return b.AppendFunc(Spinner(frames, idle))
+}
+
+func (b *Bar) PrependAttempt(minWidth int, conf byte) *Bar {
+	return b.PrependFunc(Attempt(minWidth, conf))
+}
+
+func (b *Bar) AppendAttempt(minWidth int, conf byte) *Bar {
+	return b.AppendFunc(Attempt(minWidth, conf))
+}
+
+func (b *Bar) AppendFailures(minWidth int, conf byte) *Bar {
+	return b.AppendFunc(Failures(minWidth, conf))
+}
+
+func (b *Bar) AppendGauge(format string, v *int64, minWidth int, conf byte) *Bar {
+	return b.AppendFunc(Gauge(format, v, minWidth, conf))
+}
+
+func (b *Bar) AppendPercentage(minWidth int, conf byte) *Bar {
+	return b.AppendBytesFunc(PercentageBytes(minWidth, conf))
 }
 
 func (b *Bar) PrependPercentage(minWidth int, conf byte) *Bar {
+	return b.PrependBytesFunc(PercentageBytes(minWidth, conf))
+}
+
+func (b *Bar) AppendPercentageScaled(scale PercentageScale, minWidth int, conf byte) *Bar {
+	return b.AppendFunc(PercentageScaled(scale, minWidth, conf))
+}
+
+func (b *Bar) PrependPercentageScaled(scale PercentageScale, minWidth int, conf byte) *Bar {
+	return b.PrependFunc(PercentageScaled(scale, minWidth, conf))
+}
+
+// formatDecorator pads str according to conf, syncing width if DwidthSync set
+func formatDecorator(str string, minWidth int, conf byte, myWidth chan<- int, maxWidth <-chan int) string {
 	format := "%%"
 	if (conf & DidentRight) != 0 {
 		format += "-"
 	}
 	format += "%ds"
-	b.PrependFunc(func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
-		str := fmt.Sprintf("%d %%", percentage(s.Total, s.Current, 100))
-		if (conf & DwidthSync) != 0 {
-			myWidth <- utf8.RuneCountInString(str)
-			max := <-maxWidth
-			if (conf & DextraSpace) != 0 {
-				max++
-			}
-			return fmt.Sprintf(fmt.Sprintf(format, max), str)
+	if (conf & DwidthSync) != 0 {
+		myWidth <- utf8.RuneCountInString(str)
+		max := <-maxWidth
+		if (conf & DextraSpace) != 0 {
+			max++
 		}
-		return fmt.Sprintf(fmt.Sprintf(format, minWidth), str)
+		return fmt.Sprintf(fmt.Sprintf(format, max), str)
+	}
+	return fmt.Sprintf(fmt.Sprintf(format, minWidth), str)
+}
+
+// Transform wraps f, applying fn to its output. The fn must not change
+// visible width of the output, which makes it suitable for styling.
+// Use Trunc, if width should be changed.
+func Transform(f DecoratorFunc, fn func(string) string) DecoratorFunc {
+	return func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		return fn(f(s, myWidth, maxWidth))
+	}
+}
+
+// Color wraps output of f into ANSI SGR sequence, like "31" for red or
+// "1;34" for bold blue. Escape sequences are not counted as bar width.
+func Color(f DecoratorFunc, sgr string) DecoratorFunc {
+	return Transform(f, func(str string) string {
+		return fmt.Sprintf("%c[%sm%s%c[0m", 27, sgr, str, 27)
 	})
-	return b
+}
+
+// Trunc wraps f, truncating its output to maxWidth visible runes, escape
+// sequences, i.e. of Color, are kept. If f syncs width, truncated width is
+// reported, so column stays aligned.
+func Trunc(f DecoratorFunc, maxWidth int) DecoratorFunc {
+	return func(s *Statistics, myWidth chan<- int, maxWidthCh <-chan int) string {
+		str := relayWidth(f, s, myWidth, maxWidthCh, func(w int) int {
+			if w > maxWidth {
+				return maxWidth
+			}
+			return w
+		})
+		str = truncateVisible(str, maxWidth)
+		// SGR, which is cut off along with its reset, mustn't leak
+		if strings.IndexByte(str, 27) >= 0 && !strings.HasSuffix(str, "\x1b[0m") {
+			str += "\x1b[0m"
+		}
+		return str
+	}
+}
+
+// OnComplete wraps f, replacing its output with message, once the bar has
+// completed. Message is padded to width of f's output, so the column keeps its
+// width. Pass DidentRight in conf to align message to the left.
+func OnComplete(f DecoratorFunc, message string, conf byte) DecoratorFunc {
+	return replaceWhen(isCompleted, f, message, conf)
+}
+
+// OnAbort wraps f, replacing its output with message, i.e. "aborted", once
+// the bar has been aborted, see (*Bar).Abort and OnComplete
+func OnAbort(f DecoratorFunc, message string, conf byte) DecoratorFunc {
+	return replaceWhen(func(s *Statistics) bool {
+		return s.Aborted
+	}, f, message, conf)
+}
+
+// replaceWhen wraps f, replacing its output with message, padded to width of
+// f's output, while cond holds
+func replaceWhen(cond func(*Statistics) bool, f DecoratorFunc, message string, conf byte) DecoratorFunc {
+	return func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		if !cond(s) {
+			return f(s, myWidth, maxWidth)
+		}
+		msgWidth := utf8.RuneCountInString(message)
+		str := relayWidth(f, s, myWidth, maxWidth, func(int) int {
+			return msgWidth
+		})
+		format := "%*s"
+		if (conf & DidentRight) != 0 {
+			format = "%-*s"
+		}
+		return fmt.Sprintf(format, visibleRuneCount([]byte(str)), message)
+	}
+}
+
+// relay is a pair of buffered channels, which wrapped decorator syncs its
+// width with, see relayWidth
+type relay struct {
+	myWidth  chan int
+	maxWidth chan int
+}
+
+var relayPool = sync.Pool{New: func() interface{} {
+	return &relay{make(chan int, 1), make(chan int, 1)}
+}}
+
+// relayWidth calls f, relaying its width sync through adjust func. Relay
+// channels are buffered, so f runs in the caller's goroutine: f is measured
+// first, see Measuring, its adjusted width is synced, then f is rendered
+// with the synced max width.
+func relayWidth(f DecoratorFunc, s *Statistics, myWidth chan<- int, maxWidth <-chan int, adjust func(int) int) string {
+	r := relayPool.Get().(*relay)
+	defer relayPool.Put(r)
+	measuring := *s
+	measuring.measuring = true
+	r.maxWidth <- 0
+	f(&measuring, r.myWidth, r.maxWidth)
+	select {
+	case w := <-r.myWidth:
+		myWidth <- adjust(w)
+		r.maxWidth <- <-maxWidth
+	default:
+		// f doesn't sync width, so the outer sync is left alone
+	}
+	str := f(s, r.myWidth, r.maxWidth)
+	// f may sync on one call only, relay is left empty for the next one
+	select {
+	case <-r.myWidth:
+	default:
+	}
+	select {
+	case <-r.maxWidth:
+	default:
+	}
+	return str
+}
+
+// truncateVisible truncates str to width visible runes, keeping ANSI escape
+// sequences intact
+func truncateVisible(str string, width int) string {
+	if visibleRuneCount([]byte(str)) <= width {
+		return str
+	}
+	b := []byte(str)
+	buf := make([]byte, 0, len(b))
+	var n int
+	for len(b) > 0 {
+		if b[0] == 27 && len(b) > 1 {
+			rest := skipEscape(b)
+			buf = append(buf, b[:len(b)-len(rest)]...)
+			b = rest
+			continue
+		}
+		_, size := utf8.DecodeRune(b)
+		if n < width {
+			buf = append(buf, b[:size]...)
+			n++
+		}
+		b = b[size:]
+	}
+	return string(buf)
+}
+
+func truncate(str string, width int) string {
+	if width < 0 {
+		width = 0
+	}
+	if utf8.RuneCountInString(str) <= width {
+		return str
+	}
+	var i, n int
+	for i = range str {
+		if n == width {
+			break
+		}
+		n++
+	}
+	return str[:i]
 }
@@ -0,0 +1,378 @@
+package mpb
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTruncWidthSync(t *testing.T) {
+	names := []string{"a", "abcdefgh", "abc"}
+	funcs := make([]DecoratorFunc, len(names))
+	for i, name := range names {
+		funcs[i] = Trunc(Name(name, 0, DwidthSync|DidentRight), 5)
+	}
+	ws := newWidthSync(nil, len(funcs), 1)
+	results := make(chan string, len(funcs))
+	for _, f := range funcs {
+		go func(f DecoratorFunc) {
+			results <- f(&Statistics{}, ws.listen[0], ws.result[0])
+		}(f)
+	}
+	for range funcs {
+		got := <-results
+		if n := len(got); n != 5 {
+			t.Errorf("Want width: %d, got: %d (%q)\n", 5, n, got)
+		}
+	}
+}
+
+func TestTruncColor(t *testing.T) {
+	got := RenderDecorator(func() DecoratorFunc {
+		return Trunc(Color(Name("abcdefgh", 0, 0), "31"), 4)
+	}, &Statistics{})
+	if want := "\x1b[31mabcd\x1b[0m"; got[0] != want {
+		t.Errorf("Want: %q, Got: %q\n", want, got[0])
+	}
+	got = RenderDecorator(func() DecoratorFunc {
+		return Trunc(Transform(Name("abcdefgh", 0, 0), func(str string) string {
+			return "\x1b[1m" + str
+		}), 4)
+	}, &Statistics{})
+	if want := "\x1b[1mabcd\x1b[0m"; got[0] != want {
+		t.Errorf("Want open SGR closed: %q, Got: %q\n", want, got[0])
+	}
+	got = RenderDecorator(func() DecoratorFunc {
+		return Trunc(Color(Name("abcdefgh", 0, DwidthSync), "31"), 4)
+	}, &Statistics{}, &Statistics{})
+	for _, str := range got {
+		if want := "\x1b[31mabcd\x1b[0m"; str != want {
+			t.Errorf("Want: %q, Got: %q\n", want, str)
+		}
+	}
+}
+
+func TestTruncAdvancesOnce(t *testing.T) {
+	var calls int
+	f := Trunc(func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		if !s.Measuring() {
+			calls++
+		}
+		return formatDecorator("abcdefgh", 0, DwidthSync, myWidth, maxWidth)
+	}, 5)
+	ws := presetWidthSync([]int{3})
+	if got := f(&Statistics{}, ws.listen[0], ws.result[0]); got != "abcde" {
+		t.Errorf("Want: %q, Got: %q\n", "abcde", got)
+	}
+	if calls != 1 {
+		t.Errorf("Want decorator advanced once, got: %d\n", calls)
+	}
+	if w := ws.widths()[0]; w != 5 {
+		t.Errorf("Want reported width 5, got: %d\n", w)
+	}
+}
+
+func TestColorWidth(t *testing.T) {
+	s := newTestState()
+	s.width = 20
+	s.total = 100
+	s.current = 50
+	s.prependFuncs = toBytesFuncs([]DecoratorFunc{Color(Name("foo", 0, 0), "31")})
+	got := draw(s, 22, presetWidthSync([]int{0}), presetWidthSync(nil))
+	if n := visibleRuneCount(got); n != 22 {
+		t.Errorf("Want visible width: %d, got: %d (%q)\n", 22, n, got)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		str   string
+		width int
+		want  string
+	}{
+		{"foo", 5, "foo"},
+		{"foobar", 3, "foo"},
+		{"日本語", 2, "日本"},
+		{"foo", 0, ""},
+		{"foo", -1, ""},
+	}
+	for _, test := range tests {
+		if got := truncate(test.str, test.width); got != test.want {
+			t.Errorf("Want: %q, Got: %q\n", test.want, got)
+		}
+	}
+}
+
+func TestMiniBar(t *testing.T) {
+	tests := []struct {
+		total, current int64
+		want           string
+	}{
+		{100, 0, "▱▱▱▱▱"},
+		{100, 60, "▰▰▰▱▱"},
+		{100, 100, "▰▰▰▰▰"},
+		{0, 0, "▱▱▱▱▱"},
+	}
+	f := MiniBar(5, '▰', '▱')
+	for _, test := range tests {
+		got := f(&Statistics{Total: test.total, Current: test.current}, nil, nil)
+		if got != test.want {
+			t.Errorf("Want: %q, Got: %q\n", test.want, got)
+		}
+	}
+}
+
+func TestSpinner(t *testing.T) {
+	f := Spinner("ab", time.Second)
+	active := &Statistics{Total: 10, Current: 5, Updated: time.Now()}
+	if a, b := f(active, nil, nil), f(active, nil, nil); a == b {
+		t.Errorf("Active spinner doesn't advance: %q %q\n", a, b)
+	}
+	idle := &Statistics{Total: 10, Current: 5, Updated: time.Now().Add(-time.Minute)}
+	if a, b := f(idle, nil, nil), f(idle, nil, nil); a != b {
+		t.Errorf("Idle spinner advanced: %q %q\n", a, b)
+	}
+}
+
+func TestETARange(t *testing.T) {
+	tests := []struct {
+		stat Statistics
+		want string
+	}{
+		{Statistics{Total: 100, Current: 40, TimePerItemEstimate: 4 * time.Second, TimePerItemDeviation: time.Second}, "3–5 min"},
+		{Statistics{Total: 10, Current: 0, TimePerItemEstimate: 2 * time.Second}, "20 s"},
+		{Statistics{Total: 10, Current: 10}, "0 s"},
+	}
+	f := ETARange(0, 0)
+	for _, test := range tests {
+		if got := f(&test.stat, nil, nil); got != test.want {
+			t.Errorf("Want: %q, Got: %q\n", test.want, got)
+		}
+	}
+}
+
+func TestSpeedCap(t *testing.T) {
+	tpi := func(rate float64) time.Duration {
+		return time.Duration(float64(time.Second) / rate)
+	}
+	tests := []struct {
+		stat Statistics
+		unit Units
+		want string
+	}{
+		{Statistics{TimePerItemEstimate: tpi(4.2 * bytesInMiB), RateCap: 10 * bytesInMiB}, UnitBytes, "4.2/10 MiB/s"},
+		{Statistics{TimePerItemEstimate: tpi(512), RateCap: 0}, UnitBytes, "512.0 b/s"},
+		{Statistics{TimePerItemEstimate: tpi(5), RateCap: 10}, 0, "5.0/10/s"},
+		{Statistics{RateCap: 10}, 0, "0.0/10/s"},
+	}
+	for _, test := range tests {
+		f := SpeedCap(test.unit, 0, 0)
+		if got := f(&test.stat, nil, nil); got != test.want {
+			t.Errorf("Want: %q, Got: %q\n", test.want, got)
+		}
+	}
+}
+
+func TestAttempt(t *testing.T) {
+	tests := []struct {
+		attempt, max int
+		want         string
+	}{
+		{0, 5, ""},
+		{2, 5, "attempt 2/5"},
+		{3, 0, "attempt 3"},
+	}
+	f := Attempt(0, 0)
+	for _, test := range tests {
+		got := f(&Statistics{Attempt: test.attempt, MaxAttempts: test.max}, nil, nil)
+		if got != test.want {
+			t.Errorf("Want: %q, Got: %q\n", test.want, got)
+		}
+	}
+}
+
+func TestFailures(t *testing.T) {
+	f := Failures(0, 0)
+	got := f(&Statistics{Total: 2000, Current: 1220, Failed: 17}, nil, nil)
+	if want := "1203 ok / 17 failed"; got != want {
+		t.Errorf("Want: %q, Got: %q\n", want, got)
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	tests := []struct {
+		total, current int64
+		want           string
+	}{
+		{0, 10, ""},
+		{3 * 1024 * 1024, 1024 * 1024, "2.0MiB left"},
+		{100, 120, "0b left"},
+	}
+	f := Remaining("%s left", UnitBytes, 0, 0)
+	for _, test := range tests {
+		got := f(&Statistics{Total: test.total, Current: test.current}, nil, nil)
+		if got != test.want {
+			t.Errorf("Want: %q, Got: %q\n", test.want, got)
+		}
+	}
+}
+
+func TestPercentageScaled(t *testing.T) {
+	tests := []struct {
+		scale PercentageScale
+		want  string
+	}{
+		{ScalePercent, "42 %"},
+		{ScalePermille, "421 ‰"},
+		{ScaleBasisPoints, "4213 bp"},
+		{ScaleFraction, "0.421"},
+	}
+	stat := &Statistics{Total: 10000, Current: 4213}
+	for _, test := range tests {
+		if got := PercentageScaled(test.scale, 0, 0)(stat, nil, nil); got != test.want {
+			t.Errorf("Want: %q, Got: %q\n", test.want, got)
+		}
+	}
+}
+
+func TestOnCompleteDecorator(t *testing.T) {
+	tests := []struct {
+		current int64
+		conf    byte
+		want    string
+	}{
+		{50, 0, "  50 %"},
+		{100, 0, "  done"},
+		{100, DidentRight, "done  "},
+	}
+	for _, test := range tests {
+		f := OnComplete(Percentage(6, 0), "done", test.conf)
+		got := f(&Statistics{Total: 100, Current: test.current}, nil, nil)
+		if got != test.want {
+			t.Errorf("Want: %q, Got: %q\n", test.want, got)
+		}
+	}
+}
+
+func TestOnAbort(t *testing.T) {
+	f := OnAbort(Percentage(6, 0), "failed", 0)
+	if got := f(&Statistics{Total: 100, Current: 50}, nil, nil); got != "  50 %" {
+		t.Errorf("Want: %q, Got: %q\n", "  50 %", got)
+	}
+	if got := f(&Statistics{Total: 100, Current: 50, Aborted: true}, nil, nil); got != "failed" {
+		t.Errorf("Want: %q, Got: %q\n", "failed", got)
+	}
+}
+
+func TestTimeBudget(t *testing.T) {
+	tests := []struct {
+		budget, elapsed time.Duration
+		want            string
+	}{
+		{0, time.Second, "0% used"},
+		{100 * time.Second, 38 * time.Second, "38% used"},
+		{time.Minute, 90 * time.Second, "150% used"},
+	}
+	for _, test := range tests {
+		f := TimeBudget(test.budget, "%d%% used", 0, 0)
+		got := f(&Statistics{Total: 100, Current: 10, TimeElapsed: test.elapsed}, nil, nil)
+		if got != test.want {
+			t.Errorf("Want: %q, Got: %q\n", test.want, got)
+		}
+	}
+}
+
+func TestTimeBudgetStalled(t *testing.T) {
+	f := TimeBudget(100*time.Second, "%d%% used", 0, 0)
+	// no increments for 38s, so TimeElapsed stalls at 10s
+	s := &Statistics{TimeElapsed: 10 * time.Second, started: time.Now().Add(-38 * time.Second)}
+	if got := f(s, nil, nil); got != "38% used" {
+		t.Errorf("Want: %q, Got: %q\n", "38% used", got)
+	}
+}
+
+func TestRateWindow(t *testing.T) {
+	start := time.Now()
+	rw := &rateWindow{window: 2 * time.Second}
+	steps := []struct {
+		after   time.Duration
+		current int64
+		want    float64
+	}{
+		{0, 0, 0},
+		{time.Second, 10, 10},
+		{2 * time.Second, 30, 15},
+		{3 * time.Second, 40, 15},
+		// idle for the whole window
+		{6 * time.Second, 40, 0},
+	}
+	for i, step := range steps {
+		s := &Statistics{Current: step.current, Updated: start.Add(step.after)}
+		if step.after > 3*time.Second {
+			s.Updated = start.Add(3 * time.Second)
+		}
+		if got := rw.rate(start.Add(step.after), s); got != step.want {
+			t.Errorf("Step %d: want %v, got %v\n", i, step.want, got)
+		}
+	}
+}
+
+func TestGauge(t *testing.T) {
+	var pending int64 = 3
+	f := Gauge("pending: %d", &pending, 0, 0)
+	header := GaugeFunc("queue %d", &pending)
+	if got := f(&Statistics{}, nil, nil); got != "pending: 3" {
+		t.Errorf("Want: %q, Got: %q\n", "pending: 3", got)
+	}
+	atomic.AddInt64(&pending, 9)
+	if got := f(&Statistics{}, nil, nil); got != "pending: 12" {
+		t.Errorf("Want: %q, Got: %q\n", "pending: 12", got)
+	}
+	if got := header(); got != "queue 12" {
+		t.Errorf("Want: %q, Got: %q\n", "queue 12", got)
+	}
+}
+
+func TestAppendSeconds(t *testing.T) {
+	for _, d := range []time.Duration{
+		0,
+		999 * time.Millisecond,
+		59 * time.Second,
+		time.Minute,
+		90*time.Second + 500*time.Millisecond,
+		time.Hour,
+		26*time.Hour + 3*time.Minute + 4*time.Second,
+		-90 * time.Second,
+	} {
+		want := (d / time.Second * time.Second).String()
+		if got := string(appendSeconds([]byte(">"), d)); got != ">"+want {
+			t.Errorf("%v: want %q, got %q\n", d, ">"+want, got)
+		}
+	}
+}
+
+func TestAppendStockDecorators(t *testing.T) {
+	s := &Statistics{
+		Total:               2048,
+		Current:             1024,
+		TimeElapsed:         90 * time.Second,
+		TimePerItemEstimate: time.Second,
+	}
+	tests := []struct {
+		f    BytesDecoratorFunc
+		want string
+	}{
+		{NameBytes("foo", 5, 0), "  foo"},
+		{NameBytes("foo", 5, DidentRight), "foo  "},
+		{PercentageBytes(6, 0), "  50 %"},
+		{CountersBytes("%s / %s", UnitBytes, 0, 0), "1.0KiB / 2.0KiB"},
+		{ETABytes(8, 0), "   17m4s"},
+		{ElapsedBytes(0, 0), "1m30s"},
+	}
+	for _, test := range tests {
+		got := string(test.f([]byte(">"), s, nil, nil))
+		if got != ">"+test.want {
+			t.Errorf("Want: %q, Got: %q\n", ">"+test.want, got)
+		}
+	}
+}
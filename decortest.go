@@ -0,0 +1,36 @@
+package mpb
+
+import (
+	"sync"
+	"time"
+)
+
+// RenderDecorator renders decorator against synthetic statistics, one per
+// simulated bar, as if the bars were drawn by Progress. Width of decorators,
+// which set DwidthSync, is synced among the bars. New decorator is created
+// by newFunc for every bar, because decorators may keep state. It lets
+// decorator authors verify formatting and alignment without spinning up
+// Progress. Width sync is abandoned after a second, so a decorator, which
+// doesn't report its width, can't hang the caller.
+func RenderDecorator(newFunc func() DecoratorFunc, stats ...*Statistics) []string {
+	quit := make(chan struct{})
+	timer := time.AfterFunc(time.Second, func() {
+		close(quit)
+	})
+	ws := newWidthSync(quit, len(stats), 1)
+	result := make([]string, len(stats))
+	var wg sync.WaitGroup
+	wg.Add(len(stats))
+	for i, s := range stats {
+		go func(i int, f DecoratorFunc, s *Statistics) {
+			defer wg.Done()
+			result[i] = f(s, ws.listen[0], ws.result[0])
+		}(i, newFunc(), s)
+	}
+	wg.Wait()
+	// sync goroutine of decorator, which doesn't report its width, exits
+	if timer.Stop() {
+		close(quit)
+	}
+	return result
+}
@@ -0,0 +1,45 @@
+package mpb
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestRenderDecorator(t *testing.T) {
+	stats := []*Statistics{
+		{Total: 100, Current: 5},
+		{Total: 100, Current: 100},
+	}
+	tests := []struct {
+		conf byte
+		want []string
+	}{
+		{0, []string{"5 %", "100 %"}},
+		{DwidthSync, []string{"  5 %", "100 %"}},
+		{DwidthSync | DidentRight | DextraSpace, []string{"5 %   ", "100 % "}},
+	}
+	for _, test := range tests {
+		conf := test.conf
+		got := RenderDecorator(func() DecoratorFunc { return Percentage(0, conf) }, stats...)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Conf %b: want %q, got %q\n", conf, test.want, got)
+		}
+	}
+}
+
+func TestRenderDecoratorNoLeak(t *testing.T) {
+	stats := []*Statistics{{Total: 100, Current: 5}}
+	newFunc := func() DecoratorFunc { return Percentage(0, 0) }
+	RenderDecorator(newFunc, stats...)
+	time.Sleep(10 * time.Millisecond)
+	before := runtime.NumGoroutine()
+	for i := 0; i < 50; i++ {
+		RenderDecorator(newFunc, stats...)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if after := runtime.NumGoroutine(); after > before+5 {
+		t.Errorf("Want sync goroutines to exit, goroutines before: %d, after: %d\n", before, after)
+	}
+}
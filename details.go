@@ -0,0 +1,56 @@
+package mpb
+
+import "bytes"
+
+// DetailFunc returns detail lines of the bar, i.e. recent errors, chunk map
+// or raw stats, which are rendered below the bar, when it's expanded
+type DetailFunc func(s *Statistics) []string
+
+// SetDetails sets provider of detail lines, see Expand
+func (b *Bar) SetDetails(f DetailFunc) *Bar {
+	b.operate(func(s *state) {
+		s.details = f
+	})
+	return b
+}
+
+// Expand expands the bar into its detail lines, see SetDetails, or collapses
+// it back. Lines are truncated to terminal width and are rendered on
+// terminal only.
+func (b *Bar) Expand(expanded bool) *Bar {
+	b.operate(func(s *state) {
+		s.expanded = expanded
+	})
+	return b
+}
+
+// SetExpandFocused expands the focused bar, see SetFocus, regardless of
+// (*Bar).Expand, so details follow focus. Default is false.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetExpandFocused(expand bool) *Progress {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.expandCh <- expand:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// detailLines renders detail lines of expanded bar, each ending with newline
+func detailLines(s *state, termWidth int, force bool) []byte {
+	if s.details == nil || !(s.expanded || force) {
+		return nil
+	}
+	var buf []byte
+	for _, line := range s.details(newStatistics(s)) {
+		line = string(bytes.TrimRight([]byte(line), "\n"))
+		if termWidth > 0 {
+			line = truncateVisible(line, termWidth)
+		}
+		buf = append(buf, line...)
+		buf = append(buf, '\n')
+	}
+	return buf
+}
@@ -0,0 +1,55 @@
+package mpb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestDetailLines(t *testing.T) {
+	s := &state{total: 10, current: 4}
+	s.details = func(stat *Statistics) []string {
+		return []string{fmt.Sprintf("current: %d", stat.Current), "truncated line\n"}
+	}
+	if got := detailLines(s, 9, false); got != nil {
+		t.Errorf("Collapsed bar has details: %q\n", got)
+	}
+	want := "current: \ntruncated\n"
+	if got := string(detailLines(s, 9, true)); got != want {
+		t.Errorf("Want: %q, Got: %q\n", want, got)
+	}
+	s.expanded = true
+	if got := string(detailLines(s, 9, false)); got != want {
+		t.Errorf("Want: %q, Got: %q\n", want, got)
+	}
+}
+
+func TestDrawBarsDetails(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	a := p.AddBar(10)
+	b := p.AddBar(10).SetDetails(func(*Statistics) []string {
+		return []string{"detail"}
+	}).Expand(true)
+	ibbs := make([]indexedBarBuffer, 2)
+	for ibb := range drawBars([]*Bar{a, b}, 40, 0, time.Second, false) {
+		ibbs[ibb.index] = ibb
+	}
+	if ibbs[0].details != nil {
+		t.Errorf("Bar without details has: %q\n", ibbs[0].details)
+	}
+	if got := string(ibbs[1].details); got != "detail\n" {
+		t.Errorf("Want: %q, Got: %q\n", "detail\n", got)
+	}
+	a.Incr(10)
+	b.Incr(10)
+	p.Stop()
+}
+
+func TestAlignBlockDetails(t *testing.T) {
+	ibbs := []indexedBarBuffer{{buf: []byte("abcd\n"), details: []byte("x\ny\n")}}
+	alignBlock(ibbs, 6, AlignRight)
+	if got := string(ibbs[0].details); got != "  x\n  y\n" {
+		t.Errorf("Want: %q, Got: %q\n", "  x\n  y\n", got)
+	}
+}
@@ -0,0 +1,121 @@
+package mpb
+
+import (
+	"sync"
+	"time"
+)
+
+// BarEventKind is kind of bar lifecycle event
+type BarEventKind int
+
+const (
+	// BarAdded is emitted, when the bar is added to the container
+	BarAdded BarEventKind = iota
+	// BarStarted is emitted on the first progress of the bar
+	BarStarted
+	// BarCompleted is emitted, when the bar has reached its total
+	BarCompleted
+	// BarAborted is emitted, when the bar has exited incomplete, i.e. on
+	// cancel or removal
+	BarAborted
+	// BarRemoved is emitted, when the bar is removed from the container
+	BarRemoved
+)
+
+var barEventKinds = [...]string{"added", "started", "completed", "aborted", "removed"}
+
+func (k BarEventKind) String() string {
+	if k < 0 || int(k) >= len(barEventKinds) {
+		return "unknown"
+	}
+	return barEventKinds[k]
+}
+
+// BarEvent is lifecycle event of a bar, see Events
+type BarEvent struct {
+	Kind BarEventKind
+	Bar  *Bar
+	ID   int
+	Time time.Time
+}
+
+// Events returns channel of bar lifecycle events in order of occurrence.
+// Events are emitted only after the first call, they're queued, so the
+// renderer is never blocked by slow reader. The channel is closed, when
+// Progress is stopped. All calls return the same channel.
+func (p *Progress) Events() <-chan BarEvent {
+	return p.events.subscribe()
+}
+
+// eventQueue is unbounded queue of events, which are pumped to subscriber
+type eventQueue struct {
+	mu      sync.Mutex
+	ch      chan BarEvent
+	signal  chan struct{}
+	pending []BarEvent
+	closed  bool
+}
+
+func (q *eventQueue) subscribe() <-chan BarEvent {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.ch == nil {
+		q.ch = make(chan BarEvent)
+		q.signal = make(chan struct{}, 1)
+		go q.pump()
+	}
+	return q.ch
+}
+
+// emit queues event, it's no-op without subscriber or after close
+func (q *eventQueue) emit(kind BarEventKind, b *Bar, id int) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	if q.ch == nil || q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.pending = append(q.pending, BarEvent{kind, b, id, time.Now()})
+	q.mu.Unlock()
+	q.nudge()
+}
+
+// close closes subscriber's channel, once pending events are delivered
+func (q *eventQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	subscribed := q.ch != nil
+	q.mu.Unlock()
+	if subscribed {
+		q.nudge()
+	}
+}
+
+func (q *eventQueue) nudge() {
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (q *eventQueue) pump() {
+	for {
+		q.mu.Lock()
+		batch, closed := q.pending, q.closed
+		q.pending = nil
+		q.mu.Unlock()
+		for _, e := range batch {
+			q.ch <- e
+		}
+		if len(batch) > 0 {
+			continue
+		}
+		if closed {
+			close(q.ch)
+			return
+		}
+		<-q.signal
+	}
+}
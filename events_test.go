@@ -0,0 +1,82 @@
+package mpb
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestEvents(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	events := p.Events()
+	done := p.AddBarWithID(1, 10)
+	removed := p.AddBarWithID(2, 10)
+	done.Incr(5)
+	done.Incr(5)
+	p.RemoveBar(removed)
+	p.Stop()
+
+	var got []BarEvent
+	for e := range events {
+		got = append(got, e)
+	}
+	kinds := make(map[int][]BarEventKind)
+	for _, e := range got {
+		if e.Time.IsZero() {
+			t.Errorf("Event %v of bar #%d has no time\n", e.Kind, e.ID)
+		}
+		kinds[e.ID] = append(kinds[e.ID], e.Kind)
+	}
+	want := []BarEventKind{BarAdded, BarStarted, BarCompleted}
+	if !equalKinds(kinds[1], want) {
+		t.Errorf("Bar #1: want %v, got %v\n", want, kinds[1])
+	}
+	// aborted and removed are emitted by different goroutines
+	if len(kinds[2]) != 3 || kinds[2][0] != BarAdded {
+		t.Errorf("Bar #2: unexpected events %v\n", kinds[2])
+	} else if !equalKinds(kinds[2][1:], []BarEventKind{BarAborted, BarRemoved}) &&
+		!equalKinds(kinds[2][1:], []BarEventKind{BarRemoved, BarAborted}) {
+		t.Errorf("Bar #2: unexpected events %v\n", kinds[2])
+	}
+}
+
+func TestEventsStartedByOperate(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	events := p.Events()
+	p.AddBarWithID(1, 10).IncrBy(10, time.Millisecond)
+	p.AddBarWithID(2, 10).IncrFailed(10)
+	p.AddBarWithID(3, 10).ResumeFrom(10, time.Second)
+	p.Stop()
+
+	kinds := make(map[int][]BarEventKind)
+	for e := range events {
+		kinds[e.ID] = append(kinds[e.ID], e.Kind)
+	}
+	want := []BarEventKind{BarAdded, BarStarted, BarCompleted}
+	for id := 1; id <= 3; id++ {
+		if !equalKinds(kinds[id], want) {
+			t.Errorf("Bar #%d: want %v, got %v\n", id, want, kinds[id])
+		}
+	}
+}
+
+func TestBarEventKindString(t *testing.T) {
+	if got := BarCompleted.String(); got != "completed" {
+		t.Errorf("Want: %q, Got: %q\n", "completed", got)
+	}
+	if got := BarEventKind(42).String(); got != "unknown" {
+		t.Errorf("Want: %q, Got: %q\n", "unknown", got)
+	}
+}
+
+func equalKinds(a, b []BarEventKind) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
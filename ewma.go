@@ -0,0 +1,72 @@
+package mpb
+
+import (
+	"fmt"
+	"time"
+)
+
+// IncrBy increments the bar by n items, which took dur of work, i.e. time of
+// a network read. Work time feeds exponential-weighted-moving-average of per
+// item time, which excludes idle gaps between increments, so EwmaETA and
+// EwmaSpeed stay steady for bursty transfers. Smoothing is set by
+// SetEtaAlpha.
+func (b *Bar) IncrBy(n int, dur time.Duration) {
+	if n < 1 {
+		return
+	}
+	b.operate(func(s *state) {
+		if s.completed {
+			return
+		}
+		current := s.current
+		s.incr(int64(n), time.Now())
+		if items := s.current - current; items > 0 {
+			s.ewmaUpdate(dur, items)
+		}
+	})
+}
+
+// ewmaUpdate adds per item time of items done in dur to the moving average.
+// The first sample seeds the average.
+func (s *state) ewmaUpdate(dur time.Duration, items int64) {
+	if s.ewmaTimePerItem <= 0 {
+		if dur < 0 {
+			dur = 0
+		}
+		s.ewmaTimePerItem = dur / time.Duration(items)
+		return
+	}
+	s.ewmaTimePerItem = calcTimePerItemEstimate(s.ewmaTimePerItem, dur, s.etaAlpha, items)
+}
+
+// ewmaTimePerItem returns moving average of per item work time, or the bar's
+// estimate, if IncrBy isn't used
+func ewmaTimePerItem(s *Statistics) time.Duration {
+	if s.EwmaTimePerItem > 0 {
+		return s.EwmaTimePerItem
+	}
+	return s.TimePerItemEstimate
+}
+
+// EwmaETA returns ETA decorator, which is derived from moving average of per
+// item work time, reported by (*Bar).IncrBy
+func EwmaETA(minWidth int, conf byte) DecoratorFunc {
+	return func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		eta := time.Duration(s.Total-s.Current) * ewmaTimePerItem(s)
+		str := fmt.Sprint(time.Duration(eta.Seconds()) * time.Second)
+		return formatDecorator(str, minWidth, conf, myWidth, maxWidth)
+	}
+}
+
+// EwmaSpeed returns speed decorator, like "4.2 MiB/s", which is derived from
+// moving average of per item work time, reported by (*Bar).IncrBy
+func EwmaSpeed(unit Units, minWidth int, conf byte) DecoratorFunc {
+	return func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		var rate float64
+		if tpi := ewmaTimePerItem(s); tpi > 0 {
+			rate = float64(time.Second) / float64(tpi)
+		}
+		str := formatSpeedCap(rate, 0, unit)
+		return formatDecorator(str, minWidth, conf, myWidth, maxWidth)
+	}
+}
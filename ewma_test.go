@@ -0,0 +1,44 @@
+package mpb
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestIncrBy(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	bar := p.AddBar(100)
+	for i := 0; i < 5; i++ {
+		bar.IncrBy(10, 10*time.Millisecond)
+	}
+	bar.IncrBy(10, 30*time.Millisecond)
+	s := bar.GetStatistics()
+	if s.Current != 60 {
+		t.Errorf("Want current 60, got %d\n", s.Current)
+	}
+	// seed of 1ms, then 0.25 * 3ms + 0.75 * 1ms
+	if want := 1500 * time.Microsecond; s.EwmaTimePerItem != want {
+		t.Errorf("Want ewma time per item %v, got %v\n", want, s.EwmaTimePerItem)
+	}
+	bar.IncrBy(40, 0)
+	if s := bar.GetStatistics(); s.Current != s.Total {
+		t.Error("Want bar completed")
+	}
+	p.Stop()
+}
+
+func TestEwmaDecorators(t *testing.T) {
+	s := &Statistics{Total: 100, Current: 50, TimePerItemEstimate: time.Second}
+	if got := EwmaETA(0, 0)(s, nil, nil); got != "50s" {
+		t.Errorf("Want fallback ETA %q, got %q\n", "50s", got)
+	}
+	s.EwmaTimePerItem = 100 * time.Millisecond
+	if got := EwmaETA(0, 0)(s, nil, nil); got != "5s" {
+		t.Errorf("Want ETA %q, got %q\n", "5s", got)
+	}
+	s.EwmaTimePerItem = time.Second / 2048
+	if got := EwmaSpeed(UnitBytes, 0, 0)(s, nil, nil); got != "2.0 KiB/s" {
+		t.Errorf("Want speed %q, got %q\n", "2.0 KiB/s", got)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/vbauerster/mpb"
+)
+
+var (
+	numBars    = flag.Int("bars", 64, "number of bars")
+	total      = flag.Int("total", 1000, "total of each bar")
+	incrRate   = flag.Int("rate", 100, "increments per second, per bar")
+	writeDelay = flag.Duration("write-delay", 0, "simulated slow writer delay per write")
+	drawers    = flag.Int("drawers", 0, "max drawer goroutines, 0 means unlimited")
+	refresh    = flag.Duration("refresh", 100*time.Millisecond, "refresh rate")
+	quiet      = flag.Bool("quiet", true, "discard rendered output")
+)
+
+// slowWriter simulates slow terminal and measures write latency
+type slowWriter struct {
+	delay   time.Duration
+	out     *os.File
+	writes  int
+	maxTime time.Duration
+	sumTime time.Duration
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	start := time.Now()
+	time.Sleep(w.delay)
+	var n int
+	var err error
+	if w.out != nil {
+		n, err = w.out.Write(p)
+	} else {
+		n, err = ioutil.Discard.Write(p)
+	}
+	d := time.Since(start)
+	w.writes++
+	w.sumTime += d
+	if d > w.maxTime {
+		w.maxTime = d
+	}
+	return n, err
+}
+
+func main() {
+	flag.Parse()
+
+	w := &slowWriter{delay: *writeDelay}
+	if !*quiet {
+		w.out = os.Stdout
+	}
+
+	var frames int
+	var lastFrame time.Time
+	var maxFrameGap time.Duration
+	// slowWriter isn't a terminal, disable auto refresh mode, so every frame
+	// is measured, not only periodic plain ones
+	p := mpb.New().SetOut(w).RefreshRate(*refresh).SetMaxDrawers(*drawers).
+		SetAutoRefreshMode(-1).
+		SetReporter(mpb.ReporterFunc(func(current, total int64) {
+			now := time.Now()
+			if frames > 0 {
+				if gap := now.Sub(lastFrame); gap > maxFrameGap {
+					maxFrameGap = gap
+				}
+			}
+			lastFrame = now
+			frames++
+		}))
+
+	var ms0 runtime.MemStats
+	runtime.ReadMemStats(&ms0)
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	wg.Add(*numBars)
+	for i := 0; i < *numBars; i++ {
+		name := fmt.Sprintf("Bar#%03d:", i)
+		bar := p.AddBar(int64(*total)).
+			PrependName(name, 0, mpb.DwidthSync).
+			PrependCounters("%3s/%3s", 0, 0, mpb.DwidthSync|mpb.DextraSpace).
+			AppendPercentage(5, 0)
+		go func() {
+			defer wg.Done()
+			interval := time.Second / time.Duration(*incrRate)
+			for i := 0; i < *total; i++ {
+				time.Sleep(interval)
+				bar.Incr(1)
+			}
+		}()
+	}
+	wg.Wait()
+	p.Stop()
+
+	elapsed := time.Since(start)
+	var ms1 runtime.MemStats
+	runtime.ReadMemStats(&ms1)
+
+	fmt.Fprintf(os.Stderr, "elapsed:        %v\n", elapsed)
+	fmt.Fprintf(os.Stderr, "frames:         %d\n", frames)
+	fmt.Fprintf(os.Stderr, "max frame gap:  %v\n", maxFrameGap)
+	fmt.Fprintf(os.Stderr, "allocs:         %d (%d per frame)\n", ms1.Mallocs-ms0.Mallocs, (ms1.Mallocs-ms0.Mallocs)/uint64(maxInt(frames, 1)))
+	fmt.Fprintf(os.Stderr, "alloc bytes:    %d\n", ms1.TotalAlloc-ms0.TotalAlloc)
+	fmt.Fprintf(os.Stderr, "gc cycles:      %d\n", ms1.NumGC-ms0.NumGC)
+	if w.writes > 0 {
+		fmt.Fprintf(os.Stderr, "writes:         %d\n", w.writes)
+		fmt.Fprintf(os.Stderr, "write latency:  avg %v, max %v\n", w.sumTime/time.Duration(w.writes), w.maxTime)
+	}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
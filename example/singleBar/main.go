@@ -12,7 +12,8 @@ func main() {
 	p := mpb.New()
 	// Set custom width for every bar, which mpb will render
 	// The default one in 70
-	p.SetWidth(80)
+	// SetWidth returns a copy, so keep the result
+	p = p.SetWidth(80)
 	// Set custom format for every bar, the default one is "[=>-]"
 	p.Format("╢▌▌░╟")
 	// Set custom refresh rate, the default one is 100 ms
@@ -17,7 +17,8 @@ func Example() {
 	p := mpb.New()
 	// Set custom width for every bar, which mpb will contain
 	// The default one in 70
-	p.SetWidth(80)
+	// SetWidth returns a copy, so keep the result
+	p = p.SetWidth(80)
 	// Set custom format for every bar, the default one is "[=>-]"
 	p.Format("╢▌▌░╟")
 	// Set custom refresh rate, the default one is 100 ms
@@ -111,3 +112,19 @@ func ExampleBar_AppendFunc() {
 	wg.Wait() // Wait for goroutines to finish
 	p.Stop()  // Stop mpb's rendering goroutine
 }
+
+func ExampleDecoratorHandle() {
+	p := mpb.New()
+	bar := p.AddBar(100)
+	status := bar.PrependHandle(mpb.Name("downloading", 0, 0))
+
+	for i := 0; i < 100; i++ {
+		if i == 50 {
+			// change the label at runtime
+			status.Set(mpb.Name("verifying", 0, 0))
+		}
+		bar.Incr(1)
+		time.Sleep(time.Duration(rand.Intn(100)) * time.Millisecond)
+	}
+	p.Stop()
+}
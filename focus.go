@@ -0,0 +1,135 @@
+package mpb
+
+import "bytes"
+
+// defaultFocusSGR is reverse video
+const defaultFocusSGR = "7"
+
+// FocusAction is an action on the focused bar, see BindKey
+type FocusAction func(b *Bar)
+
+// SetFocus focuses b, which is rendered with focus style, see SetFocusStyle,
+// and is the target of key actions, see BindKey. Nil clears focus. Focus is
+// cleared, when the bar is removed from the container.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetFocus(b *Bar) *Progress {
+	p.focusOp(barFocus, b)
+	return p
+}
+
+// Focused returns the focused bar, nil if there is none
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) Focused() *Bar {
+	return p.focusOp(barFocused, nil)
+}
+
+// FocusNext moves focus to the next bar in render order, wrapping around,
+// and returns the focused bar. If there is no focus, the first bar is focused.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) FocusNext() *Bar {
+	return p.focusOp(barFocusNext, nil)
+}
+
+// FocusPrev moves focus to the previous bar in render order, wrapping around,
+// and returns the focused bar. If there is no focus, the last bar is focused.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) FocusPrev() *Bar {
+	return p.focusOp(barFocusPrev, nil)
+}
+
+func (p *Progress) focusOp(kind barOpType, b *Bar) *Bar {
+	p.acquire()
+	defer p.release()
+	op := &operation{kind: kind, bar: b, focused: make(chan *Bar, 1)}
+	select {
+	case p.operationCh <- op:
+		return <-op.focused
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+}
+
+// SetFocusStyle sets ANSI SGR style of the focused bar's line, like "44" for
+// blue background. Default is "7", reverse video. Style is applied on
+// terminal with colors only.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetFocusStyle(sgr string) *Progress {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.focusStyleCh <- sgr:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// BindKey binds action on the focused bar to key, i.e. 'p' to pause or 'a'
+// to abort. Progress doesn't read input, keys are fed by HandleKey. Nil
+// action unbinds the key.
+func (p *Progress) BindKey(key rune, action FocusAction) *Progress {
+	h := p.srv
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if action == nil {
+		delete(h.keys, key)
+		return p
+	}
+	if h.keys == nil {
+		h.keys = make(map[rune]FocusAction)
+	}
+	h.keys[key] = action
+	return p
+}
+
+// HandleKey runs action bound to key on the focused bar, and reports whether
+// it did. Action runs in caller's goroutine, so it may call methods of p.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) HandleKey(key rune) bool {
+	h := p.srv
+	h.mu.Lock()
+	action := h.keys[key]
+	h.mu.Unlock()
+	if action == nil {
+		return false
+	}
+	b := p.Focused()
+	if b == nil {
+		return false
+	}
+	action(b)
+	return true
+}
+
+// moveFocus returns bar next to focus by delta in bars, wrapping around
+func moveFocus(bars []*Bar, focus *Bar, delta int) *Bar {
+	if len(bars) == 0 {
+		return nil
+	}
+	for i, b := range bars {
+		if b == focus {
+			return bars[(i+delta+len(bars))%len(bars)]
+		}
+	}
+	if delta < 0 {
+		return bars[len(bars)-1]
+	}
+	return bars[0]
+}
+
+// styleLine applies sgr to the whole line, reapplying it after resets of
+// the line's own escape sequences
+func styleLine(line []byte, sgr string) []byte {
+	start := []byte("\x1b[" + sgr + "m")
+	reset := []byte("\x1b[0m")
+	body := bytes.TrimSuffix(line, []byte("\n"))
+	body = bytes.Replace(body, reset, append(reset, start...), -1)
+	styled := make([]byte, 0, len(body)+len(start)+len(reset)+1)
+	styled = append(styled, start...)
+	styled = append(styled, body...)
+	styled = append(styled, reset...)
+	if len(body) < len(line) {
+		styled = append(styled, '\n')
+	}
+	return styled
+}
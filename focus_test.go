@@ -0,0 +1,64 @@
+package mpb
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestFocusNavigation(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	a := p.AddBar(10)
+	b := p.AddBar(10)
+	c := p.AddBar(10)
+
+	if focused := p.Focused(); focused != nil {
+		t.Errorf("Want no focus, got bar #%d\n", focused.GetID())
+	}
+	for _, want := range []*Bar{a, b, c, a} {
+		if got := p.FocusNext(); got != want {
+			t.Errorf("FocusNext: want bar #%d, got bar #%d\n", want.GetID(), got.GetID())
+		}
+	}
+	if got := p.FocusPrev(); got != c {
+		t.Errorf("FocusPrev: want bar #%d, got bar #%d\n", c.GetID(), got.GetID())
+	}
+	p.RemoveBar(c)
+	if focused := p.Focused(); focused != nil {
+		t.Errorf("Want focus cleared on remove, got bar #%d\n", focused.GetID())
+	}
+
+	var actioned *Bar
+	p.BindKey('x', func(b *Bar) { actioned = b })
+	if p.HandleKey('x') {
+		t.Error("Key is handled without focus")
+	}
+	p.SetFocus(b)
+	if !p.HandleKey('x') || actioned != b {
+		t.Error("Key action isn't run on focused bar")
+	}
+	if p.HandleKey('y') {
+		t.Error("Unbound key is handled")
+	}
+	p.BindKey('x', nil)
+	if p.HandleKey('x') {
+		t.Error("Unbound key is handled")
+	}
+
+	a.Incr(10)
+	b.Incr(10)
+	p.Stop()
+}
+
+func TestStyleLine(t *testing.T) {
+	tests := []struct {
+		line, want string
+	}{
+		{"foo\n", "\x1b[7mfoo\x1b[0m\n"},
+		{"a \x1b[31m[==]\x1b[0m b", "\x1b[7ma \x1b[31m[==]\x1b[0m\x1b[7m b\x1b[0m"},
+	}
+	for _, test := range tests {
+		if got := string(styleLine([]byte(test.line), "7")); got != test.want {
+			t.Errorf("Want: %q, Got: %q\n", test.want, got)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package mpb
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/vbauerster/mpb/decor"
+)
 
 const (
 	_          = iota
@@ -40,24 +44,8 @@ func (f *formatter) Width(width int) *formatter {
 func (f *formatter) String() string {
 	switch f.unit {
 	case UnitBytes:
-		return formatBytes(f.n)
+		return decor.CounterKiB(f.n).String()
 	default:
 		return fmt.Sprintf(fmt.Sprintf("%%%dd", f.width), f.n)
 	}
 }
-
-func formatBytes(i int64) (result string) {
-	switch {
-	case i >= bytesInTiB:
-		result = fmt.Sprintf("%.1fTiB", float64(i)/bytesInTiB)
-	case i >= bytesInGiB:
-		result = fmt.Sprintf("%.1fGiB", float64(i)/bytesInGiB)
-	case i >= bytesInMiB:
-		result = fmt.Sprintf("%.1fMiB", float64(i)/bytesInMiB)
-	case i >= bytesInKiB:
-		result = fmt.Sprintf("%.1fKiB", float64(i)/bytesInKiB)
-	default:
-		result = fmt.Sprintf("%db", i)
-	}
-	return
-}
@@ -0,0 +1,67 @@
+// +build go1.18
+
+package mpb
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/vbauerster/mpb/internal"
+)
+
+func FuzzFormat(f *testing.F) {
+	f.Add(int64(0), 0)
+	f.Add(int64(1024), 10)
+	f.Add(int64(-1), -10)
+	f.Add(int64(1<<62), 1<<20)
+	f.Fuzz(func(t *testing.T, n int64, width int) {
+		_ = Format(n).Width(width).String()
+		_ = Format(n).To(UnitBytes).String()
+	})
+}
+
+func FuzzBarFormat(f *testing.F) {
+	f.Add("[=>-]")
+	f.Add("╢▌▌░╟")
+	f.Add("\xff\xfe\xfd\xfc\xfb")
+	f.Fuzz(func(t *testing.T, format string) {
+		s := newTestState()
+		s.width = 20
+		s.total = 100
+		s.current = 50
+		if utf8.RuneCountInString(format) == numFmtRunes {
+			s.updateFormat(format)
+		}
+		draw(s, 40, newWidthSync(nil, 1, 0), newWidthSync(nil, 1, 0))
+	})
+}
+
+func FuzzDraw(f *testing.F) {
+	f.Add("Bar#1:", 80, 70, int64(100), int64(40))
+	f.Add("", 0, 2, int64(0), int64(0))
+	f.Add("日本語", 3, 100, int64(10), int64(20))
+	f.Add("\x1b[31mred\x1b[0m", -1, 10, int64(-5), int64(-10))
+	f.Fuzz(func(t *testing.T, name string, termWidth, width int, total, current int64) {
+		if width > 1<<12 || termWidth > 1<<12 {
+			t.Skip()
+		}
+		s := newTestState()
+		s.width = width
+		s.total = total
+		s.current = current
+		s.prependFuncs = toBytesFuncs([]DecoratorFunc{
+			func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+				return name
+			},
+		})
+		s.appendFuncs = toBytesFuncs([]DecoratorFunc{
+			func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+				return Format(s.Current).To(UnitBytes).String()
+			},
+		})
+		draw(s, termWidth, presetWidthSync([]int{0}), presetWidthSync([]int{0}))
+		if p := internal.Percentage(total, current, 100); p < 0 || p > 100 {
+			t.Errorf("percentage out of range: %d", p)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package mpb
+
+// Group is a set of bars, which share visual identity, i.e. all "upload"
+// bars are blue, while all "verify" bars are magenta.
+type Group struct {
+	p     *Progress
+	name  string
+	theme Theme
+}
+
+// Theme defines how bars of a Group look. Colors are ANSI SGR parameters,
+// like "34" for blue or "1;35" for bold magenta. Empty color means no color.
+type Theme struct {
+	// BarColor is color of the bar body
+	BarColor string
+	// DecoratorColor is color of decorators, added by Group's PrependFunc
+	// and AppendFunc
+	DecoratorColor string
+	// Format overrides bar format, see (*Progress).Format
+	Format string
+}
+
+// AddGroup creates Group of bars with theme, which is applied to every bar
+// added by the Group's AddBar.
+func (p *Progress) AddGroup(name string, theme Theme) *Group {
+	return &Group{p: p, name: name, theme: theme}
+}
+
+// Name returns name of the group
+func (g *Group) Name() string {
+	return g.name
+}
+
+// AddBar adds bar to the container, applying the group's theme.
+// The group name is available via bar.Meta("group").
+// Pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (g *Group) AddBar(total int64) *Bar {
+	bar := g.p.AddBar(total).SetMeta("group", g.name)
+	if g.theme.Format != "" {
+		bar.Format(g.theme.Format)
+	}
+	if g.theme.BarColor != "" {
+		bar.SetColor(g.theme.BarColor)
+	}
+	return bar
+}
+
+// PrependFunc prepends f to the bar, colored with the group's
+// DecoratorColor
+func (g *Group) PrependFunc(bar *Bar, f DecoratorFunc) *Bar {
+	return bar.PrependFunc(g.decorator(f))
+}
+
+// AppendFunc appends f to the bar, colored with the group's DecoratorColor
+func (g *Group) AppendFunc(bar *Bar, f DecoratorFunc) *Bar {
+	return bar.AppendFunc(g.decorator(f))
+}
+
+func (g *Group) decorator(f DecoratorFunc) DecoratorFunc {
+	if g.theme.DecoratorColor == "" {
+		return f
+	}
+	return Color(f, g.theme.DecoratorColor)
+}
@@ -0,0 +1,26 @@
+package mpb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestGroupTheme(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	g := p.AddGroup("upload", Theme{BarColor: "34", DecoratorColor: "35"})
+	bar := g.AddBar(10).TrimLeftSpace().TrimRightSpace()
+	g.PrependFunc(bar, Name("up", 0, 0))
+	if got := bar.Meta("group"); got != "upload" {
+		t.Errorf("Want: %q, Got: %v\n", "upload", got)
+	}
+	s := bar.getState()
+	buf := draw(&s, 80, newWidthSync(nil, 1, 1), newWidthSync(nil, 1, 0))
+	for _, want := range []string{"\x1b[35mup\x1b[0m", "\x1b[34m["} {
+		if !bytes.Contains(buf, []byte(want)) {
+			t.Errorf("Want %q in %q\n", want, buf)
+		}
+	}
+	bar.Completed()
+	p.Stop()
+}
@@ -0,0 +1,48 @@
+package mpb
+
+// heatmapShades are shades of heatmap cells, from idle to completed
+var heatmapShades = []rune(" ░▒▓█")
+
+// SetHeatmap toggles heatmap mode, which is meant for thousands of bars.
+// Instead of a line per bar, a single summary line is rendered, each cell of
+// which is a shade, representing average completion of a bucket of bars.
+// There is a cell per bar, while bars fit the width. Plain output, i.e. when
+// output isn't a terminal, falls back to percentage per bar. Default is false.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetHeatmap(enabled bool) *Progress {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.heatmapCh <- enabled:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// heatmapLine renders bars of ibbs into at most width cells
+func heatmapLine(ibbs []indexedBarBuffer, width int) []byte {
+	n := len(ibbs)
+	cells := n
+	if width > 0 && cells > width {
+		cells = width
+	}
+	line := make([]rune, cells)
+	for i := range line {
+		from, till := i*n/cells, (i+1)*n/cells
+		var sum float64
+		for _, ibb := range ibbs[from:till] {
+			if ibb.total <= 0 {
+				continue
+			}
+			if ibb.current >= ibb.total {
+				sum++
+			} else {
+				sum += float64(ibb.current) / float64(ibb.total)
+			}
+		}
+		avg := sum / float64(till-from)
+		line[i] = heatmapShades[int(avg*float64(len(heatmapShades)-1)+0.5)]
+	}
+	return []byte(string(line) + "\n")
+}
@@ -0,0 +1,46 @@
+package mpb
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHeatmapLine(t *testing.T) {
+	ibbs := []indexedBarBuffer{
+		{total: 10, current: 0},
+		{total: 10, current: 10},
+		{total: 10, current: 5},
+		{total: 10, current: 5},
+		{total: 0, current: 3},
+		{total: 10, current: 20},
+	}
+	tests := []struct {
+		width int
+		want  string
+	}{
+		{0, " █▒▒ █\n"},
+		{3, "▒▒▒\n"},
+	}
+	for _, test := range tests {
+		if got := string(heatmapLine(ibbs, test.width)); got != test.want {
+			t.Errorf("Width %d want: %q, got: %q\n", test.width, test.want, got)
+		}
+	}
+}
+
+func TestHeatmapMode(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetWidth(20).SetHeatmap(true)
+	for i := 0; i < 4; i++ {
+		p.AddBar(10).Incr(10)
+	}
+	p.Stop()
+	// plain output falls back to percentage per bar
+	if strings.Contains(buf.String(), "█") {
+		t.Errorf("Heatmap line in plain output: %q\n", buf.String())
+	}
+	if !strings.Contains(buf.String(), strings.Repeat("100 %\n", 4)) {
+		t.Errorf("Percentage lines not found in: %q\n", buf.String())
+	}
+}
@@ -0,0 +1,22 @@
+// Package internal holds helpers, which are shared by mpb and decor packages.
+package internal
+
+import "math"
+
+// Percentage calculates percentage of current to total, scaled to ratio, i.e.
+// 100 for percents or bar width for filled cells. It is 0, if total is
+// unknown or current is out of range. Fraction above .4 is rounded up.
+func Percentage(total, current int64, ratio int) int {
+	if total <= 0 || current <= 0 || current > total {
+		return 0
+	}
+	num := float64(ratio) * float64(current) / float64(total)
+	ceil := math.Ceil(num)
+	diff := ceil - num
+	// num = 2.34 will return 2
+	// num = 2.44 will return 3
+	if math.Max(diff, 0.6) == diff {
+		return int(num)
+	}
+	return int(ceil)
+}
@@ -0,0 +1,30 @@
+package internal
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPercentage(t *testing.T) {
+	tests := []struct {
+		total, current int64
+		ratio          int
+		want           int
+	}{
+		{0, 5, 100, 0},
+		{100, -1, 100, 0},
+		{100, 101, 100, 0},
+		{100, 50, 100, 50},
+		{200, 51, 100, 26},
+		{1000, 234, 10, 2},
+		{1000, 244, 10, 3},
+		{math.MaxInt64, math.MaxInt64 / 2, 100, 50},
+		{math.MaxInt64, math.MaxInt64, 100, 100},
+	}
+	for _, test := range tests {
+		if got := Percentage(test.total, test.current, test.ratio); got != test.want {
+			t.Errorf("Percentage(%d, %d, %d): want %d, got %d\n",
+				test.total, test.current, test.ratio, test.want, got)
+		}
+	}
+}
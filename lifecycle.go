@@ -0,0 +1,175 @@
+package mpb
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// serverHandle starts server of Progress lazily, on the first call, which
+// needs it, and lets it exit, while there are neither bars nor callers, if
+// idle shutdown is set. It is shared by copies of Progress, i.e. WithCancel.
+type serverHandle struct {
+	mu      sync.Mutex
+	p       *Progress
+	running bool
+	// users is count of calls in flight
+	users int
+	// saved is configuration of exited server, nil means defaults
+	saved *serverState
+	// shards are stopped along with Progress, see Shard
+	shards []*Progress
+	// keys are bound by BindKey
+	keys map[rune]FocusAction
+}
+
+// barCounter counts bars in progress. Unlike sync.WaitGroup, it may be
+// incremented, while it is waited on, so AddBar may race with Stop.
+type barCounter struct {
+	mu   sync.Mutex
+	n    int
+	zero chan struct{}
+}
+
+func (c *barCounter) Add(delta int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.n += delta
+	if c.n < 0 {
+		panic("mpb: negative bar counter")
+	}
+	if c.n == 0 && c.zero != nil {
+		close(c.zero)
+		c.zero = nil
+	}
+}
+
+func (c *barCounter) Done() {
+	c.Add(-1)
+}
+
+// Wait blocks until there are no bars in progress
+func (c *barCounter) Wait() {
+	<-c.Zero()
+}
+
+// Zero returns channel, which is closed, when there are no bars in progress.
+// Unlike Wait, it may be selected on along with cancellation, so waiter
+// doesn't leak.
+func (c *barCounter) Zero() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.n == 0 {
+		zero := make(chan struct{})
+		close(zero)
+		return zero
+	}
+	if c.zero == nil {
+		c.zero = make(chan struct{})
+	}
+	return c.zero
+}
+
+// serverState is configuration of server, which survives idle shutdown
+type serverState struct {
+	userRR         time.Duration
+	reporter       Reporter
+	hideCur        bool
+	maxDrawers     int
+	columns        [2][]string
+	sampleInterval time.Duration
+	plainPrefix    PlainPrefix
+	throttle       *plainThrottle
+	autoRefresh    time.Duration
+	ci             CIProvider
+	less           BarLess
+	bottomUp       bool
+	report         io.Writer
+	started        time.Time
+	align          alignment
+	header, footer func() string
+	minWidth       int
+	format         string
+	beforeRender   BeforeRender
+	out            io.Writer
+	termSizeFunc   TermSizeFunc
+	mode           RenderMode
+	writeFailed    bool
+	idleShutdown   bool
+	noAnimations   bool
+	frameBudget    time.Duration
+	nameColumn     *nameColumn
+	jitter         float64
+	focusSGR       string
+	expandFocused  bool
+	debug          bool
+	heatmap        bool
+}
+
+func defaultServerState() *serverState {
+	return &serverState{
+		userRR:      rr * time.Millisecond,
+		autoRefresh: autoRefreshInterval,
+		started:     time.Now(),
+		minWidth:    minTermWidth,
+		focusSGR:    defaultFocusSGR,
+		out:         os.Stdout,
+	}
+}
+
+// acquire starts server, if it isn't running, and holds it running till
+// release. Server isn't restarted after Stop.
+func (p *Progress) acquire() {
+	h := p.srv
+	h.mu.Lock()
+	h.users++
+	if !h.running {
+		select {
+		case <-p.done:
+		default:
+			h.running = true
+			go h.p.server()
+		}
+	}
+	h.mu.Unlock()
+}
+
+// release releases server, acquired by acquire, and nudges it to check,
+// whether it is idle
+func (p *Progress) release() {
+	h := p.srv
+	h.mu.Lock()
+	h.users--
+	idle := h.users == 0
+	h.mu.Unlock()
+	if idle {
+		select {
+		case p.idleCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// SetIdleShutdown makes rendering goroutine exit, when there are no bars,
+// i.e. after the last one is removed, so Progress held by a library has no
+// background goroutines, while idle. Goroutine is started again on the next
+// call. Configuration is kept. Default is false.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetIdleShutdown(idle bool) *Progress {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.idleShutdownCh <- idle:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// running reports whether rendering goroutine is running
+func (p *Progress) running() bool {
+	p.srv.mu.Lock()
+	defer p.srv.mu.Unlock()
+	return p.srv.running
+}
@@ -0,0 +1,99 @@
+package mpb
+
+import "unicode/utf8"
+
+// NameTruncation specifies, which part of too long name is cut off in the
+// name column, see SetNameColumn
+type NameTruncation int
+
+const (
+	// TruncateEnd keeps the beginning of the name: "long-na…"
+	TruncateEnd NameTruncation = iota
+	// TruncateStart keeps the end of the name: "…ng-name"
+	TruncateStart
+	// TruncateMiddle keeps both ends of the name: "lon…ame"
+	TruncateMiddle
+)
+
+const ellipsis = '…'
+
+// nameColumn is the built-in leading column, see SetNameColumn
+type nameColumn struct {
+	maxWidth int
+	truncate NameTruncation
+}
+
+// SetName sets name of the bar, which is rendered in the name column, see
+// (*Progress).SetNameColumn. It is the same as SetMeta("name", name).
+func (b *Bar) SetName(name string) *Bar {
+	return b.SetMeta("name", name)
+}
+
+// SetNameColumn renders names of the bars, set by (*Bar).SetName, as the
+// leading column, left aligned and synced to the longest name. Names longer
+// than maxWidth are truncated according to truncate, zero maxWidth means no
+// limit. The column applies to all bars, bars without name get blank cell.
+// It's labeled "name" in ColumnSchema.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetNameColumn(maxWidth int, truncate NameTruncation) *Progress {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.nameColumnCh <- &nameColumn{maxWidth, truncate}:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+func (b *Bar) setNameColumn(col *nameColumn) {
+	b.configure(func(s *state) {
+		s.nameColumn = col
+	})
+}
+
+// decorator renders name, synced to the widest name
+func (col *nameColumn) decorator(name string) DecoratorFunc {
+	name = truncateName(name, col.maxWidth, col.truncate)
+	return func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		return formatDecorator(name, 0, DidentRight|DwidthSync, myWidth, maxWidth)
+	}
+}
+
+// truncateName cuts name down to width runes, replacing the cut off part
+// with ellipsis. Zero width means no limit.
+func truncateName(name string, width int, truncate NameTruncation) string {
+	n := utf8.RuneCountInString(name)
+	if width <= 0 || n <= width {
+		return name
+	}
+	runes := []rune(name)
+	keep := width - 1
+	switch truncate {
+	case TruncateStart:
+		return string(ellipsis) + string(runes[n-keep:])
+	case TruncateMiddle:
+		head := (keep + 1) / 2
+		tail := keep - head
+		return string(runes[:head]) + string(ellipsis) + string(runes[n-tail:])
+	default:
+		return string(runes[:keep]) + string(ellipsis)
+	}
+}
+
+// prependColumns returns prepend decorators and their layouts in terminal
+// order, including the name column
+func (s *state) prependColumns() ([]BytesDecoratorFunc, []Layout) {
+	funcs, layouts := arrangeColumns(s.prependOrder, s.prependIDs, s.prependFuncs, s.prependLayouts)
+	if s.nameColumn == nil {
+		return funcs, layouts
+	}
+	funcs = append([]BytesDecoratorFunc{BytesFunc(s.nameColumn.decorator(barName(s)))}, funcs...)
+	// name column gives up width last
+	nameLayout := Layout{Priority: int(^uint(0) >> 1), MinWidth: 1}
+	if len(layouts) < len(funcs)-1 {
+		layouts = append(layouts, make([]Layout, len(funcs)-1-len(layouts))...)
+	}
+	layouts = append([]Layout{nameLayout}, layouts...)
+	return funcs, layouts
+}
@@ -0,0 +1,77 @@
+package mpb
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTruncateName(t *testing.T) {
+	tests := []struct {
+		name     string
+		width    int
+		truncate NameTruncation
+		want     string
+	}{
+		{"download", 0, TruncateEnd, "download"},
+		{"download", 8, TruncateEnd, "download"},
+		{"download", 5, TruncateEnd, "down…"},
+		{"download", 5, TruncateStart, "…load"},
+		{"download", 5, TruncateMiddle, "do…ad"},
+		{"download", 4, TruncateMiddle, "do…d"},
+		{"загрузка", 4, TruncateEnd, "заг…"},
+	}
+	for _, test := range tests {
+		got := truncateName(test.name, test.width, test.truncate)
+		if got != test.want {
+			t.Errorf("truncateName(%q, %d, %d): want %q, got %q\n",
+				test.name, test.width, test.truncate, test.want, got)
+		}
+	}
+}
+
+func TestNameColumn(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetWidth(40).SetNameColumn(6, TruncateEnd)
+	a := p.AddBar(10).SetName("a").AppendPercentage(0, 0)
+	b := p.AddBar(10).SetName("very-long-name").AppendPercentage(0, 0)
+	c := p.AddBar(10).AppendPercentage(0, 0)
+
+	schema := p.ColumnSchema()
+	if len(schema.Prepend) != 1 || schema.Prepend[0] != "name" {
+		t.Errorf("Expected name column in schema, got: %q\n", schema.Prepend)
+	}
+
+	a.Incr(10)
+	b.Incr(10)
+	c.Incr(10)
+	p.Stop()
+
+	out := buf.String()
+	for _, want := range []string{"\na      [", "\nvery-… [", "\n       ["} {
+		if !strings.Contains("\n"+out, want) {
+			t.Errorf("Output doesn't contain %q:\n%s\n", want, out)
+		}
+	}
+}
+
+func TestNameColumnCompletedBar(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetWidth(40).RefreshRate(10 * time.Millisecond)
+	a := p.AddBar(10).SetName("a")
+	b := p.AddBar(10).SetName("b")
+	a.Incr(10)
+	for a.InProgress() {
+		time.Sleep(time.Millisecond)
+	}
+	p.SetNameColumn(0, TruncateEnd)
+	b.Incr(10)
+	p.Wait()
+	out := buf.String()
+	for _, want := range []string{"a [", "b ["} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Output doesn't contain %q:\n%s\n", want, out)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package mpb
+
+import (
+	"unicode/utf8"
+
+	"github.com/vbauerster/mpb/decor"
+)
+
+// BarOption configures the bar at AddBar time, see PrependDecorators
+type BarOption func(*state)
+
+// BarID overrides id of the bar, see (*Bar).GetID
+func BarID(id int) BarOption {
+	return func(s *state) {
+		s.id = id
+	}
+}
+
+// BarPriority sets priority of the bar, bars with higher priority are
+// rendered first, see (*Progress).UpdateBarPriority
+func BarPriority(n int) BarOption {
+	return func(s *state) {
+		s.priority = n
+	}
+}
+
+// BarWidth overrides width of the container for the bar, n must be at least 2
+func BarWidth(n int) BarOption {
+	return func(s *state) {
+		if n >= 2 {
+			s.width = n
+		}
+	}
+}
+
+// BarFormat overrides format of the container for the bar, invalid format,
+// see ErrInvalidFormat, is ignored
+func BarFormat(format string) BarOption {
+	return func(s *state) {
+		if utf8.RuneCountInString(format) == numFmtRunes {
+			s.updateFormat(format)
+		}
+	}
+}
+
+// BarRemoveOnComplete removes the bar from the container right after it has
+// completed, so subsequent bars move up. See (*Bar).RemoveOnComplete for
+// removal with grace period.
+func BarRemoveOnComplete() BarOption {
+	return func(s *state) {
+		s.removeOnComplete = true
+		s.removeGrace = 0
+	}
+}
+
+// BarClearOnComplete replaces the bar with a blank line, once it has
+// completed, so positions of other bars don't change
+func BarClearOnComplete() BarOption {
+	return func(s *state) {
+		s.clearOnComplete = true
+	}
+}
+
+// PrependDecorators prepends decorators of decor package to the bar. They
+// have no column id, so they aren't rendered, if column order is declared by
+// (*Progress).SetColumns, see (*Bar).PrependColumn.
+func PrependDecorators(decorators ...decor.Decorator) BarOption {
+	return func(s *state) {
+		for _, d := range decorators {
+			s.prependFuncs = append(s.prependFuncs, BytesFunc(adaptDecorator(d)))
+			s.prependLayouts = append(s.prependLayouts, Layout{})
+			s.prependIDs = appendID(s.prependIDs, len(s.prependFuncs), "")
+		}
+	}
+}
+
+// AppendDecorators appends decorators of decor package to the bar, see
+// PrependDecorators
+func AppendDecorators(decorators ...decor.Decorator) BarOption {
+	return func(s *state) {
+		for _, d := range decorators {
+			s.appendFuncs = append(s.appendFuncs, BytesFunc(adaptDecorator(d)))
+			s.appendLayouts = append(s.appendLayouts, Layout{})
+			s.appendIDs = appendID(s.appendIDs, len(s.appendFuncs), "")
+		}
+	}
+}
+
+// adaptDecorator adapts decor.Decorator to DecoratorFunc
+func adaptDecorator(d decor.Decorator) DecoratorFunc {
+	return func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		ds := &decor.Statistics{
+			Completed:           isCompleted(s),
+			Total:               s.Total,
+			Current:             s.Current,
+			TimeElapsed:         s.TimeElapsed,
+			TimePerItemEstimate: s.TimePerItemEstimate,
+		}
+		return d.Decor(ds, myWidth, maxWidth)
+	}
+}
@@ -0,0 +1,80 @@
+package mpb
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vbauerster/mpb/decor"
+)
+
+func TestBarOptions(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetWidth(40)
+	bar := p.AddBar(100,
+		PrependDecorators(decor.CountersKibiByte("%s/%s", decor.WC{W: 10})),
+		AppendDecorators(decor.Percentage()),
+	)
+	if n := bar.NumOfPrependers(); n != 1 {
+		t.Errorf("Want 1 prepender, got: %d\n", n)
+	}
+	bar.Incr(100)
+	p.Stop()
+	out := buf.String()
+	if !strings.Contains(out, " 100b/100b [") || !strings.Contains(out, "] 100 %") {
+		t.Errorf("Unexpected output: %q\n", out)
+	}
+}
+
+func TestBarOptionsColumnIDs(t *testing.T) {
+	p := New().SetOut(&bytes.Buffer{})
+	bar := p.AddBar(100, AppendDecorators(decor.Percentage(), decor.Elapsed()))
+	bar.AppendColumn("name", Name("foo", 0, 0))
+	s := bar.getState()
+	if got := strings.Join(s.appendIDs, ","); got != ",,name" {
+		t.Errorf("Want ids %q, got: %q\n", ",,name", got)
+	}
+	bar.Incr(100)
+	p.Stop()
+	if DidentRight != decor.DidentRight || DwidthSync != decor.DwidthSync || DextraSpace != decor.DextraSpace {
+		t.Error("Formatting flags differ from decor ones")
+	}
+}
+
+func TestBarOptionsStyle(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetWidth(40)
+	bar := p.AddBar(10, BarID(7), BarWidth(12), BarFormat("<##->"))
+	if id := bar.GetID(); id != 7 {
+		t.Errorf("Want id 7, got: %d\n", id)
+	}
+	bar.Incr(10)
+	p.Stop()
+	want := " <########> "
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("Want %q in: %q\n", want, buf.String())
+	}
+}
+
+func TestBarOnCompleteOptions(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetWidth(12).RefreshRate(10 * time.Millisecond)
+	removed := p.AddBar(10, BarRemoveOnComplete())
+	cleared := p.AddBar(10, BarClearOnComplete())
+	bar := p.AddBar(10)
+	removed.Incr(10)
+	for i := 0; len(p.Snapshot()) != 2; i++ {
+		if i == 100 {
+			t.Fatal("Bar wasn't removed on complete")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cleared.Incr(10)
+	bar.Incr(10)
+	p.Stop()
+	out := buf.String()
+	if want := "\n\n [========] \n"; !strings.HasSuffix(out, want) {
+		t.Errorf("Want suffix %q, got: %q\n", want, out)
+	}
+}
@@ -1,16 +1,20 @@
 package mpb
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"log"
 	"os"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 	"unicode/utf8"
 
 	"github.com/vbauerster/mpb/cwriter"
+	"github.com/vbauerster/mpb/internal"
 )
 
 var logger = log.New(os.Stderr, "mpb: ", log.LstdFlags|log.Lshortfile)
@@ -19,6 +23,13 @@ var logger = log.New(os.Stderr, "mpb: ", log.LstdFlags|log.Lshortfile)
 // are called after (*Progress).Stop() has been called
 var ErrCallAfterStop = errors.New("method call on stopped Progress instance")
 
+// ErrInvalidFormat is returned by SetFormat, if format isn't exactly 5 runes
+var ErrInvalidFormat = errors.New("format must consist of 5 runes, i.e. \"[=>-]\"")
+
+// ErrForeignBar is returned by TryRemoveBar, if the bar belongs to another
+// Progress instance
+var ErrForeignBar = errors.New("bar belongs to another Progress instance")
+
 type (
 	// BeforeRender is a func, which gets called before render process
 	BeforeRender func([]*Bar)
@@ -28,28 +39,81 @@ type (
 		kind   barOpType
 		bar    *Bar
 		result chan bool
+		// used by barTransfer
+		to     *Bar
+		amount int64
+		moved  chan int64
+		// used by focus operations
+		focused chan *Bar
+		// used by barPriority
+		priority int
+	}
+
+	barRequest struct {
+		id     int
+		total  int64
+		cancel <-chan struct{}
+		// wg is user WaitGroup, see WithWaitGroup
+		wg *sync.WaitGroup
+		// width is width of the bar, see SetWidth
+		width  int
+		result chan *Bar
+		// used by AddFromTemplate
+		tpl  *BarTemplate
+		name string
+		// options are applied after template
+		options []BarOption
 	}
 
 	indexedBarBuffer struct {
-		index int
-		buf   []byte
+		index   int
+		buf     []byte
+		total   int64
+		current int64
+		name    string
+		// details are detail lines of expanded bar
+		details []byte
 	}
 
 	indexedBar struct {
 		index     int
 		termWidth int
 		bar       *Bar
+		// animate enables completion animation
+		animate bool
+	}
+
+	// termInfo holds capabilities of the output writer
+	termInfo struct {
+		tty   bool
+		color bool
+		// cr means carriage return only rendering, see RenderCarriageReturn
+		cr bool
+		// accessible means announcements instead of bars, see RenderAccessible
+		accessible bool
+		// shared means frames are composed by Renderer, see SharedRenderer
+		shared bool
 	}
 
 	widthSync struct {
 		listen []chan int
 		result []chan int
+		// done gets a value per column, when its sync goroutine exits,
+		// true if it exited cleanly, i.e. widthSync may be reused
+		done    chan bool
+		buf     [][]int
+		numBars int
 	}
 )
 
 const (
-	barAdd barOpType = iota
-	barRemove
+	barRemove barOpType = iota
+	barTransfer
+	barFocus
+	barFocused
+	barFocusNext
+	barFocusPrev
+	barPriority
 )
 
 const (
@@ -59,46 +123,147 @@ const (
 	pwidth = 70
 	// number of format runes for bar
 	numFmtRunes = 5
+	// default min terminal width, below which bars are rendered compactly
+	minTermWidth = 20
+	// how long terminal size is cached, unless terminal is resized
+	termSizeTTL = 2 * time.Second
+	// default interval of plain lines, see SetAutoRefreshMode
+	autoRefreshInterval = 10 * time.Second
 )
 
 // Progress represents the container that renders Progress bars
 type Progress struct {
-	// Context for canceling bars rendering
-	// ctx context.Context
-	// WaitGroup for internal rendering sync
-	wg *sync.WaitGroup
-
-	out    io.Writer
-	width  int
-	format string
+	// active bars, for internal rendering sync
+	wg *barCounter
 
+	addBarReqCh    chan *barRequest
 	operationCh    chan *operation
+	formatCh       chan string
 	rrChangeReqCh  chan time.Duration
 	outChangeReqCh chan io.Writer
 	barCountReqCh  chan chan int
+	barsReqCh      chan chan []*Bar
 	brCh           chan BeforeRender
+	reporterCh     chan Reporter
+	maxDrawersCh   chan int
+	sortCh         chan BarLess
+	bottomUpCh     chan bool
+	alignCh        chan alignment
+	headerCh       chan func() string
+	minTermWidthCh chan int
+	footerCh       chan func() string
+	stopReqCh      chan struct{}
+	errCh          chan error
+	configReqCh    chan chan *config
+	columnsCh      chan [2][]string
+	hideCursorCh   chan bool
+	termSizeCh     chan TermSizeFunc
+	sampleCh       chan time.Duration
+	logCh          chan string
+	plainPrefixCh  chan PlainPrefix
+	throttleCh     chan *plainThrottle
+	autoRefreshCh  chan time.Duration
+	ciCh           chan CIProvider
+	renderModeCh   chan RenderMode
+	reportCh       chan io.Writer
+	redrawCh       chan struct{}
+	foreignCh      chan *foreignWrite
+	idleShutdownCh chan bool
+	animationsCh   chan bool
+	frameBudgetCh  chan time.Duration
+	nameColumnCh   chan *nameColumn
+	jitterCh       chan float64
+	focusStyleCh   chan string
+	expandCh       chan bool
+	debugCh        chan bool
+	heatmapCh      chan bool
+	refreshCh      chan struct{}
+	shutdownCh     chan struct{}
+	idleCh         chan struct{}
+	srv            *serverHandle
 	done           chan struct{}
 	cancel         <-chan struct{}
+	// uwg is user WaitGroup, see WithWaitGroup
+	uwg *sync.WaitGroup
+	// events are shared by copies, see Events
+	events *eventQueue
+	// width of bars, added via this instance, see SetWidth
+	width int
 }
 
 // New creates new Progress instance, which will orchestrate bars rendering
-// process. It acceepts context.Context, for cancellation.
-// If you don't plan to cancel, it is safe to feed with nil
+// process. Use WithCancel or WithContext for cancellation. Rendering
+// goroutine is started on the first call, and rendering starts with the first
+// added bar, so Progress without bars has no effect on terminal.
 func New() *Progress {
 	p := &Progress{
-		width:          pwidth,
+		addBarReqCh:    make(chan *barRequest),
 		operationCh:    make(chan *operation),
+		formatCh:       make(chan string),
 		rrChangeReqCh:  make(chan time.Duration),
 		outChangeReqCh: make(chan io.Writer),
 		barCountReqCh:  make(chan chan int),
+		barsReqCh:      make(chan chan []*Bar),
 		brCh:           make(chan BeforeRender),
+		reporterCh:     make(chan Reporter),
+		maxDrawersCh:   make(chan int),
+		sortCh:         make(chan BarLess),
+		bottomUpCh:     make(chan bool),
+		alignCh:        make(chan alignment),
+		headerCh:       make(chan func() string),
+		minTermWidthCh: make(chan int),
+		footerCh:       make(chan func() string),
+		stopReqCh:      make(chan struct{}),
+		errCh:          make(chan error, 1),
+		configReqCh:    make(chan chan *config),
+		columnsCh:      make(chan [2][]string),
+		hideCursorCh:   make(chan bool),
+		termSizeCh:     make(chan TermSizeFunc),
+		sampleCh:       make(chan time.Duration),
+		logCh:          make(chan string),
+		plainPrefixCh:  make(chan PlainPrefix),
+		throttleCh:     make(chan *plainThrottle),
+		autoRefreshCh:  make(chan time.Duration),
+		ciCh:           make(chan CIProvider),
+		renderModeCh:   make(chan RenderMode),
+		reportCh:       make(chan io.Writer),
+		redrawCh:       make(chan struct{}),
+		foreignCh:      make(chan *foreignWrite),
+		idleShutdownCh: make(chan bool),
+		animationsCh:   make(chan bool),
+		frameBudgetCh:  make(chan time.Duration),
+		nameColumnCh:   make(chan *nameColumn),
+		jitterCh:       make(chan float64),
+		focusStyleCh:   make(chan string),
+		expandCh:       make(chan bool),
+		debugCh:        make(chan bool),
+		heatmapCh:      make(chan bool),
+		refreshCh:      make(chan struct{}, 1),
+		shutdownCh:     make(chan struct{}),
+		idleCh:         make(chan struct{}, 1),
 		done:           make(chan struct{}),
-		wg:             new(sync.WaitGroup),
+		wg:             new(barCounter),
+		width:          pwidth,
 	}
-	go p.server()
+	p.srv = &serverHandle{p: p}
+	p.events = new(eventQueue)
 	return p
 }
 
+// WithWaitGroup returns copy of p, which adds bars, tracked by wg, in
+// addition to internal tracking. wg.Wait returns, when all bars added via the
+// copy have completed or aborted, so the host may coordinate other shutdown
+// steps, before calling Stop.
+func (p *Progress) WithWaitGroup(wg *sync.WaitGroup) *Progress {
+	if wg == nil {
+		panic("nil wait group")
+	}
+	p2 := new(Progress)
+	*p2 = *p
+	p2.uwg = wg
+	return p2
+}
+
 // WithCancel cancellation via channel
 func (p *Progress) WithCancel(ch <-chan struct{}) *Progress {
 	if ch == nil {
@@ -110,7 +275,8 @@ func (p *Progress) WithCancel(ch <-chan struct{}) *Progress {
 	return p2
 }
 
-// SetWidth overrides default (70) width of bar(s)
+// SetWidth overrides default (70) width of bar(s). It returns a copy of p,
+// which shares container with p, but bars added via the copy are of width n.
 func (p *Progress) SetWidth(n int) *Progress {
 	if n < 0 {
 		panic("negative width")
@@ -122,140 +288,758 @@ func (p *Progress) SetWidth(n int) *Progress {
 }
 
 // SetOut sets underlying writer of progress. Default is os.Stdout
+// Capabilities of w are detected anew: if w isn't a terminal, plain frames
+// are appended periodically instead of rewritten in place, see
+// SetAutoRefreshMode, and colors are stripped.
+// Colors are also stripped, if NO_COLOR is set or TERM is "dumb".
 // pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
 func (p *Progress) SetOut(w io.Writer) *Progress {
-	if isClosed(p.done) {
-		panic(ErrCallAfterStop)
-	}
+	p.acquire()
+	defer p.release()
 	if w == nil {
 		return p
 	}
-	p.outChangeReqCh <- w
+	select {
+	case p.outChangeReqCh <- w:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
 	return p
 }
 
 // RefreshRate overrides default (100ms) refresh rate value
 // pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
 func (p *Progress) RefreshRate(d time.Duration) *Progress {
-	if isClosed(p.done) {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.rrChangeReqCh <- d:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// SetMaxDrawers limits number of goroutines, which draw bars on each refresh.
+// By default every bar is drawn in its own goroutine, which may cause CPU
+// throttling spikes in CPU-quota-limited containers, when there are many bars.
+// With the limit, decorators are rendered twice per refresh, the first time
+// only to measure their width, see (*Statistics).Measuring.
+// Zero value means no limit.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetMaxDrawers(n int) *Progress {
+	p.acquire()
+	defer p.release()
+	if n < 0 {
+		panic("negative max drawers")
+	}
+	select {
+	case p.maxDrawersCh <- n:
+	case <-p.done:
 		panic(ErrCallAfterStop)
 	}
-	p.rrChangeReqCh <- d
 	return p
 }
 
 // BeforeRenderFunc accepts a func, which gets called before render process.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
 func (p *Progress) BeforeRenderFunc(f BeforeRender) *Progress {
-	if isClosed(p.done) {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.brCh <- f:
+	case <-p.done:
 		panic(ErrCallAfterStop)
 	}
-	p.brCh <- f
 	return p
 }
 
-// AddBar creates a new progress bar and adds to the container
+// SortBy sets order, in which bars are rendered on each refresh, i.e.
+// SortByPercentage. Sort is applied after BeforeRender func, and doesn't
+// change order of bars passed to it. Nil value disables sorting.
 // pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
-func (p *Progress) AddBar(total int64) *Bar {
-	return p.AddBarWithID(0, total)
+func (p *Progress) SortBy(less BarLess) *Progress {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.sortCh <- less:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
 }
 
-// AddBarWithID creates a new progress bar and adds to the container
+// Redraw repaints bars from scratch at the cursor position, without erasing
+// previously rendered lines. Call it, when the screen was cleared or another
+// tool has written over the bars, to recover corrupted display. It is noop
+// for non-terminal output.
 // pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
-func (p *Progress) AddBarWithID(id int, total int64) *Bar {
-	if isClosed(p.done) {
+func (p *Progress) Redraw() {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.redrawCh <- struct{}{}:
+	case <-p.done:
 		panic(ErrCallAfterStop)
 	}
-	result := make(chan bool)
-	bar := newBar(id, total, p.width, p.format, p.wg, p.cancel)
-	p.operationCh <- &operation{barAdd, bar, result}
-	if <-result {
-		p.wg.Add(1)
+}
+
+// SetBottomUp renders bars in reverse order, so the newest bar is on top and
+// bars stack upward. Order is reversed after sort, see SortBy.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetBottomUp(bottomUp bool) *Progress {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.bottomUpCh <- bottomUp:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// SetMinTermWidth overrides default (20) min terminal width. When terminal is
+// narrower, bar body and decorators are dropped and only percentage is
+// rendered for each bar. Zero value disables compact rendering.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetMinTermWidth(n int) *Progress {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.minTermWidthCh <- n:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// SetHeader sets func, which output is rendered above the bars on each
+// refresh. Output may contain multiple lines. Nil value removes the header.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetHeader(f func() string) *Progress {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.headerCh <- f:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// SetFooter sets func, which output is rendered below the bars on each
+// refresh. Output may contain multiple lines. Nil value removes the footer.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetFooter(f func() string) *Progress {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.footerCh <- f:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// SetReporter sets Reporter, which receives aggregate progress of all bars
+// after each rendered frame.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetReporter(r Reporter) *Progress {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.reporterCh <- r:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// AddBar creates a new progress bar and adds to the container. Options, if
+// any, configure the bar, i.e. PrependDecorators.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) AddBar(total int64, options ...BarOption) *Bar {
+	return p.AddBarWithID(0, total, options...)
+}
+
+// AddBarWithID creates a new progress bar and adds to the container
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) AddBarWithID(id int, total int64, options ...BarOption) *Bar {
+	bar, err := p.TryAddBarWithID(id, total, options...)
+	if err != nil {
+		panic(err)
 	}
 	return bar
 }
 
-// RemoveBar removes bar at any time.
+// TryAddBar is AddBar, which returns ErrCallAfterStop instead of panic, so
+// adding bars may race with Stop, i.e. in worker goroutines.
+func (p *Progress) TryAddBar(total int64, options ...BarOption) (*Bar, error) {
+	return p.TryAddBarWithID(0, total, options...)
+}
+
+// TryAddBarWithID is AddBarWithID, which returns ErrCallAfterStop instead of
+// panic, see TryAddBar
+func (p *Progress) TryAddBarWithID(id int, total int64, options ...BarOption) (*Bar, error) {
+	p.acquire()
+	defer p.release()
+	req := &barRequest{
+		id:      id,
+		total:   total,
+		width:   p.width,
+		cancel:  p.cancel,
+		wg:      p.uwg,
+		result:  make(chan *Bar),
+		options: options,
+	}
+	select {
+	case p.addBarReqCh <- req:
+		return <-req.result, nil
+	case <-p.done:
+		return nil, ErrCallAfterStop
+	}
+}
+
+// SetColumns declares order of prepend and append columns by their ids,
+// see (*Bar).PrependColumn. Columns are synced by identity then, so changing
+// order, in which decorators are attached, doesn't change which columns sync
+// together. Column, which a bar doesn't have, is rendered blank. Nil slice
+// means attachment order.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetColumns(prependIDs, appendIDs []string) *Progress {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.columnsCh <- [2][]string{prependIDs, appendIDs}:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// SetSampleInterval sets rate sampling interval of bar(s), added after this
+// call, see (*Bar).SetSampleInterval. Combined with RefreshRate, it allows to
+// estimate rates from fine-grained samples, while terminal is redrawn at most
+// once per refresh.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetSampleInterval(d time.Duration) *Progress {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.sampleCh <- d:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// SetHideCursor hides cursor of terminal, while bars are rendered. Cursor is
+// shown on Stop. Defer RestoreTerminalOnPanic in main goroutine, so cursor is
+// shown, if the app panics.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetHideCursor(hide bool) *Progress {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.hideCursorCh <- hide:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// TermSizeFunc provides dimensions of terminal, bars are rendered to
+type TermSizeFunc func() (width, height int, err error)
+
+// SetTermSize sets provider of terminal size, which is queried every refresh.
+// It is useful, when size comes from an embedding TUI or in-memory renderer,
+// rather than from output's file descriptor. Nil restores default provider.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetTermSize(f TermSizeFunc) *Progress {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.termSizeCh <- f:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// SetPlainPrefix sets prefix of every line, which is appended to non-terminal
+// output, i.e. CI log, see TimestampPrefix. It makes logs useful for timing
+// analysis. Nil means no prefix, which is default.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetPlainPrefix(prefix PlainPrefix) *Progress {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.plainPrefixCh <- prefix:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// SetPlainThrottle limits non-terminal output, i.e. CI log, to lines of
+// bars, which have advanced by at least percent, or have changed, when
+// interval has passed since their last line. Completion of a bar is always
+// printed. Header and footer aren't printed in this mode. Zero percent and
+// interval disable throttling.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetPlainThrottle(percent float64, interval time.Duration) *Progress {
+	p.acquire()
+	defer p.release()
+	var pt *plainThrottle
+	if percent > 0 || interval > 0 {
+		pt = &plainThrottle{percent: percent, interval: interval}
+	}
+	select {
+	case p.throttleCh <- pt:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// SetAutoRefreshMode sets interval of plain lines, which output, that isn't
+// a terminal, i.e. CI log or pipe, gets in RenderAuto mode. Rendering in place
+// is kept on terminal. Such output gets plain lines of changed bars appended
+// at most once per interval, stripped of escape sequences, and completion of
+// a bar is always printed, see SetPlainThrottle, which overrides it. Zero
+// interval means default 10 seconds, negative one disables the mode, so every
+// changed frame is appended.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetAutoRefreshMode(interval time.Duration) *Progress {
+	p.acquire()
+	defer p.release()
+	if interval == 0 {
+		interval = autoRefreshInterval
+	}
+	select {
+	case p.autoRefreshCh <- interval:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// SetCIGroups wraps lines of every bar in non-terminal output into log
+// grouping markers of CI provider, so per task progress folds nicely in CI
+// UI, see DetectCI. Groups can't overlap in CI logs, so it works best, when
+// bars run one after another.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetCIGroups(provider CIProvider) *Progress {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.ciCh <- provider:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// Println prints log line above the bars, operands are formatted as by
+// fmt.Println. Printed lines are never overwritten by the bars, so they stay
+// in terminal's scrollback.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) Println(a ...interface{}) {
+	p.printLog(fmt.Sprintln(a...))
+}
+
+// Printf prints log line above the bars, see Println
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) Printf(format string, a ...interface{}) {
+	p.printLog(fmt.Sprintf(format, a...))
+}
+
+// Write prints b as log line above the bars, see Println, newline is appended,
+// if b doesn't end with one. It makes Progress io.Writer, so log.SetOutput(p)
+// interleaves log output with the bars. Every write is a line, so partial
+// lines must be buffered by the caller, as log.Logger does.
+// After Stop it returns ErrCallAfterStop.
+func (p *Progress) Write(b []byte) (int, error) {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.logCh <- string(b):
+		return len(b), nil
+	case <-p.done:
+		return 0, ErrCallAfterStop
+	}
+}
+
+func (p *Progress) printLog(str string) {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.logCh <- str:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+}
+
+// Errors returns channel, which receives error of underlying writer, i.e.
+// closed pipe. After write error, rendering stops until new writer is set by
+// SetOut, bars keep going though. The channel is closed after Stop.
+func (p *Progress) Errors() <-chan error {
+	return p.errCh
+}
+
+// RemoveBar removes bar at any time. Bar of another Progress instance is
+// left intact and false is returned.
 // Pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
 func (p *Progress) RemoveBar(b *Bar) bool {
+	ok, err := p.TryRemoveBar(b)
+	if err == ErrCallAfterStop {
+		panic(err)
+	}
+	return ok
+}
+
+// TryRemoveBar is RemoveBar, which returns ErrForeignBar, if b belongs to
+// another Progress instance, and ErrCallAfterStop instead of panic. Reports
+// false without error, if b has been removed already.
+func (p *Progress) TryRemoveBar(b *Bar) (bool, error) {
+	if b == nil || b.p == nil || b.p.srv != p.srv {
+		return false, ErrForeignBar
+	}
+	p.acquire()
+	defer p.release()
+	result := make(chan bool)
+	select {
+	case p.operationCh <- &operation{kind: barRemove, bar: b, result: result}:
+		return <-result, nil
+	case <-p.done:
+		return false, ErrCallAfterStop
+	}
+}
+
+// UpdateBarPriority sets priority of the bar, see (*Bar).SetPriority, and
+// moves it after bars with the same or higher priority, so bars are rendered
+// in priority order, i.e. active downloads float to the top and queued ones
+// sink. Returns false, if the bar isn't in the container.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) UpdateBarPriority(b *Bar, priority int) bool {
 	if isClosed(p.done) {
 		panic(ErrCallAfterStop)
 	}
+	return p.updatePriority(b, priority)
+}
+
+// updatePriority is UpdateBarPriority, which reports false after Stop
+func (p *Progress) updatePriority(b *Bar, priority int) bool {
+	p.acquire()
+	defer p.release()
 	result := make(chan bool)
-	p.operationCh <- &operation{barRemove, b, result}
-	return <-result
+	select {
+	case p.operationCh <- &operation{kind: barPriority, bar: b, priority: priority, result: result}:
+		return <-result
+	case <-p.done:
+		return false
+	}
 }
 
 // BarCount returns bars count in the container.
 // Pancis if called on stopped Progress instance, i.e after (*Progress).Stop()
 func (p *Progress) BarCount() int {
-	if isClosed(p.done) {
+	p.acquire()
+	defer p.release()
+	respCh := make(chan int, 1)
+	select {
+	case p.barCountReqCh <- respCh:
+		return <-respCh
+	case <-p.done:
 		panic(ErrCallAfterStop)
 	}
-	respCh := make(chan int, 1)
-	p.barCountReqCh <- respCh
-	return <-respCh
 }
 
-// Format sets custom format for underlying bar(s).
-// The default one is "[=>-]"
+// Format sets custom format for bar(s), added after this call.
+// The default one is "[=>-]". Invalid format is ignored, use SetFormat to
+// get an error instead.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
 func (p *Progress) Format(format string) *Progress {
+	p.SetFormat(format, false)
+	return p
+}
+
+// SetFormat sets custom format for bar(s), added after this call, and for
+// already added bars too, if existing is true. ErrInvalidFormat is returned,
+// if format isn't exactly 5 runes, nothing is changed in that case.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetFormat(format string, existing bool) error {
 	if utf8.RuneCountInString(format) != numFmtRunes {
-		return p
+		return ErrInvalidFormat
 	}
-	p.format = format
-	return p
+	p.acquire()
+	defer p.release()
+	select {
+	case p.formatCh <- format:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	if !existing {
+		return nil
+	}
+	respCh := make(chan []*Bar, 1)
+	select {
+	case p.barsReqCh <- respCh:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	for _, b := range <-respCh {
+		b.SetFormat(format)
+	}
+	return nil
 }
 
 // Stop shutdowns Progress' goroutine.
 // Should be called only after each bar's work done, i.e. bar has reached its
 // 100 %. It is NOT for cancelation. Use WithContext or WithCancel for
-// cancelation purposes.
+// cancelation purposes. See Wait, which also guarantees the final frame.
+// Shards of Progress, if any, are stopped as well.
+// It is safe to call Stop more than once and from several goroutines, each
+// call returns, after Progress' goroutine has exited.
 func (p *Progress) Stop() {
-	p.wg.Wait()
+	p.acquire()
+	defer p.release()
 	if isClosed(p.done) {
+		p.stopShards()
 		return
 	}
-	close(p.operationCh)
+	// bars may be incomplete, if Shutdown stops Progress meanwhile
+	select {
+	case <-p.wg.Zero():
+		select {
+		case p.stopReqCh <- struct{}{}:
+		case <-p.done:
+		}
+	case <-p.done:
+	}
+	<-p.done
+	p.stopShards()
+}
+
+// Wait blocks until every bar has completed or aborted, then renders the
+// final frame, so the last state of every bar is on the screen, and stops
+// Progress' goroutine. Unlike Stop, which may cut the last frame, the final
+// state of every bar is rendered. If Progress is stopped meanwhile, i.e. by
+// Stop, Shutdown or cancellation, Wait returns without waiting for the rest
+// of bars. Shards of Progress, if any, are waited as well.
+// It is safe to call Wait more than once.
+func (p *Progress) Wait() {
+	p.acquire()
+	defer p.release()
+	select {
+	case <-p.wg.Zero():
+		// shutdown stops after the next frame, which is rendered right away
+		select {
+		case p.shutdownCh <- struct{}{}:
+			select {
+			case p.refreshCh <- struct{}{}:
+			default:
+			}
+		case <-p.done:
+		}
+	case <-p.done:
+	}
+	<-p.done
+	h := p.srv
+	h.mu.Lock()
+	shards := h.shards
+	h.shards = nil
+	h.mu.Unlock()
+	for _, shard := range shards {
+		shard.Wait()
+	}
 }
 
 // server monitors underlying channels and renders any progress bars
 func (p *Progress) server() {
-	userRR := rr * time.Millisecond
-	t := time.NewTicker(userRR)
+	p.srv.mu.Lock()
+	st := p.srv.saved
+	p.srv.mu.Unlock()
+	if st == nil {
+		st = defaultServerState()
+	}
+
+	userRR := st.userRR
+	// render loop is dormant until the first bar is added
+	jitter := st.jitter
+	focusSGR := st.focusSGR
+	// focus isn't persisted, bars don't survive idle shutdown
+	var focus *Bar
+	expandFocused := st.expandFocused
+	debug := st.debug
+	heatmap := st.heatmap
+	// frame number and render duration of the previous frame, for debug overlay
+	var frameNum int
+	var renderTime time.Duration
+	var t *refreshTicker
+	var tick <-chan time.Time
+
+	reporter := st.reporter
+	hideCur := st.hideCur
+	var altered *alteredTerm
+	// idle is set, when server exits on idle shutdown
+	var idle bool
 
 	defer func() {
-		t.Stop()
+		if idle {
+			return
+		}
+		if t != nil {
+			t.Stop()
+		}
+		if altered != nil {
+			altered.restore()
+		}
+		close(p.errCh)
+		if closer, ok := reporter.(io.Closer); ok {
+			closer.Close()
+		}
 		close(p.done)
 	}()
 
-	var wg sync.WaitGroup
-	recoverIfPanic := func() {
-		if p := recover(); p != nil {
-			logger.Printf("unexpected panic: %+v\n", p)
-			var buf [4096]byte
-			n := runtime.Stack(buf[:], false)
-			os.Stderr.Write(buf[:n])
-		}
-		wg.Done()
+	maxDrawers := st.maxDrawers
+	columns := st.columns
+	sampleInterval := st.sampleInterval
+	plainPrefix := st.plainPrefix
+	throttle := st.throttle
+	autoRefresh := st.autoRefresh
+	// fallback is throttle of auto, accessible and ci modes, if throttle
+	// isn't set
+	var fallback *plainThrottle
+	ci := st.ci
+	less := st.less
+	bottomUp := st.bottomUp
+	report := st.report
+	started := st.started
+	align := st.align
+	header, footer := st.header, st.footer
+	minWidth := st.minWidth
+	format := st.format
+	beforeRender := st.beforeRender
+	out := st.out
+	cw := cwriter.New(out)
+	termSize := cwriter.NewSizeCache(out, termSizeTTL)
+	termSizeFunc := st.termSizeFunc
+	mode := st.mode
+	term := detectTerm(out).withMode(mode)
+	var frame bytes.Buffer
+	var lastFrame []byte
+	// skipped is the last frame, which auto mode hasn't appended yet
+	var skipped, skippedPlain []byte
+	var autoFrames frameThrottle
+	writeFailed := st.writeFailed
+	idleShutdown := st.idleShutdown
+	animations := !st.noAnimations
+	nameCol := st.nameColumn
+	var budget *budgetDrawer
+	if st.frameBudget > 0 {
+		budget = &budgetDrawer{budget: st.frameBudget}
 	}
-
-	var beforeRender BeforeRender
-	cw := cwriter.New(os.Stdout)
 	bars := make([]*Bar, 0, 3)
 
+	// flush leaves output, as if the bars were finished
+	flush := func() {
+		termSize.Stop()
+		if len(skipped) > 0 && !writeFailed {
+			writeFrame(cw, out, term, skipped, skippedPlain, &lastFrame)
+			skipped = skipped[:0]
+		}
+		// don't lose log lines, printed after the last frame
+		if cw.HasLog() && !writeFailed {
+			writeFrame(cw, out, term, lastFrame, nil, &lastFrame)
+		}
+		// leave the line, written by carriage return renderer
+		if term.cr && len(lastFrame) > 0 && !writeFailed {
+			io.WriteString(out, "\n")
+		}
+	}
+	defer func() {
+		if !idle {
+			flush()
+			p.events.close()
+		}
+	}()
+	writeReport := func() {
+		if report == nil {
+			return
+		}
+		if err := writeHTMLReport(report, started, time.Now(), bars); err != nil {
+			select {
+			case p.errCh <- err:
+			default:
+			}
+		}
+	}
+	// shutdown is set by Shutdown, server stops after the final frame
+	var shutdown bool
+
 	for {
 		select {
 		case w := <-p.outChangeReqCh:
-			cw.Flush()
+			if !writeFailed {
+				cw.Flush()
+			}
+			if altered != nil {
+				altered.restore()
+				altered = nil
+			}
+			out = w
 			cw = cwriter.New(w)
-		case op, ok := <-p.operationCh:
-			if !ok {
-				return
+			termSize.Stop()
+			termSize = cwriter.NewSizeCache(w, termSizeTTL)
+			if term.cr && len(lastFrame) > 0 {
+				io.WriteString(out, "\n")
+			}
+			term = detectTerm(w).withMode(mode)
+			lastFrame = nil
+			skipped = skipped[:0]
+			autoFrames = frameThrottle{}
+			writeFailed = false
+		case req := <-p.addBarReqCh:
+			if t == nil {
+				t = newRefreshTicker(userRR, jitter)
+				tick = t.C
+			}
+			p.wg.Add(1)
+			if req.wg != nil {
+				req.wg.Add(1)
+			}
+			bar := newBar(p, req.id, req.total, req.width, format, req.wg, req.cancel)
+			if sampleInterval > 0 {
+				bar.SetSampleInterval(sampleInterval)
+			}
+			if columns[0] != nil || columns[1] != nil {
+				bar.setColumns(columns)
+			}
+			if nameCol != nil {
+				bar.setNameColumn(nameCol)
 			}
+			if req.tpl != nil {
+				tpl, name := req.tpl, req.name
+				bar.operate(func(s *state) {
+					s.applyTemplate(tpl, name)
+				})
+			}
+			if len(req.options) > 0 {
+				options := req.options
+				bar.operate(func(s *state) {
+					for _, option := range options {
+						option(s)
+					}
+				})
+			}
+			// options may override id and priority
+			s := bar.getState()
+			p.events.emit(BarAdded, bar, s.id)
+			bars = insertByPriority(bars, bar, s.priority)
+			req.result <- bar
+		case op := <-p.operationCh:
 			switch op.kind {
-			case barAdd:
-				bars = append(bars, op.bar)
-				op.result <- true
 			case barRemove:
 				var ok bool
 				for i, b := range bars {
@@ -263,18 +1047,282 @@ func (p *Progress) server() {
 						bars = append(bars[:i], bars[i+1:]...)
 						ok = true
 						b.remove()
+						p.events.emit(BarRemoved, b, b.GetID())
+						if b == focus {
+							focus = nil
+						}
 						break
 					}
 				}
 				op.result <- ok
+			case barTransfer:
+				op.moved <- transferWork(op.bar, op.to, op.amount)
+			case barFocus:
+				focus = nil
+				for _, b := range bars {
+					if b == op.bar {
+						focus = b
+					}
+				}
+				op.focused <- focus
+			case barFocused:
+				op.focused <- focus
+			case barFocusNext, barFocusPrev:
+				renderBars := bars
+				if less != nil {
+					renderBars = sortBars(bars, less)
+				}
+				delta := 1
+				if (op.kind == barFocusPrev) != bottomUp {
+					delta = -1
+				}
+				focus = moveFocus(renderBars, focus, delta)
+				op.focused <- focus
+			case barPriority:
+				var ok bool
+				for i, b := range bars {
+					if b == op.bar {
+						bars = append(bars[:i], bars[i+1:]...)
+						ok = true
+						break
+					}
+				}
+				if ok {
+					priority := op.priority
+					op.bar.operate(func(s *state) {
+						s.priority = priority
+					})
+					bars = insertByPriority(bars, op.bar, priority)
+				}
+				op.result <- ok
+			}
+		case format = <-p.formatCh:
+		case idleShutdown = <-p.idleShutdownCh:
+		case animations = <-p.animationsCh:
+		case nameCol = <-p.nameColumnCh:
+			for _, b := range bars {
+				b.setNameColumn(nameCol)
+			}
+		case d := <-p.frameBudgetCh:
+			budget = nil
+			if d > 0 {
+				budget = &budgetDrawer{budget: d}
+			}
+		case <-p.idleCh:
+			if !idleShutdown || len(bars) > 0 {
+				break
+			}
+			p.srv.mu.Lock()
+			if p.srv.users > 0 {
+				p.srv.mu.Unlock()
+				break
+			}
+			// output is finished before the next server may start
+			flush()
+			if t != nil {
+				t.Stop()
+			}
+			if altered != nil {
+				altered.restore()
+			}
+			p.srv.saved = &serverState{
+				userRR:         userRR,
+				reporter:       reporter,
+				hideCur:        hideCur,
+				maxDrawers:     maxDrawers,
+				columns:        columns,
+				sampleInterval: sampleInterval,
+				plainPrefix:    plainPrefix,
+				throttle:       throttle,
+				autoRefresh:    autoRefresh,
+				ci:             ci,
+				less:           less,
+				bottomUp:       bottomUp,
+				report:         report,
+				started:        started,
+				align:          align,
+				header:         header,
+				footer:         footer,
+				minWidth:       minWidth,
+				format:         format,
+				beforeRender:   beforeRender,
+				out:            out,
+				termSizeFunc:   termSizeFunc,
+				mode:           mode,
+				writeFailed:    writeFailed,
+				idleShutdown:   idleShutdown,
+				noAnimations:   !animations,
+				nameColumn:     nameCol,
+				jitter:         jitter,
+				focusSGR:       focusSGR,
+				expandFocused:  expandFocused,
+				debug:          debug,
+				heatmap:        heatmap,
+			}
+			if budget != nil {
+				p.srv.saved.frameBudget = budget.budget
+			}
+			p.srv.running = false
+			p.srv.mu.Unlock()
+			idle = true
+			return
+		case <-p.stopReqCh:
+			writeReport()
+			return
+		case <-p.shutdownCh:
+			if tick == nil || len(bars) == 0 {
+				writeReport()
+				return
 			}
+			shutdown = true
 		case respCh := <-p.barCountReqCh:
 			respCh <- len(bars)
+		case respCh := <-p.barsReqCh:
+			respCh <- append([]*Bar(nil), bars...)
+		case respCh := <-p.configReqCh:
+			respCh <- &config{
+				format:       format,
+				refreshRate:  userRR,
+				maxDrawers:   maxDrawers,
+				minTermWidth: minWidth,
+				term:         term,
+				writeFailed:  writeFailed,
+				sorted:       less != nil,
+				bottomUp:     bottomUp,
+				columns:      columns,
+				header:       header != nil,
+				footer:       footer != nil,
+				reporter:     reporter != nil,
+				bars:         append([]*Bar(nil), bars...),
+			}
 		case beforeRender = <-p.brCh:
-		case <-t.C:
+		case reporter = <-p.reporterCh:
+		case maxDrawers = <-p.maxDrawersCh:
+		case sampleInterval = <-p.sampleCh:
+		case plainPrefix = <-p.plainPrefixCh:
+		case throttle = <-p.throttleCh:
+		case autoRefresh = <-p.autoRefreshCh:
+		case ci = <-p.ciCh:
+		case str := <-p.logCh:
+			if !strings.HasSuffix(str, "\n") {
+				str += "\n"
+			}
+			switch {
+			case writeFailed:
+			case term.shared:
+				if err := out.(*sharedWriter).log([]byte(str)); err != nil {
+					writeFailed = true
+				}
+			case term.cr:
+				// log line replaces bars line, which is redrawn next frame
+				if err := clearCR(out, lastFrame); err != nil {
+					writeFailed = true
+				} else if _, err := io.WriteString(out, str); err != nil {
+					writeFailed = true
+				}
+				lastFrame = nil
+			case !term.tty:
+				if _, err := io.WriteString(out, str); err != nil {
+					writeFailed = true
+				}
+			case len(bars) == 0:
+				cw.WriteLog([]byte(str))
+				cw.Flush()
+			default:
+				// printed above the bars on the next frame
+				cw.WriteLog([]byte(str))
+			}
+		case req := <-p.foreignCh:
+			repaint := !writeFailed && len(lastFrame) > 0 && (term.tty || term.cr)
+			if repaint {
+				if term.tty {
+					cw.Clear()
+				} else {
+					clearCR(out, lastFrame)
+				}
+			}
+			n, err := req.w.Write(req.b)
+			req.result <- foreignResult{n, err}
+			if repaint {
+				var err error
+				if n > 0 && req.b[n-1] != '\n' {
+					// don't start the bars in the middle of the line
+					_, err = io.WriteString(out, "\n")
+				}
+				if err == nil {
+					if term.cr {
+						frame := lastFrame
+						lastFrame = nil
+						err = writeCR(out, frame, &lastFrame)
+					} else {
+						err = writeFrame(cw, out, term, lastFrame, nil, &lastFrame)
+					}
+				}
+				if err != nil {
+					writeFailed = true
+					select {
+					case p.errCh <- err:
+					default:
+					}
+				}
+			}
+		case mode = <-p.renderModeCh:
+			term = detectTerm(out).withMode(mode)
+			lastFrame = nil
+		case termSizeFunc = <-p.termSizeCh:
+		case hideCur = <-p.hideCursorCh:
+			if !hideCur && altered != nil {
+				altered.restore()
+				altered = nil
+			}
+		case columns = <-p.columnsCh:
+			for _, b := range bars {
+				b.setColumns(columns)
+			}
+		case less = <-p.sortCh:
+		case bottomUp = <-p.bottomUpCh:
+		case align = <-p.alignCh:
+		case report = <-p.reportCh:
+		case <-p.redrawCh:
+			var err error
+			switch {
+			case writeFailed || len(lastFrame) == 0:
+			case term.cr:
+				_, err = io.WriteString(out, "\r"+string(lastFrame))
+			case term.tty:
+				// previous lines are gone, so don't erase anything
+				cw.Reset()
+				err = writeFrame(cw, out, term, lastFrame, nil, &lastFrame)
+			}
+			if err != nil {
+				writeFailed = true
+				select {
+				case p.errCh <- err:
+				default:
+				}
+			}
+		case minWidth = <-p.minTermWidthCh:
+		case header = <-p.headerCh:
+		case footer = <-p.footerCh:
+		case <-p.refreshCh:
+			// render right away, the next tick is the regular one, see Refresh
+			if t != nil {
+				immediate := make(chan time.Time, 1)
+				immediate <- time.Now()
+				tick = immediate
+			}
+		case <-tick:
+			if t != nil {
+				tick = t.C
+			}
+			renderStart := time.Now()
 			numBars := len(bars)
 
 			if numBars == 0 {
+				if shutdown {
+					writeReport()
+					return
+				}
 				break
 			}
 
@@ -282,114 +1330,776 @@ func (p *Progress) server() {
 				beforeRender(bars)
 			}
 
-			quitWidthSyncCh := make(chan struct{})
-			time.AfterFunc(userRR, func() {
-				close(quitWidthSyncCh)
-			})
+			renderBars := bars
+			if less != nil {
+				renderBars = sortBars(bars, less)
+			}
+			if bottomUp {
+				renderBars = reverseBars(renderBars)
+			}
 
-			b0 := bars[0]
-			prependWs := newWidthSync(quitWidthSyncCh, numBars, b0.NumOfPrependers())
-			appendWs := newWidthSync(quitWidthSyncCh, numBars, b0.NumOfAppenders())
+			sizeFunc := termSizeFunc
+			if sizeFunc == nil {
+				sizeFunc = termSize.Size
+			}
+			termWidth, termHeight, _ := sizeFunc()
+			drawWidth := termWidth
+			if align.maxWidth > 0 && (drawWidth <= 0 || drawWidth > align.maxWidth) {
+				drawWidth = align.maxWidth
+			}
+			var ibbCh <-chan indexedBarBuffer
+			if termWidth > 0 && termWidth < minWidth || heatmap {
+				ibbCh = compactDraw(renderBars)
+			} else if budget != nil {
+				ibbCh = budget.drawBars(renderBars, drawWidth, animations && term.tty)
+			} else {
+				ibbCh = drawBars(renderBars, drawWidth, maxDrawers, userRR, animations && term.tty)
+			}
 
-			width, _, _ := cwriter.GetTermSize()
-			ibars := iBarsGen(bars, width)
-			ibbCh := make(chan indexedBarBuffer)
-			wg.Add(numBars)
-			for i := 0; i < numBars; i++ {
-				go func() {
-					defer recoverIfPanic()
-					drawer(ibars, ibbCh, prependWs, appendWs)
-				}()
+			var current, total int64
+			ibbs := make([]indexedBarBuffer, numBars)
+			for ibb := range ibbCh {
+				ibbs[ibb.index] = ibb
+				if ibb.total > 0 {
+					current += ibb.current
+					total += ibb.total
+				}
 			}
-			go func() {
-				wg.Wait()
-				close(ibbCh)
-				for _, ch := range prependWs.listen {
-					close(ch)
+			alignBlock(ibbs, termWidth, align.align)
+			for i, b := range renderBars {
+				if b != focus {
+					continue
 				}
-				for _, ch := range appendWs.listen {
-					close(ch)
+				if term.tty && term.color {
+					ibbs[i].buf = styleLine(ibbs[i].buf, focusSGR)
+				}
+				if expandFocused && ibbs[i].details == nil {
+					s := b.getState()
+					ibbs[i].details = detailLines(&s, drawWidth, true)
 				}
-			}()
-
-			m := make(map[int][]byte, len(bars))
-			for ibb := range ibbCh {
-				m[ibb.index] = ibb.buf
 			}
-			for i := 0; i < len(bars); i++ {
-				cw.Write(m[i])
+			// after write error, frames are dropped until SetOut
+			if !writeFailed {
+				frame.Reset()
+				if header != nil {
+					frame.Write(line(header()))
+				}
+				// plain output falls back to percentage per bar, which
+				// is drawn by compactDraw
+				if heatmap && term.tty {
+					frame.Write(heatmapLine(ibbs, drawWidth))
+				} else {
+					for _, ibb := range ibbs {
+						frame.Write(ibb.buf)
+						if term.tty {
+							frame.Write(ibb.details)
+						}
+					}
+				}
+				if footer != nil {
+					frame.Write(line(footer()))
+				}
+				if debug {
+					frameNum++
+					frame.Write(debugLine(frameNum, renderTime, termWidth, termHeight))
+				}
+				if hideCur && term.tty && !term.shared && altered == nil {
+					altered = hideCursor(out)
+				}
+				var plain []byte
+				if !term.tty && !term.cr && (throttle != nil || ci != CINone || plainPrefix != nil || term.accessible) {
+					for i := range ibbs {
+						switch {
+						case term.accessible:
+							ibbs[i].buf = accessibleLine(i, ibbs[i])
+						case !term.color:
+							ibbs[i].buf = stripEscapes(ibbs[i].buf)
+						}
+					}
+					pt := throttle
+					switch {
+					case pt != nil:
+					case term.accessible:
+						pt = fallbackThrottle(&fallback, accessibleInterval)
+					case ci != CINone:
+						// per bar lines are required for grouping
+						pt = fallbackThrottle(&fallback, 0)
+					}
+					if pt != nil {
+						plain = pt.lines(time.Now(), renderBars, ibbs, plainPrefix, ci)
+						// throttle dedupes lines itself, i.e. completion
+						// is emitted, even if the frame hasn't changed
+						lastFrame = lastFrame[:0]
+					} else {
+						plain = prefixFrame(plainPrefix, time.Now(), header, footer, ibbs, !term.color)
+					}
+				}
+				// plain frames of auto mode are appended periodically, the
+				// last skipped one is appended by flush
+				auto := !term.tty && !term.cr && !term.shared && mode == RenderAuto && autoRefresh > 0 &&
+					throttle == nil && ci == CINone
+				if auto && !shutdown && !autoFrames.due(time.Now(), autoRefresh, ibbs) {
+					skipped = append(skipped[:0], frame.Bytes()...)
+					skippedPlain = plain
+				} else {
+					skipped = skipped[:0]
+					if err := writeFrame(cw, out, term, frame.Bytes(), plain, &lastFrame); err != nil {
+						writeFailed = true
+						select {
+						case p.errCh <- err:
+						default:
+						}
+					}
+				}
+				renderTime = time.Since(renderStart)
+				renderTime -= renderTime % time.Microsecond
 			}
 
-			cw.Flush()
+			if reporter != nil {
+				reporter.Report(current, total)
+			}
 
 			for _, b := range bars {
 				b.flushed()
 			}
+			if len(bars) > 0 {
+				bars = removeCompleted(bars, time.Now(), p.events)
+				if focus != nil && moveFocus(bars, focus, 0) != focus {
+					focus = nil
+				}
+				if len(bars) == 0 {
+					select {
+					case p.idleCh <- struct{}{}:
+					default:
+					}
+				}
+			}
+			if shutdown {
+				writeReport()
+				return
+			}
+		case focusSGR = <-p.focusStyleCh:
+		case expandFocused = <-p.expandCh:
+		case debug = <-p.debugCh:
+		case heatmap = <-p.heatmapCh:
+		case jitter = <-p.jitterCh:
+			if t != nil {
+				t.Stop()
+				t = newRefreshTicker(userRR, jitter)
+				tick = t.C
+			}
 		case userRR = <-p.rrChangeReqCh:
-			t.Stop()
-			t = time.NewTicker(userRR)
+			if t != nil {
+				t.Stop()
+				t = newRefreshTicker(userRR, jitter)
+				tick = t.C
+			}
 		case <-p.cancel:
 			return
 		}
 	}
 }
 
+// removeCompleted returns bars without completed ones, which are set to be
+// removed on completion and whose grace period has passed. Removal is
+// emitted to events.
+func removeCompleted(bars []*Bar, now time.Time, events *eventQueue) []*Bar {
+	var result []*Bar
+	for i, b := range bars {
+		select {
+		case <-b.done:
+		default:
+			if result != nil {
+				result = append(result, b)
+			}
+			continue
+		}
+		s := &b.state
+		if s.removeOnComplete && !s.completedAt.IsZero() && now.Sub(s.completedAt) >= s.removeGrace {
+			if result == nil {
+				result = append(make([]*Bar, 0, len(bars)), bars[:i]...)
+			}
+			events.emit(BarRemoved, b, s.id)
+			continue
+		}
+		if result != nil {
+			result = append(result, b)
+		}
+	}
+	if result == nil {
+		return bars
+	}
+	return result
+}
+
+// transferWork moves up to amount of remaining work from one bar to another.
+// It runs in Progress' goroutine, so both bars are adjusted between renders.
+func transferWork(from, to *Bar, amount int64) int64 {
+	s := from.getState()
+	if s.completed || s.total <= 0 {
+		return 0
+	}
+	if remaining := s.total - s.current; amount > remaining {
+		amount = remaining
+	}
+	if amount <= 0 {
+		return 0
+	}
+	// work is reserved at the other bar first, so the bar isn't completed
+	// by taking work, which may be given back
+	acceptedCh := make(chan bool, 1)
+	if !to.operate(func(s *state) {
+		acceptedCh <- s.acceptWork(amount)
+	}) || !<-acceptedCh {
+		return 0
+	}
+	movedCh := make(chan int64, 1)
+	if !from.operate(func(s *state) {
+		movedCh <- s.takeRemaining(amount)
+	}) {
+		movedCh <- 0
+	}
+	moved := <-movedCh
+	if excess := amount - moved; excess > 0 {
+		// the bar has progressed or completed meanwhile, give excess back
+		to.operate(func(s *state) {
+			s.addToTotal(-excess)
+		})
+	}
+	return moved
+}
+
+func detectTerm(w io.Writer) termInfo {
+	if sw, ok := w.(*sharedWriter); ok {
+		t := detectTerm(sw.r.out)
+		t.shared = true
+		return t
+	}
+	tty := cwriter.IsTerminal(w)
+	return termInfo{
+		tty:   tty,
+		color: tty && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb",
+	}
+}
+
+// writeFrame writes frame according to output capabilities. Terminal gets
+// frame rewritten in place. Other writers, i.e. pipe or CI log, get plain
+// frame appended, only if it differs from the last one. If plain isn't nil,
+// it is appended instead of frame, plain isn't stripped of colors. The last
+// frame is kept in both cases. Region of Renderer gets every frame as is,
+// plain is ignored, as Renderer composes and appends whole blocks of regions
+// to non-terminal output on its own, see (*Renderer).Writer.
+func writeFrame(cw *cwriter.Writer, out io.Writer, term termInfo, frame, plain []byte, lastFrame *[]byte) error {
+	if !term.color {
+		frame = stripEscapes(frame)
+	}
+	if term.shared {
+		*lastFrame = append((*lastFrame)[:0], frame...)
+		_, err := out.Write(frame)
+		return err
+	}
+	if term.cr {
+		return writeCR(out, frame, lastFrame)
+	}
+	if term.tty {
+		*lastFrame = append((*lastFrame)[:0], frame...)
+		cw.Write(frame)
+		return cw.Flush()
+	}
+	if bytes.Equal(frame, *lastFrame) {
+		return nil
+	}
+	*lastFrame = append((*lastFrame)[:0], frame...)
+	if plain == nil {
+		plain = frame
+	}
+	if len(plain) == 0 {
+		return nil
+	}
+	_, err := out.Write(plain)
+	return err
+}
+
+// PlainPrefix returns prefix of a line, which is appended to non-terminal
+// output, i.e. CI log. Name is bar.Meta("name"), empty for header and footer.
+type PlainPrefix func(now time.Time, name string) string
+
+// TimestampPrefix returns PlainPrefix, which formats time by layout and
+// appends bar name, if any, like "15:04:05 upload: "
+func TimestampPrefix(layout string) PlainPrefix {
+	return func(now time.Time, name string) string {
+		if name == "" {
+			return now.Format(layout) + " "
+		}
+		return now.Format(layout) + " " + name + ": "
+	}
+}
+
+// prefixFrame builds frame, which every line is prefixed by prefix
+func prefixFrame(prefix PlainPrefix, now time.Time, header, footer func() string, ibbs []indexedBarBuffer, strip bool) []byte {
+	var buf bytes.Buffer
+	writeLine := func(str string) {
+		b := line(str)
+		if strip {
+			b = stripEscapes(b)
+		}
+		writePrefixed(&buf, prefix, now, b, "")
+	}
+	if header != nil {
+		writeLine(header())
+	}
+	for _, ibb := range ibbs {
+		writePrefixed(&buf, prefix, now, ibb.buf, ibb.name)
+	}
+	if footer != nil {
+		writeLine(footer())
+	}
+	return buf.Bytes()
+}
+
+// writePrefixed writes every line of b to buf, prefixed by prefix, if any
+func writePrefixed(buf *bytes.Buffer, prefix PlainPrefix, now time.Time, b []byte, name string) {
+	for _, l := range bytes.SplitAfter(b, []byte("\n")) {
+		if len(l) == 0 {
+			continue
+		}
+		if prefix != nil {
+			buf.WriteString(prefix(now, name))
+		}
+		buf.Write(l)
+	}
+}
+
+// plainThrottle limits non-terminal output, see SetPlainThrottle
+type plainThrottle struct {
+	percent  float64
+	interval time.Duration
+	last     map[*Bar]*plainLine
+}
+
+// plainLine is the last emitted line of a bar
+type plainLine struct {
+	percent   float64
+	time      time.Time
+	buf       []byte
+	completed bool
+	// section is id of ci group
+	section string
+}
+
+// lines returns lines of bars, which have advanced by at least percent, or
+// changed after interval since they were emitted last time. Completion is
+// always emitted. Zero percent and interval mean any change is emitted.
+// Lines of every bar are wrapped into ci group markers, if ci is set. Result
+// is never nil, so writeFrame doesn't fall back to frame, if nothing is due.
+func (pt *plainThrottle) lines(now time.Time, bars []*Bar, ibbs []indexedBarBuffer, prefix PlainPrefix, ci CIProvider) []byte {
+	buf := new(bytes.Buffer)
+	last := make(map[*Bar]*plainLine, len(bars))
+	for i, b := range bars {
+		ibb := ibbs[i]
+		var percent float64
+		if ibb.total > 0 {
+			percent = float64(ibb.current) * 100 / float64(ibb.total)
+		}
+		completed := ibb.total > 0 && ibb.current >= ibb.total
+		pl, ok := pt.last[b]
+		emit := !ok ||
+			completed && !pl.completed ||
+			pt.percent <= 0 && pt.interval <= 0 && !bytes.Equal(ibb.buf, pl.buf) ||
+			percent-pl.percent >= pt.percent && pt.percent > 0 ||
+			now.Sub(pl.time) >= pt.interval && pt.interval > 0 && !bytes.Equal(ibb.buf, pl.buf)
+		if emit {
+			var section string
+			if ok {
+				section = pl.section
+			} else {
+				section = sectionID(i, ibb.name)
+				ci.writeStart(buf, now, section, groupTitle(i, ibb.name))
+			}
+			writePrefixed(buf, prefix, now, ibb.buf, ibb.name)
+			if completed && (!ok || !pl.completed) {
+				ci.writeEnd(buf, now, section)
+			}
+			pl = &plainLine{percent, now, append([]byte(nil), ibb.buf...), completed, section}
+		}
+		last[b] = pl
+	}
+	pt.last = last
+	if buf.Len() == 0 {
+		return []byte{}
+	}
+	return buf.Bytes()
+}
+
+// frameThrottle limits plain frames of non-terminal output in RenderAuto
+// mode, see SetAutoRefreshMode
+type frameThrottle struct {
+	last      time.Time
+	numBars   int
+	completed int
+}
+
+// due reports whether frame of ibbs is to be appended. It is at most once per
+// interval, unless a bar has been added, removed or completed since the last
+// appended frame.
+func (ft *frameThrottle) due(now time.Time, interval time.Duration, ibbs []indexedBarBuffer) bool {
+	var completed int
+	for _, ibb := range ibbs {
+		if ibb.total > 0 && ibb.current >= ibb.total {
+			completed++
+		}
+	}
+	if !ft.last.IsZero() && now.Sub(ft.last) < interval && len(ibbs) == ft.numBars && completed == ft.completed {
+		return false
+	}
+	ft.last, ft.numBars, ft.completed = now, len(ibbs), completed
+	return true
+}
+
+// fallbackThrottle returns *pt, which is replaced by new throttle, if its
+// interval differs, so lines state is kept between frames of the same mode
+func fallbackThrottle(pt **plainThrottle, interval time.Duration) *plainThrottle {
+	if *pt == nil || (*pt).interval != interval || (*pt).percent != 0 {
+		*pt = &plainThrottle{interval: interval}
+	}
+	return *pt
+}
+
+func barName(s *state) string {
+	name, _ := s.meta["name"].(string)
+	return name
+}
+
+// drawBars draws bars concurrently, sending results to returned channel,
+// which is closed after all bars are drawn. Width sync is abandoned after
+// syncTimeout.
+func drawBars(bars []*Bar, termWidth, maxDrawers int, syncTimeout time.Duration, animate bool) <-chan indexedBarBuffer {
+	numBars := len(bars)
+	s0 := bars[0].getState()
+	columns := s0.columnSchema()
+	numPrepend, numAppend := len(columns.Prepend), len(columns.Append)
+	if maxDrawers > 0 && maxDrawers < numBars {
+		return limitedDraw(bars, termWidth, maxDrawers, numPrepend, numAppend, animate)
+	}
+
+	quitWidthSyncCh := make(chan struct{})
+	timer := time.AfterFunc(syncTimeout, func() {
+		close(quitWidthSyncCh)
+	})
+	// drawnCh is closed, after all drawers are done
+	drawnCh := make(chan struct{})
+
+	prependWs := getWidthSync(quitWidthSyncCh, drawnCh, numBars, numPrepend)
+	appendWs := getWidthSync(quitWidthSyncCh, drawnCh, numBars, numAppend)
+
+	ibars := iBarsGen(bars, termWidth, animate)
+	ibbCh := make(chan indexedBarBuffer)
+	var wg sync.WaitGroup
+	wg.Add(numBars)
+	for i := 0; i < numBars; i++ {
+		go func() {
+			defer wg.Done()
+			defer recoverIfPanic()
+			drawer(ibars, ibbCh, prependWs, appendWs)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(ibbCh)
+		timer.Stop()
+		close(drawnCh)
+		putWidthSync(prependWs)
+		putWidthSync(appendWs)
+	}()
+	return ibbCh
+}
+
 func newWidthSync(quit <-chan struct{}, numBars, numColumn int) *widthSync {
+	ws := allocWidthSync(numBars, numColumn)
+	ws.start(quit, nil)
+	return ws
+}
+
+func allocWidthSync(numBars, numColumn int) *widthSync {
 	ws := &widthSync{
-		listen: make([]chan int, numColumn),
-		result: make([]chan int, numColumn),
+		listen:  make([]chan int, numColumn),
+		result:  make([]chan int, numColumn),
+		done:    make(chan bool, numColumn),
+		buf:     make([][]int, numColumn),
+		numBars: numBars,
 	}
 	for i := 0; i < numColumn; i++ {
 		ws.listen[i] = make(chan int, numBars)
 		ws.result[i] = make(chan int, numBars)
+		ws.buf[i] = make([]int, 0, numBars)
 	}
-	for i := 0; i < numColumn; i++ {
-		go func(listenCh <-chan int, resultCh chan<- int) {
-			defer close(resultCh)
-			widths := make([]int, 0, numBars)
+	return ws
+}
+
+// start runs sync goroutine per column. Result channel is closed, only if
+// sync is abandoned by quit, because decorators may still wait on it. Column,
+// which no decorator syncs, exits cleanly, when drawn is closed.
+func (ws *widthSync) start(quit, drawn <-chan struct{}) {
+	for i := range ws.listen {
+		go func(listenCh <-chan int, resultCh chan<- int, widths []int) {
+			clean := true
+			defer func() {
+				ws.done <- clean
+			}()
 		loop:
 			for {
 				select {
 				case w := <-listenCh:
 					widths = append(widths, w)
-					if len(widths) == numBars {
+					if len(widths) == ws.numBars {
 						break loop
 					}
 				case <-quit:
+					clean = false
+					defer close(resultCh)
 					if len(widths) == 0 {
 						return
 					}
 					break loop
+				case <-drawn:
+					// nobody waits for result, so partial widths are dropped
+					clean = len(widths) == 0 && len(listenCh) == 0
+					return
 				}
 			}
 			result := max(widths)
 			for i := 0; i < len(widths); i++ {
 				resultCh <- result
 			}
-		}(ws.listen[i], ws.result[i])
+		}(ws.listen[i], ws.result[i], ws.buf[i][:0])
 	}
+}
+
+// widthSyncPool keeps widthSync structures for reuse by drawBars, so steady
+// state ticks don't allocate channels and slices. Pools are keyed by number
+// of columns only, pooled widthSync is reused for any number of bars, which
+// fits capacity of its channels, so changing bar count doesn't grow the map.
+var widthSyncPool = struct {
+	sync.Mutex
+	m map[int]*sync.Pool
+}{m: make(map[int]*sync.Pool)}
+
+func widthSyncPoolFor(numColumn int) *sync.Pool {
+	widthSyncPool.Lock()
+	defer widthSyncPool.Unlock()
+	pool, ok := widthSyncPool.m[numColumn]
+	if !ok {
+		pool = new(sync.Pool)
+		widthSyncPool.m[numColumn] = pool
+	}
+	return pool
+}
+
+// getWidthSync is newWidthSync, which reuses pooled widthSync, see putWidthSync.
+// Sync goroutines exit, when drawn is closed, so it must be closed after all
+// decorators, using ws, are done.
+func getWidthSync(quit, drawn <-chan struct{}, numBars, numColumn int) *widthSync {
+	ws, _ := widthSyncPoolFor(numColumn).Get().(*widthSync)
+	if ws == nil || !ws.fits(numBars) {
+		// too small one is dropped, the bigger one is pooled instead
+		ws = allocWidthSync(numBars, numColumn)
+	}
+	ws.numBars = numBars
+	ws.start(quit, drawn)
 	return ws
 }
 
+// fits reports whether channels of ws can buffer widths of numBars
+func (ws *widthSync) fits(numBars int) bool {
+	return len(ws.listen) == 0 || cap(ws.listen[0]) >= numBars
+}
+
+// putWidthSync waits for sync goroutines of ws to exit, then returns ws to
+// the pool, if all of them exited cleanly and nothing is left in channels.
+// It must be called after drawn of getWidthSync is closed. It reports
+// whether ws was returned.
+func putWidthSync(ws *widthSync) bool {
+	clean := true
+	for range ws.listen {
+		clean = <-ws.done && clean
+	}
+	if !clean {
+		return false
+	}
+	for i := range ws.listen {
+		if len(ws.listen[i]) != 0 || len(ws.result[i]) != 0 {
+			return false
+		}
+	}
+	widthSyncPoolFor(len(ws.listen)).Put(ws)
+	return true
+}
+
 func drawer(ibars <-chan indexedBar, ibbCh chan<- indexedBarBuffer, prependWs, appendWs *widthSync) {
 	for b := range ibars {
-		buf := b.bar.bytes(b.termWidth, prependWs, appendWs)
+		s := b.bar.getState()
+		if !b.animate {
+			s.completionAnim = nil
+		}
+		buf := draw(&s, b.termWidth, prependWs, appendWs)
 		buf = append(buf, '\n')
-		ibbCh <- indexedBarBuffer{b.index, buf}
+		details := detailLines(&s, b.termWidth, false)
+		ibbCh <- indexedBarBuffer{b.index, buf, s.total, s.current, barName(&s), details}
 	}
 }
 
-func iBarsGen(bars []*Bar, width int) <-chan indexedBar {
+// compactDraw draws percentage only, for terminals, which are too narrow
+// to render anything else
+func compactDraw(bars []*Bar) <-chan indexedBarBuffer {
+	ibbCh := make(chan indexedBarBuffer)
+	go func() {
+		defer close(ibbCh)
+		for i, b := range bars {
+			s := b.getState()
+			buf := []byte(compactLine(&s))
+			ibbCh <- indexedBarBuffer{i, buf, s.total, s.current, barName(&s), nil}
+		}
+	}()
+	return ibbCh
+}
+
+func compactLine(s *state) string {
+	if s.simpleSpinner != nil {
+		return string(s.simpleSpinner(s)) + "\n"
+	}
+	return fmt.Sprintf("%3d %%\n", internal.Percentage(s.total, s.current, 100))
+}
+
+// limitedDraw draws bars with at most numDrawers goroutines.
+// As decorators block on width sync, until every bar in the column reports its
+// width, bars are drawn in two passes: the first one only measures decorators,
+// see measureDecorators, and the second one renders with already known max
+// widths.
+func limitedDraw(bars []*Bar, termWidth, numDrawers, numPrepend, numAppend int, animate bool) <-chan indexedBarBuffer {
+	ibbCh := make(chan indexedBarBuffer)
+	go func() {
+		defer close(ibbCh)
+		states := make([]state, len(bars))
+		prependWidths := make([][]int, len(bars))
+		appendWidths := make([][]int, len(bars))
+		runLimited(len(bars), numDrawers, func(i int) {
+			states[i] = bars[i].getState()
+			if !animate {
+				states[i].completionAnim = nil
+			}
+			prependWs := presetWidthSync(make([]int, numPrepend))
+			appendWs := presetWidthSync(make([]int, numAppend))
+			measureDecorators(&states[i], prependWs, appendWs)
+			prependWidths[i] = prependWs.widths()
+			appendWidths[i] = appendWs.widths()
+		})
+		prependMax := maxPerColumn(prependWidths, numPrepend)
+		appendMax := maxPerColumn(appendWidths, numAppend)
+		runLimited(len(bars), numDrawers, func(i int) {
+			s := &states[i]
+			buf := draw(s, termWidth, presetWidthSync(prependMax), presetWidthSync(appendMax))
+			buf = append(buf, '\n')
+			details := detailLines(s, termWidth, false)
+			ibbCh <- indexedBarBuffer{i, buf, s.total, s.current, barName(s), details}
+		})
+	}()
+	return ibbCh
+}
+
+// runLimited calls f for each index in [0, n) with at most limit goroutines
+func runLimited(n, limit int, f func(int)) {
+	var wg sync.WaitGroup
+	indexes := make(chan int)
+	go func() {
+		defer close(indexes)
+		for i := 0; i < n; i++ {
+			indexes <- i
+		}
+	}()
+	wg.Add(limit)
+	for i := 0; i < limit; i++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				func() {
+					defer recoverIfPanic()
+					f(i)
+				}()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// presetWidthSync creates widthSync, which never blocks decorators,
+// because result channels are prefilled with provided widths
+func presetWidthSync(widths []int) *widthSync {
+	ws := &widthSync{
+		listen: make([]chan int, len(widths)),
+		result: make([]chan int, len(widths)),
+	}
+	for i, w := range widths {
+		ws.listen[i] = make(chan int, 1)
+		ws.result[i] = make(chan int, 1)
+		ws.result[i] <- w
+	}
+	return ws
+}
+
+// widths returns widths reported by decorators, -1 for a column which
+// didn't report
+func (ws *widthSync) widths() []int {
+	widths := make([]int, len(ws.listen))
+	for i, ch := range ws.listen {
+		select {
+		case widths[i] = <-ch:
+		default:
+			widths[i] = -1
+		}
+	}
+	return widths
+}
+
+func maxPerColumn(widths [][]int, numColumn int) []int {
+	result := make([]int, numColumn)
+	for _, row := range widths {
+		for i := 0; i < len(row) && i < numColumn; i++ {
+			if row[i] > result[i] {
+				result[i] = row[i]
+			}
+		}
+	}
+	return result
+}
+
+func recoverIfPanic() {
+	if p := recover(); p != nil {
+		logger.Printf("unexpected panic: %+v\n", p)
+		var buf [4096]byte
+		n := runtime.Stack(buf[:], false)
+		os.Stderr.Write(buf[:n])
+	}
+}
+
+func iBarsGen(bars []*Bar, width int, animate bool) <-chan indexedBar {
 	ibars := make(chan indexedBar)
 	go func() {
 		defer close(ibars)
 		for i, b := range bars {
-			ibars <- indexedBar{i, width, b}
+			ibars <- indexedBar{i, width, b, animate}
 		}
 	}()
 	return ibars
 }
 
+// line returns str as bytes, terminated with newline
+func line(str string) []byte {
+	if len(str) == 0 || str[len(str)-1] != '\n' {
+		str += "\n"
+	}
+	return []byte(str)
+}
+
 // isClosed check if ch closed
 // caution see: http://www.tapirgames.com/blog/golang-channel-closing
 func isClosed(ch <-chan struct{}) bool {
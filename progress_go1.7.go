@@ -2,7 +2,12 @@
 
 package mpb
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 // WithContext cancellation via context
 func (p *Progress) WithContext(ctx context.Context) *Progress {
@@ -14,3 +19,94 @@ func (p *Progress) WithContext(ctx context.Context) *Progress {
 	p2.cancel = ctx.Done()
 	return p2
 }
+
+type progressContextKey struct{}
+
+// ContextWithProgress returns a copy of ctx, which carries p. It is an
+// explicit handle, which the application passes to libraries, so they attach
+// bars to p instead of running own render loops. See also Register.
+func ContextWithProgress(ctx context.Context, p *Progress) context.Context {
+	return context.WithValue(ctx, progressContextKey{}, p)
+}
+
+// ProgressFromContext returns Progress stored in ctx by ContextWithProgress,
+// or nil
+func ProgressFromContext(ctx context.Context) *Progress {
+	p, _ := ctx.Value(progressContextKey{}).(*Progress)
+	return p
+}
+
+// ShutdownError is returned by Shutdown, if the context is done before all
+// bars have completed
+type ShutdownError struct {
+	// Err is the context's error
+	Err error
+	// Incomplete are snapshots of the bars, which haven't completed
+	Incomplete []BarSnapshot
+}
+
+func (e *ShutdownError) Error() string {
+	ids := make([]string, len(e.Incomplete))
+	for i, s := range e.Incomplete {
+		ids[i] = "#" + strconv.Itoa(s.ID)
+		if s.Name != "" {
+			ids[i] += " " + s.Name
+		}
+	}
+	return fmt.Sprintf("mpb: shutdown: %v, incomplete bars: %s", e.Err, strings.Join(ids, ", "))
+}
+
+// Shutdown is Stop with deadline. It waits for all bars to complete, till ctx
+// is done. In the latter case the final frame is rendered, rendering stops,
+// and *ShutdownError reporting incomplete bars is returned. Incomplete bars
+// aren't aborted, they may be still updated, but aren't rendered anymore.
+// Shards of Progress, if any, are stopped with the same ctx.
+// It is safe to call Shutdown more than once.
+func (p *Progress) Shutdown(ctx context.Context) error {
+	p.acquire()
+	defer p.release()
+	select {
+	case <-p.wg.Zero():
+		p.Stop()
+		return nil
+	case <-ctx.Done():
+	case <-p.done:
+	}
+	var incomplete []BarSnapshot
+	respCh := make(chan []*Bar, 1)
+	select {
+	case p.barsReqCh <- respCh:
+		for _, s := range snapshots(<-respCh) {
+			if !s.Completed {
+				incomplete = append(incomplete, s)
+			}
+		}
+		select {
+		case p.shutdownCh <- struct{}{}:
+		case <-p.done:
+		}
+	case <-p.done:
+	}
+	<-p.done
+	err := p.shutdownShards(ctx)
+	if len(incomplete) > 0 {
+		return &ShutdownError{Err: ctx.Err(), Incomplete: incomplete}
+	}
+	return err
+}
+
+// shutdownShards shuts shards of p down, see Shard
+func (p *Progress) shutdownShards(ctx context.Context) error {
+	h := p.srv
+	h.mu.Lock()
+	shards := h.shards
+	h.shards = nil
+	h.mu.Unlock()
+	var err error
+	for _, shard := range shards {
+		if e := shard.Shutdown(ctx); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,95 @@
+//+build go1.7
+
+package mpb
+
+import (
+	"context"
+	"io/ioutil"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestShutdown(t *testing.T) {
+	p := New().SetOut(ioutil.Discard).RefreshRate(10 * time.Millisecond)
+	done := p.AddBar(10).SetMeta("name", "done")
+	p.AddBarWithID(7, 10).SetMeta("name", "stuck")
+	done.Incr(10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := p.Shutdown(ctx)
+	serr, ok := err.(*ShutdownError)
+	if !ok {
+		t.Fatalf("Want *ShutdownError, got: %v\n", err)
+	}
+	if serr.Err != context.DeadlineExceeded {
+		t.Errorf("Want %v, got: %v\n", context.DeadlineExceeded, serr.Err)
+	}
+	if len(serr.Incomplete) != 1 || serr.Incomplete[0].Name != "stuck" {
+		t.Errorf("Unexpected incomplete bars: %+v\n", serr.Incomplete)
+	}
+	if want := "mpb: shutdown: context deadline exceeded, incomplete bars: #7 stuck"; err.Error() != want {
+		t.Errorf("Want: %q, Got: %q\n", want, err.Error())
+	}
+	// Stop doesn't wait for incomplete bars after Shutdown
+	p.Stop()
+}
+
+func TestShutdownCompleted(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	p.AddBar(10).Incr(10)
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Errorf("Unexpected error: %v\n", err)
+	}
+}
+
+func TestShutdownNoLeak(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	p.AddBar(10)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p.Shutdown(ctx)
+	time.Sleep(10 * time.Millisecond)
+	before := runtime.NumGoroutine()
+	for i := 0; i < 50; i++ {
+		p.Shutdown(ctx)
+		p.Stop()
+	}
+	time.Sleep(10 * time.Millisecond)
+	if after := runtime.NumGoroutine(); after > before+5 {
+		t.Errorf("Want waiters to exit, goroutines before: %d, after: %d\n", before, after)
+	}
+}
+
+func TestWaitShutdown(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	p.AddBar(10)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		p.Shutdown(ctx)
+	}()
+	waited := make(chan struct{})
+	go func() {
+		p.Wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+	case <-time.After(time.Second):
+		t.Error("Wait didn't return after Shutdown")
+	}
+}
+
+func TestProgressFromContext(t *testing.T) {
+	if p := ProgressFromContext(context.Background()); p != nil {
+		t.Error("Progress found in empty context")
+	}
+	p := New().SetOut(ioutil.Discard)
+	if got := ProgressFromContext(ContextWithProgress(context.Background(), p)); got != p {
+		t.Errorf("Want carried Progress, got: %p\n", got)
+	}
+	p.Stop()
+}
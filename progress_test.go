@@ -2,7 +2,16 @@ package mpb
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestAddBar(t *testing.T) {
@@ -37,3 +46,1032 @@ func TestRemoveBar(t *testing.T) {
 	}
 	p.Stop()
 }
+
+func TestRemoveForeignBar(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	other := New().SetOut(ioutil.Discard)
+	b := other.AddBar(10)
+	if ok, err := p.TryRemoveBar(b); ok || err != ErrForeignBar {
+		t.Errorf("Want ErrForeignBar, got: %v, %v\n", ok, err)
+	}
+	if p.RemoveBar(b) {
+		t.Error("Want RemoveBar of foreign bar to fail")
+	}
+	if count := other.BarCount(); count != 1 {
+		t.Errorf("Foreign bar want intact, count: %d\n", count)
+	}
+	if ok, err := other.WithCancel(make(chan struct{})).TryRemoveBar(b); !ok || err != nil {
+		t.Errorf("Want removal via copy of Progress, got: %v, %v\n", ok, err)
+	}
+	p.Stop()
+	other.Stop()
+	if _, err := other.TryRemoveBar(b); err != ErrCallAfterStop {
+		t.Errorf("Want ErrCallAfterStop, got: %v\n", err)
+	}
+}
+
+func TestStopConcurrent(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	p.AddBar(10).Incr(10)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.Stop()
+		}()
+	}
+	wg.Wait()
+	p.Stop()
+	if _, err := p.TryAddBar(10); err != ErrCallAfterStop {
+		t.Errorf("Want ErrCallAfterStop, got: %v\n", err)
+	}
+}
+
+func TestTryAddBarDuringStop(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	p.AddBar(1).Incr(1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			bar, err := p.TryAddBar(1)
+			if err != nil {
+				if err != ErrCallAfterStop {
+					t.Errorf("Want ErrCallAfterStop, got: %v\n", err)
+				}
+				return
+			}
+			bar.Incr(1)
+		}
+	}()
+	time.Sleep(10 * time.Millisecond)
+	p.Stop()
+	<-done
+}
+
+func TestTransferTo(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	a := p.AddBar(10)
+	b := p.AddBar(10)
+	a.Incr(4)
+	for a.GetStatistics().Current != 4 {
+		time.Sleep(time.Millisecond)
+	}
+	if moved := a.TransferTo(b, 100); moved != 6 {
+		t.Errorf("Moved want: %d, got: %d\n", 6, moved)
+	}
+	if moved := a.TransferTo(b, 1); moved != 0 {
+		t.Errorf("Moved from completed bar want: %d, got: %d\n", 0, moved)
+	}
+	b.Incr(16)
+	p.Stop()
+	if s := a.GetStatistics(); s.Total != 4 || s.Current != 4 {
+		t.Errorf("Bar a want: 4/4, got: %d/%d\n", s.Current, s.Total)
+	}
+	if s := b.GetStatistics(); s.Total != 16 || s.Current != 16 {
+		t.Errorf("Bar b want: 16/16, got: %d/%d\n", s.Current, s.Total)
+	}
+}
+
+func TestReporter(t *testing.T) {
+	var buf bytes.Buffer
+	var current, total int64
+	p := New().SetOut(&buf).SetReporter(ReporterFunc(func(c, t int64) {
+		current, total = c, t
+	}))
+	bar := p.AddBar(10)
+	for i := 0; i < 10; i++ {
+		bar.Incr(1)
+	}
+	p.Stop()
+	if current != 10 || total != 10 {
+		t.Errorf("Report want: %d/%d, got: %d/%d\n", 10, 10, current, total)
+	}
+}
+
+type failingWriter struct {
+	writes int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, io.ErrClosedPipe
+}
+
+func TestWriteError(t *testing.T) {
+	w := new(failingWriter)
+	p := New().SetOut(w).RefreshRate(time.Millisecond)
+	bar := p.AddBar(10)
+	select {
+	case err := <-p.Errors():
+		if err != io.ErrClosedPipe {
+			t.Errorf("Want: %v, Got: %v\n", io.ErrClosedPipe, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("No write error reported")
+	}
+	time.Sleep(10 * time.Millisecond)
+	bar.Incr(10)
+	p.Stop()
+	if w.writes != 1 {
+		t.Errorf("Want writes: %d, Got: %d\n", 1, w.writes)
+	}
+	if _, ok := <-p.Errors(); ok {
+		t.Error("Errors channel isn't closed after stop")
+	}
+}
+
+func TestWriteFramePlain(t *testing.T) {
+	var buf bytes.Buffer
+	term := detectTerm(&buf)
+	if term.tty || term.color {
+		t.Fatalf("bytes.Buffer detected as %+v\n", term)
+	}
+	var lastFrame []byte
+	frames := []string{"\x1b[31ma\x1b[0m\n", "\x1b[32ma\x1b[0m\n", "b\n"}
+	for _, f := range frames {
+		if err := writeFrame(nil, &buf, term, []byte(f), nil, &lastFrame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := buf.String(); got != "a\nb\n" {
+		t.Errorf("Want: %q, Got: %q\n", "a\nb\n", got)
+	}
+}
+
+func TestPlainPrefix(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetPlainPrefix(TimestampPrefix("15:04:05")).
+		SetHeader(func() string { return "header" })
+	bar := p.AddBar(10).SetMeta("name", "upload").TrimLeftSpace().TrimRightSpace()
+	bar.Incr(10)
+	p.Stop()
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("Want at least 2 lines, got: %q\n", buf.String())
+	}
+	header, barLine := lines[len(lines)-2], lines[len(lines)-1]
+	if _, err := time.Parse("15:04:05", header[:8]); err != nil || header[8:] != " header" {
+		t.Errorf("Unexpected header line: %q\n", header)
+	}
+	if !strings.HasPrefix(barLine[8:], " upload: [") {
+		t.Errorf("Unexpected bar line: %q\n", barLine)
+	}
+}
+
+func TestPlainThrottle(t *testing.T) {
+	pt := &plainThrottle{percent: 10, interval: time.Minute}
+	bars := []*Bar{new(Bar), new(Bar)}
+	now := time.Now()
+	steps := []struct {
+		after    time.Duration
+		currents []int64
+		want     string
+	}{
+		{0, []int64{0, 0}, "a0\nb0\n"},
+		{time.Second, []int64{5, 10}, "b10\n"},
+		{2 * time.Second, []int64{9, 15}, ""},
+		{time.Minute, []int64{9, 16}, "a9\n"},
+		{time.Minute + time.Second, []int64{100, 16}, "a100\nb16\n"},
+		{time.Minute + 2*time.Second, []int64{100, 17}, ""},
+	}
+	for i, step := range steps {
+		ibbs := make([]indexedBarBuffer, len(bars))
+		for j, name := range []string{"a", "b"} {
+			ibbs[j] = indexedBarBuffer{
+				index:   j,
+				buf:     []byte(fmt.Sprintf("%s%d\n", name, step.currents[j])),
+				total:   100,
+				current: step.currents[j],
+			}
+		}
+		got := pt.lines(now.Add(step.after), bars, ibbs, nil, CINone)
+		if got == nil {
+			t.Errorf("Step %d: want non nil lines, so frame isn't written instead\n", i)
+		}
+		if string(got) != step.want {
+			t.Errorf("Step %d: want %q, got %q\n", i, step.want, got)
+		}
+	}
+}
+
+func TestPlainThrottleOutput(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetWidth(10).RefreshRate(10*time.Millisecond).SetPlainThrottle(0, time.Hour)
+	bar := p.AddBar(10)
+	for i := 0; i < 3; i++ {
+		bar.Incr(3)
+		time.Sleep(30 * time.Millisecond)
+	}
+	bar.Incr(1)
+	// Wait renders the final frame, so completion line is there
+	p.Wait()
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 || lines[1] != " [======] " {
+		t.Errorf("Want first and completion lines, got: %q\n", buf.String())
+	}
+}
+
+func TestAutoRefreshMode(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		// throttled means only first and completion lines
+		throttled bool
+	}{
+		// default interval
+		{0, true},
+		{time.Hour, true},
+		// every changed frame
+		{-1, false},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		p := New().SetOut(&buf).SetWidth(10).RefreshRate(10 * time.Millisecond)
+		if test.interval != 0 {
+			p.SetAutoRefreshMode(test.interval)
+		}
+		bar := p.AddBar(10)
+		for i := 0; i < 3; i++ {
+			bar.Incr(3)
+			time.Sleep(30 * time.Millisecond)
+		}
+		bar.Incr(1)
+		p.Wait()
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		if (len(lines) == 2) != test.throttled || lines[len(lines)-1] != " [======] " {
+			t.Errorf("Interval %v: want throttled %t, got: %q\n", test.interval, test.throttled, buf.String())
+		}
+	}
+}
+
+func TestAutoRefreshModeFlush(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetWidth(10).RefreshRate(10 * time.Millisecond)
+	bar := p.AddBar(10)
+	time.Sleep(30 * time.Millisecond)
+	bar.Incr(3)
+	time.Sleep(30 * time.Millisecond)
+	bar.Abort(false)
+	p.Stop()
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 || lines[1] != " [=>----] " {
+		t.Errorf("Want first and last skipped lines, got: %q\n", buf.String())
+	}
+}
+
+func TestLimitedDrawWidthSync(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf)
+	names := []string{"a", "abcdef", "abc"}
+	bars := make([]*Bar, len(names))
+	for i, name := range names {
+		bars[i] = p.AddBar(10).PrependName(name, 0, DwidthSync)
+	}
+	lines := make([][]byte, len(bars))
+	for ibb := range limitedDraw(bars, 40, 1, 1, 0, false) {
+		lines[ibb.index] = ibb.buf
+	}
+	for i, line := range lines {
+		if got := bytes.IndexByte(line, '['); got != 7 {
+			t.Errorf("Line %d: want '[' at %d, got %d: %q\n", i, 7, got, line)
+		}
+	}
+	for _, b := range bars {
+		b.Completed()
+	}
+	p.Stop()
+}
+
+func TestLimitedDrawSpinnerAdvancesOnce(t *testing.T) {
+	p := New().SetOut(ioutil.Discard).RefreshRate(time.Hour)
+	bars := make([]*Bar, 2)
+	for i := range bars {
+		// body of the bar with unknown total is the default spinner
+		bars[i] = p.AddBar(0).PrependFunc(Spinner("ab", time.Hour)).TrimLeftSpace()
+	}
+	var got string
+	for tick := 0; tick < 3; tick++ {
+		lines := make([]string, len(bars))
+		for ibb := range limitedDraw(bars, 40, 1, 1, 0, false) {
+			lines[ibb.index] = string(ibb.buf)
+		}
+		// decorator and spinner rune of the body
+		got += lines[0][:1] + lines[0][2:3]
+	}
+	if want := `b\a|b/`; got != want {
+		t.Errorf("Want spinners to advance once per tick: %q, got: %q\n", want, got)
+	}
+	p.RefreshRate(10 * time.Millisecond)
+	for _, b := range bars {
+		b.Completed()
+	}
+	p.Stop()
+}
+
+func BenchmarkIncr(b *testing.B) {
+	p := New().SetOut(ioutil.Discard)
+	bar := p.AddBar(int64(b.N))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bar.Incr(1)
+	}
+	b.StopTimer()
+	p.Stop()
+}
+
+func BenchmarkLimitedDraw(b *testing.B) {
+	p := New().SetOut(ioutil.Discard)
+	bars := make([]*Bar, 32)
+	for i := range bars {
+		bars[i] = p.AddBar(100).
+			PrependName(fmt.Sprintf("Bar#%d:", i), 0, DwidthSync).
+			AppendPercentage(5, 0)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for range limitedDraw(bars, 100, 4, 1, 1, false) {
+		}
+	}
+	b.StopTimer()
+	for _, bar := range bars {
+		bar.Completed()
+	}
+	p.Stop()
+}
+
+func TestDrawBarsWidthSyncReuse(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	names := []string{"a", "abcdef", "abc"}
+	bars := make([]*Bar, len(names))
+	for i, name := range names {
+		bars[i] = p.AddBar(10).PrependName(name, 0, DwidthSync)
+	}
+	for tick := 0; tick < 3; tick++ {
+		for ibb := range drawBars(bars, 40, 0, time.Second, false) {
+			if got := bytes.IndexByte(ibb.buf, '['); got != 7 {
+				t.Errorf("Tick %d, line %d: want '[' at %d, got %d: %q\n", tick, ibb.index, 7, got, ibb.buf)
+			}
+		}
+	}
+	for _, b := range bars {
+		b.Completed()
+	}
+	p.Stop()
+}
+
+func TestPutWidthSync(t *testing.T) {
+	quit := make(chan struct{})
+	drawn := make(chan struct{})
+	ws := getWidthSync(quit, drawn, 2, 1)
+	ws.listen[0] <- 1
+	ws.listen[0] <- 3
+	for i := 0; i < 2; i++ {
+		if got := <-ws.result[0]; got != 3 {
+			t.Errorf("Want max width 3, got %d\n", got)
+		}
+	}
+	close(drawn)
+	if !putWidthSync(ws) {
+		t.Error("Want clean widthSync to be pooled")
+	}
+
+	// column, which nobody syncs, is done, when bars are drawn
+	drawn = make(chan struct{})
+	ws = getWidthSync(quit, drawn, 2, 1)
+	close(drawn)
+	if !putWidthSync(ws) {
+		t.Error("Want unsynced widthSync to be pooled")
+	}
+
+	ws = getWidthSync(quit, make(chan struct{}), 2, 1)
+	ws.listen[0] <- 1
+	close(quit)
+	<-ws.result[0]
+	if putWidthSync(ws) {
+		t.Error("Want abandoned widthSync not to be pooled")
+	}
+}
+
+func TestWidthSyncPoolBarCount(t *testing.T) {
+	const numColumn = 7
+	sync := func(numBars int) {
+		drawn := make(chan struct{})
+		ws := getWidthSync(nil, drawn, numBars, numColumn)
+		for k := range ws.listen {
+			for i := 0; i < numBars; i++ {
+				ws.listen[k] <- i
+			}
+			for i := 0; i < numBars; i++ {
+				if got := <-ws.result[k]; got != numBars-1 {
+					t.Fatalf("%d bars: want max width %d, got %d\n", numBars, numBars-1, got)
+				}
+			}
+		}
+		close(drawn)
+		if !putWidthSync(ws) {
+			t.Fatalf("%d bars: want widthSync to be pooled\n", numBars)
+		}
+	}
+	widthSyncPool.Lock()
+	n := len(widthSyncPool.m)
+	widthSyncPool.Unlock()
+	// smaller count reuses bigger widthSync, bigger one replaces it
+	for _, numBars := range []int{10, 3, 10, 50, 1, 50} {
+		sync(numBars)
+	}
+	widthSyncPool.Lock()
+	defer widthSyncPool.Unlock()
+	if got := len(widthSyncPool.m) - n; got > 1 {
+		t.Errorf("Want at most one pool per column count, got %d new\n", got)
+	}
+}
+
+func TestDrawBarsUnsyncedNoLeak(t *testing.T) {
+	p := New().SetOut(ioutil.Discard).RefreshRate(time.Hour)
+	bar := p.AddBar(10).
+		PrependFunc(func(s *Statistics, _ chan<- int, _ <-chan int) string {
+			return "a"
+		}).
+		AppendFunc(func(s *Statistics, _ chan<- int, _ <-chan int) string {
+			return "b"
+		})
+	bars := []*Bar{bar}
+	for ibb := range drawBars(bars, 40, 0, time.Hour, false) {
+		_ = ibb
+	}
+	time.Sleep(10 * time.Millisecond)
+	before := runtime.NumGoroutine()
+	for i := 0; i < 50; i++ {
+		for ibb := range drawBars(bars, 40, 0, time.Hour, false) {
+			_ = ibb
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	if after := runtime.NumGoroutine(); after > before+5 {
+		t.Errorf("Want sync goroutines to exit, goroutines before: %d, after: %d\n", before, after)
+	}
+	p.RefreshRate(10 * time.Millisecond)
+	bar.Incr(10)
+	p.Stop()
+}
+
+func BenchmarkWidthSync(b *testing.B) {
+	const numBars, numColumn = 32, 2
+	var wg sync.WaitGroup
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		drawn := make(chan struct{})
+		ws := getWidthSync(nil, drawn, numBars, numColumn)
+		wg.Add(numBars)
+		for j := 0; j < numBars; j++ {
+			go func(w int) {
+				defer wg.Done()
+				for k := range ws.listen {
+					ws.listen[k] <- w
+					<-ws.result[k]
+				}
+			}(j)
+		}
+		wg.Wait()
+		close(drawn)
+		putWidthSync(ws)
+	}
+}
+
+func TestHeaderFooter(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).
+		SetHeader(func() string { return "header" }).
+		SetFooter(func() string { return "footer\n" })
+	bar := p.AddBar(10).TrimLeftSpace().TrimRightSpace()
+	bar.Incr(10)
+	p.Stop()
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) < 3 {
+		t.Fatalf("Want at least 3 lines, got: %q\n", buf.Bytes())
+	}
+	last := lines[len(lines)-3:]
+	if !bytes.HasSuffix(last[0], []byte("header")) {
+		t.Errorf("Want header, got: %q\n", last[0])
+	}
+	if !bytes.HasPrefix(last[1], []byte("[")) {
+		t.Errorf("Want bar, got: %q\n", last[1])
+	}
+	if string(last[2]) != "footer" {
+		t.Errorf("Want footer, got: %q\n", last[2])
+	}
+}
+
+func TestPrintln(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf)
+	p.Println("before", 1)
+	bar := p.AddBar(10).TrimLeftSpace().TrimRightSpace()
+	p.Printf("during %d", 2)
+	bar.Incr(10)
+	p.Stop()
+	out := buf.String()
+	if !strings.HasPrefix(out, "before 1\n") {
+		t.Errorf("Want log first, got: %q\n", out)
+	}
+	if !strings.Contains(out, "during 2\n") {
+		t.Errorf("Want printf log line, got: %q\n", out)
+	}
+}
+
+func TestProgressWrite(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf)
+	logger := log.New(p, "log: ", 0)
+	bar := p.AddBar(10)
+	logger.Print("during")
+	bar.Incr(10)
+	p.Stop()
+	if !strings.Contains(buf.String(), "log: during\n") {
+		t.Errorf("Want log line, got: %q\n", buf.String())
+	}
+	if _, err := p.Write([]byte("after")); err != ErrCallAfterStop {
+		t.Errorf("Want ErrCallAfterStop, got: %v\n", err)
+	}
+}
+
+func TestWait(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetWidth(10).RefreshRate(10 * time.Millisecond)
+	bar := p.AddBar(10)
+	go bar.Incr(10)
+	p.Wait()
+	if !strings.HasSuffix(buf.String(), " [======] \n") {
+		t.Errorf("Final frame not found in: %q\n", buf.String())
+	}
+	p.Wait()
+}
+
+func TestTransferToCompleted(t *testing.T) {
+	p := New().SetOut(ioutil.Discard).RefreshRate(time.Hour)
+	from := p.AddBar(10)
+	to := p.AddBar(10)
+	fired := make(chan struct{}, 1)
+	from.OnComplete(func(*Bar) {
+		fired <- struct{}{}
+	})
+	from.Incr(5)
+	to.Incr(10)
+	for from.GetStatistics().Current != 5 || !isCompleted(to.GetStatistics()) {
+		time.Sleep(time.Millisecond)
+	}
+	if moved := from.TransferTo(to, 5); moved != 0 {
+		t.Errorf("Moved to completed bar want: %d, got: %d\n", 0, moved)
+	}
+	if s := from.GetStatistics(); s.Total != 10 || s.Current != 5 {
+		t.Errorf("Bar want: 5/10, got: %d/%d\n", s.Current, s.Total)
+	}
+	select {
+	case <-fired:
+		t.Error("OnComplete fired by rolled back transfer")
+	case <-time.After(20 * time.Millisecond):
+	}
+	from.Incr(5)
+	p.RefreshRate(10 * time.Millisecond)
+	p.Wait()
+}
+
+func TestCompactLine(t *testing.T) {
+	s := &state{total: 200, current: 50}
+	if got := compactLine(s); got != " 25 %\n" {
+		t.Errorf("Want: %q, Got: %q\n", " 25 %\n", got)
+	}
+}
+
+func TestWriteFrameCarriageReturn(t *testing.T) {
+	var buf bytes.Buffer
+	term := detectTerm(&buf).withMode(RenderCarriageReturn)
+	var lastFrame []byte
+	for _, f := range []string{"abc\ndef\n", "abc\ndef\n", "ab\n"} {
+		if err := writeFrame(nil, &buf, term, []byte(f), nil, &lastFrame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := "\rabc | def\rab       "
+	if got := buf.String(); got != want {
+		t.Errorf("Want: %q, Got: %q\n", want, got)
+	}
+}
+
+func TestRenderCarriageReturn(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetRenderMode(RenderCarriageReturn)
+	bar := p.AddBar(10).TrimLeftSpace().TrimRightSpace()
+	bar.Incr(10)
+	p.Stop()
+	out := buf.String()
+	if strings.Contains(out, "\x1b[") {
+		t.Errorf("Unexpected escape sequence: %q\n", out)
+	}
+	if !strings.HasPrefix(out, "\r[") || !strings.HasSuffix(out, "]\n") {
+		t.Errorf("Want single carriage return line, got: %q\n", out)
+	}
+}
+
+func TestSetTermSize(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetTermSize(func() (int, int, error) {
+		return minTermWidth - 1, 24, nil
+	})
+	bar := p.AddBar(10)
+	bar.Incr(10)
+	p.Stop()
+	out := buf.String()
+	if strings.Contains(out, "[") || !strings.Contains(out, "100 %") {
+		t.Errorf("Want compact line, got: %q\n", out)
+	}
+}
+
+func TestBottomUp(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetBottomUp(true)
+	first := p.AddBar(10).PrependName("first", 0, 0)
+	second := p.AddBar(10).PrependName("second", 0, 0)
+	first.Incr(10)
+	second.Incr(10)
+	p.Stop()
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("Want at least 2 lines, got: %q\n", buf.String())
+	}
+	last := lines[len(lines)-2:]
+	if !strings.HasPrefix(last[0], "second") || !strings.HasPrefix(last[1], "first") {
+		t.Errorf("Want newest bar on top, got: %q\n", last)
+	}
+}
+
+func TestAlignBlock(t *testing.T) {
+	tests := []struct {
+		align Align
+		want  []string
+	}{
+		{AlignLeft, []string{"ab\n", "abcd\n"}},
+		{AlignRight, []string{"      ab\n", "      abcd\n"}},
+		{AlignCenter, []string{"   ab\n", "   abcd\n"}},
+	}
+	for _, test := range tests {
+		ibbs := []indexedBarBuffer{{buf: []byte("ab\n")}, {buf: []byte("abcd\n")}}
+		alignBlock(ibbs, 10, test.align)
+		for i, ibb := range ibbs {
+			if string(ibb.buf) != test.want[i] {
+				t.Errorf("Align %d, line %d: want %q, got %q\n", test.align, i, test.want[i], ibb.buf)
+			}
+		}
+	}
+}
+
+func TestSetAlignMaxWidth(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetAlign(AlignRight, 40).
+		SetTermSize(func() (int, int, error) { return 100, 24, nil })
+	bar := p.AddBar(10).TrimLeftSpace().TrimRightSpace()
+	bar.Incr(10)
+	p.Stop()
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	last := lines[len(lines)-1]
+	if len(last) != 100 || !strings.HasPrefix(last, strings.Repeat(" ", 60)+"[") {
+		t.Errorf("Want bar of width 40 at right edge, got: %q\n", last)
+	}
+}
+
+func TestRenderAccessible(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetRenderMode(RenderAccessible)
+	bar := p.AddBar(10).SetMeta("name", "upload")
+	bar.Incr(4)
+	time.Sleep(200 * time.Millisecond)
+	bar.Incr(6)
+	p.Stop()
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Want 2 announcements, got: %q\n", buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "upload: ") || !strings.HasSuffix(lines[0], " percent") {
+		t.Errorf("Unexpected first announcement: %q\n", lines[0])
+	}
+	if lines[1] != "upload: done, 10 of 10" {
+		t.Errorf("Unexpected last announcement: %q\n", lines[1])
+	}
+}
+
+func TestRenderAccessibleKeepsThrottle(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetWidth(10).RefreshRate(10 * time.Millisecond).SetRenderMode(RenderAccessible)
+	bar := p.AddBar(10)
+	bar.Incr(1)
+	time.Sleep(50 * time.Millisecond)
+	// leaving accessible mode restores unthrottled plain frames
+	p.SetRenderMode(RenderPlain)
+	for i := 0; i < 3; i++ {
+		bar.Incr(3)
+		time.Sleep(50 * time.Millisecond)
+	}
+	p.Stop()
+	if got := strings.Count(buf.String(), "["); got < 3 {
+		t.Errorf("Want every frame after accessible mode, got: %q\n", buf.String())
+	}
+}
+
+func TestRedrawCarriageReturn(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetRenderMode(RenderCarriageReturn)
+	bar := p.AddBar(10).TrimLeftSpace().TrimRightSpace()
+	bar.Incr(10)
+	for bar.GetStatistics().Current != 10 {
+		time.Sleep(time.Millisecond)
+	}
+	// unchanged frame isn't rewritten by refresh
+	time.Sleep(200 * time.Millisecond)
+	p.Redraw()
+	p.Stop()
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\r")
+	if len(lines) < 3 || lines[len(lines)-1] != lines[len(lines)-2] {
+		t.Errorf("Want last line repainted, got: %q\n", buf.String())
+	}
+}
+
+func TestWrapWriter(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetRenderMode(RenderCarriageReturn)
+	w := p.WrapWriter(&buf)
+	bar := p.AddBar(10).TrimLeftSpace().TrimRightSpace()
+	bar.Incr(5)
+	// let the bar render
+	time.Sleep(250 * time.Millisecond)
+	fmt.Fprint(w, "foreign")
+	bar.Incr(5)
+	p.Stop()
+	fmt.Fprint(w, "after stop")
+	out := buf.String()
+	if !strings.Contains(out, "\rforeign\n\r[") {
+		t.Errorf("Want bars repainted below foreign output, got: %q\n", out)
+	}
+	if !strings.HasSuffix(out, "]\nafter stop") {
+		t.Errorf("Want direct write after stop, got: %q\n", out)
+	}
+}
+
+func TestNoBarsNoOutput(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetHideCursor(true).RefreshRate(time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	p.Stop()
+	if buf.Len() != 0 {
+		t.Errorf("Want no output, got: %q\n", buf.String())
+	}
+}
+
+func TestSetWidthCopy(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	p2 := p.SetWidth(20)
+	if p2 == p {
+		t.Fatal("SetWidth returned the same instance")
+	}
+	b1 := p.AddBar(10)
+	b2 := p2.AddBar(10)
+	if w := b1.getState().width; w != pwidth {
+		t.Errorf("Bar of p want width %d, got: %d\n", pwidth, w)
+	}
+	if w := b2.getState().width; w != 20 {
+		t.Errorf("Bar of copy want width %d, got: %d\n", 20, w)
+	}
+	b1.Incr(10)
+	b2.Incr(10)
+	p.Stop()
+	// SetWidth doesn't talk to server, so it doesn't panic after Stop
+	p.SetWidth(30)
+}
+
+func TestIdleShutdown(t *testing.T) {
+	var buf bytes.Buffer
+	p := New()
+	if p.running() {
+		t.Fatal("Server is started by New")
+	}
+	p = p.SetOut(&buf).SetWidth(20).SetIdleShutdown(true)
+	waitIdle := func() {
+		for p.running() {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitIdle()
+
+	bar := p.AddBar(10).TrimLeftSpace().TrimRightSpace()
+	bar.Incr(10)
+	for bar.GetStatistics().Current != 10 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(200 * time.Millisecond)
+	p.RemoveBar(bar)
+	waitIdle()
+
+	// configuration survives restart
+	bar = p.AddBar(10).TrimLeftSpace().TrimRightSpace()
+	bar.Incr(10)
+	p.Stop()
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if last := lines[len(lines)-1]; len(last) != 20 {
+		t.Errorf("Want bar of width 20, got: %q\n", last)
+	}
+}
+
+func TestRemoveOnComplete(t *testing.T) {
+	p := New().SetOut(ioutil.Discard).RefreshRate(10 * time.Millisecond)
+	a := p.AddBar(10).RemoveOnComplete(0)
+	b := p.AddBar(10).RemoveOnComplete(time.Hour)
+	c := p.AddBar(10)
+	a.Incr(10)
+	b.Incr(10)
+	deadline := time.Now().Add(time.Second)
+	for p.BarCount() != 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if count := p.BarCount(); count != 2 {
+		t.Errorf("Want %d bars, got %d\n", 2, count)
+	}
+	c.Incr(10)
+	p.Stop()
+}
+
+func TestSetFormat(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).SetWidth(10)
+	bar := p.AddBar(10).TrimLeftSpace().TrimRightSpace()
+	if err := p.SetFormat("[=>]", true); err != ErrInvalidFormat {
+		t.Errorf("Want %v, got: %v\n", ErrInvalidFormat, err)
+	}
+	if err := bar.SetFormat("[=>-"); err != ErrInvalidFormat {
+		t.Errorf("Want %v, got: %v\n", ErrInvalidFormat, err)
+	}
+	if err := p.SetFormat("(#>.)", true); err != nil {
+		t.Errorf("Unexpected error: %v\n", err)
+	}
+	bar.Incr(10)
+	p.Stop()
+	if want := "(########)"; !strings.Contains(buf.String(), want) {
+		t.Errorf("Want %q in output, got: %q\n", want, buf.String())
+	}
+}
+
+func TestWithWaitGroup(t *testing.T) {
+	var wg sync.WaitGroup
+	p := New().SetOut(ioutil.Discard)
+	tracked := p.WithWaitGroup(&wg).AddBar(10)
+	untracked := p.AddBar(10)
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	tracked.Incr(10)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("WaitGroup isn't done after tracked bar completed")
+	}
+	untracked.Incr(10)
+	p.Stop()
+}
+
+type unityRecorder struct {
+	gate    chan struct{}
+	updates chan unityUpdate
+	closed  bool
+}
+
+func (r *unityRecorder) update(appURI string, u unityUpdate) error {
+	if appURI != "application://app.desktop" {
+		return fmt.Errorf("unexpected app uri: %q", appURI)
+	}
+	r.updates <- u
+	<-r.gate
+	return nil
+}
+
+func (r *unityRecorder) Close() error {
+	r.closed = true
+	return nil
+}
+
+func newUnityRecorder(r *UnityReporter) *unityRecorder {
+	rec := &unityRecorder{
+		gate:    make(chan struct{}),
+		updates: make(chan unityUpdate, 8),
+	}
+	var dials int
+	r.dial = func() (unitySignaler, error) {
+		dials++
+		if dials > 1 {
+			return nil, errors.New("redialed")
+		}
+		return rec, nil
+	}
+	return rec
+}
+
+func TestUnityReporter(t *testing.T) {
+	r := NewUnityReporter("app.desktop")
+	rec := newUnityRecorder(r)
+	r.Report(0, 0)
+	r.Report(50, 200)
+	want := []unityUpdate{{0.25, true}, {1, true}, {0, false}}
+	if u := <-rec.updates; u != want[0] {
+		t.Errorf("Update %d: want %+v, got: %+v\n", 0, want[0], u)
+	}
+	// worker is blocked at 25%, the rest is coalesced to the latest
+	r.Report(100, 200)
+	r.Report(200, 200)
+	close(rec.gate)
+	if u := <-rec.updates; u != want[1] {
+		t.Errorf("Update %d: want %+v, got: %+v\n", 1, want[1], u)
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf("Close: %v\n", err)
+	}
+	if u := <-rec.updates; u != want[2] {
+		t.Errorf("Update %d: want %+v, got: %+v\n", 2, want[2], u)
+	}
+	if !rec.closed {
+		t.Error("Connection isn't closed")
+	}
+	for err := range r.Errors() {
+		t.Errorf("Unexpected error: %v\n", err)
+	}
+}
+
+func TestUnityReporterSlowEmit(t *testing.T) {
+	r := NewUnityReporter("app.desktop")
+	rec := newUnityRecorder(r)
+	reported := make(chan int64, 100)
+	p := New().SetOut(ioutil.Discard).RefreshRate(time.Millisecond).
+		SetReporter(ReporterFunc(func(current, total int64) {
+			r.Report(current, total)
+			select {
+			case reported <- current:
+			default:
+			}
+		}))
+	bar := p.AddBar(100)
+	for i := 0; i < 100; i++ {
+		bar.Incr(1)
+	}
+	// frames keep coming, while the first signal is stuck
+	timeout := time.After(5 * time.Second)
+	for current := int64(0); current != 100; {
+		select {
+		case current = <-reported:
+		case <-timeout:
+			t.Fatal("Frames are blocked by emit")
+		}
+	}
+	close(rec.gate)
+	p.Stop()
+	r.Close()
+	var last unityUpdate
+	for len(rec.updates) > 0 {
+		last = <-rec.updates
+	}
+	if last != (unityUpdate{}) {
+		t.Errorf("Last update want hidden, got: %+v\n", last)
+	}
+}
+
+func TestUnityReporterErrors(t *testing.T) {
+	r := NewUnityReporter("app.desktop")
+	r.dial = func() (unitySignaler, error) {
+		return nil, errors.New("no bus")
+	}
+	r.Report(1, 2)
+	if err := <-r.Errors(); err == nil || err.Error() != "no bus" {
+		t.Errorf("Want error %q, got: %v\n", "no bus", err)
+	}
+	if err := r.Close(); err == nil {
+		t.Error("Close want error, got nil")
+	}
+}
+
+func TestDBusSocketPath(t *testing.T) {
+	tests := map[string]string{
+		"unix:path=/run/user/1000/bus":        "/run/user/1000/bus",
+		"unix:abstract=/tmp/dbus-x,guid=abcd": "@/tmp/dbus-x",
+		"unix:guid=ab,path=/tmp/my%20bus":     "/tmp/my bus",
+	}
+	for addr, want := range tests {
+		if got, err := dbusSocketPath(addr); err != nil || got != want {
+			t.Errorf("%q: want %q, got: %q, %v\n", addr, want, got, err)
+		}
+	}
+	if _, err := dbusSocketPath("tcp:host=localhost,port=1"); err == nil {
+		t.Error("Want error for tcp address")
+	}
+}
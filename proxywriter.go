@@ -0,0 +1,23 @@
+package mpb
+
+import "io"
+
+// Writer is io.Writer wrapper, for proxy written bytes
+type Writer struct {
+	io.Writer
+	bar *Bar
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.bar.Incr(n)
+	return n, err
+}
+
+// Close the writer when it implements io.Closer
+func (w *Writer) Close() error {
+	if closer, ok := w.Writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package mpb
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestProxyReaderWriter(t *testing.T) {
+	content := strings.Repeat("x", 100)
+	p := New().SetOut(ioutil.Discard)
+	read := p.AddBar(int64(len(content)))
+	written := p.AddBar(int64(len(content)))
+
+	var dst bytes.Buffer
+	if _, err := io.Copy(written.ProxyWriter(&dst), read.ProxyReader(strings.NewReader(content))); err != nil {
+		t.Fatal(err)
+	}
+	p.Stop()
+	if dst.String() != content {
+		t.Errorf("Want: %q, Got: %q\n", content, dst.String())
+	}
+	for _, bar := range []*Bar{read, written} {
+		if got := bar.GetStatistics().Current; got != int64(len(content)) {
+			t.Errorf("Want current: %d, Got: %d\n", len(content), got)
+		}
+	}
+}
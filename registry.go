@@ -0,0 +1,51 @@
+package mpb
+
+import (
+	"io"
+	"sync"
+)
+
+// registry holds Progress instances of the application, keyed by writer
+var registry = struct {
+	sync.Mutex
+	m map[io.Writer]*Progress
+}{m: make(map[io.Writer]*Progress)}
+
+// Register makes p discoverable by libraries, which render to w, see Lookup.
+// Usually it is called by the application for os.Stdout or os.Stderr, so
+// dependencies attach bars to its Progress, instead of each running its own
+// render loop. The w must be comparable, i.e. a pointer. Stopped Progress is
+// unregistered automatically.
+func Register(w io.Writer, p *Progress) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.m[w] = p
+}
+
+// Unregister removes Progress, registered for w, if any
+func Unregister(w io.Writer) {
+	registry.Lock()
+	defer registry.Unlock()
+	delete(registry.m, w)
+}
+
+// Lookup returns Progress, registered for w, or nil, if there is none
+func Lookup(w io.Writer) *Progress {
+	registry.Lock()
+	defer registry.Unlock()
+	p := registry.m[w]
+	if p != nil && isClosed(p.done) {
+		delete(registry.m, w)
+		return nil
+	}
+	return p
+}
+
+// LookupOrNew returns Progress, registered for w, or new one, which renders
+// to w. The new one isn't registered, so the caller stops it as usual.
+func LookupOrNew(w io.Writer) (p *Progress, found bool) {
+	if p = Lookup(w); p != nil {
+		return p, true
+	}
+	return New().SetOut(w), false
+}
@@ -0,0 +1,35 @@
+package mpb
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	var buf bytes.Buffer
+	if p := Lookup(&buf); p != nil {
+		t.Fatal("Lookup of unregistered writer returned Progress")
+	}
+	p := New().SetOut(&buf)
+	Register(&buf, p)
+	if got := Lookup(&buf); got != p {
+		t.Errorf("Want registered Progress, got: %p\n", got)
+	}
+	if got, found := LookupOrNew(&buf); got != p || !found {
+		t.Errorf("Want registered Progress, got: %p, found: %t\n", got, found)
+	}
+	p.Stop()
+	if got := Lookup(&buf); got != nil {
+		t.Error("Lookup returned stopped Progress")
+	}
+	other, found := LookupOrNew(&buf)
+	if other == p || found {
+		t.Error("LookupOrNew didn't create new Progress")
+	}
+	Register(&buf, other)
+	Unregister(&buf)
+	if got := Lookup(&buf); got != nil {
+		t.Error("Lookup returned unregistered Progress")
+	}
+	other.Stop()
+}
@@ -0,0 +1,205 @@
+package mpb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/vbauerster/mpb/internal"
+)
+
+// RenderMode defines how frames are written to the output
+type RenderMode int
+
+const (
+	// RenderAuto rewrites frames in place on terminal and appends plain
+	// frames to other writers periodically, see SetAutoRefreshMode. It is
+	// default.
+	RenderAuto RenderMode = iota
+	// RenderPlain always appends every changed plain frame, even to terminal
+	RenderPlain
+	// RenderCarriageReturn rewrites single line with carriage return, without
+	// moving cursor up. It is suitable for notebooks and basic REPLs, where
+	// multi-line ANSI movement is unsupported. Multiple bars are joined into
+	// one line.
+	RenderCarriageReturn
+	// RenderAccessible announces concise progress of every bar, i.e.
+	// "upload: 3 of 10, 30 percent", instead of drawing bars. Announcements
+	// are limited to one per 10 seconds per bar, unless SetPlainThrottle
+	// overrides it. It is friendly to screen readers.
+	RenderAccessible
+)
+
+// accessibleInterval is default interval between announcements of a bar in
+// RenderAccessible mode
+const accessibleInterval = 10 * time.Second
+
+// SetRenderMode sets how frames are written to the output, see RenderMode
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetRenderMode(mode RenderMode) *Progress {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.renderModeCh <- mode:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// withMode adjusts detected capabilities to render mode
+func (t termInfo) withMode(mode RenderMode) termInfo {
+	switch mode {
+	case RenderPlain:
+		t.tty = false
+	case RenderCarriageReturn:
+		t.tty = false
+		t.cr = true
+	case RenderAccessible:
+		t.tty = false
+		t.color = false
+		t.accessible = true
+	}
+	return t
+}
+
+// writeCR rewrites current line with frame, joining its lines. Previous
+// line is overwritten by spaces, as erase sequences may be unsupported.
+func writeCR(out io.Writer, frame []byte, lastFrame *[]byte) error {
+	frame = bytes.Replace(bytes.TrimRight(frame, "\n"), []byte("\n"), []byte(" | "), -1)
+	if bytes.Equal(frame, *lastFrame) {
+		return nil
+	}
+	buf := make([]byte, 0, len(frame)+len(*lastFrame)+1)
+	buf = append(buf, '\r')
+	buf = append(buf, frame...)
+	for pad := visibleRuneCount(*lastFrame) - visibleRuneCount(frame); pad > 0; pad-- {
+		buf = append(buf, ' ')
+	}
+	*lastFrame = append((*lastFrame)[:0], frame...)
+	_, err := out.Write(buf)
+	return err
+}
+
+// accessibleLine returns announcement of bar at index
+func accessibleLine(index int, ibb indexedBarBuffer) []byte {
+	title := groupTitle(index, ibb.name)
+	switch {
+	case ibb.total <= 0:
+		return []byte(fmt.Sprintf("%s: %d\n", title, ibb.current))
+	case ibb.current >= ibb.total:
+		return []byte(fmt.Sprintf("%s: done, %d of %d\n", title, ibb.current, ibb.total))
+	}
+	return []byte(fmt.Sprintf("%s: %d of %d, %d percent\n", title, ibb.current, ibb.total,
+		internal.Percentage(ibb.total, ibb.current, 100)))
+}
+
+// clearCR clears line written by writeCR
+func clearCR(out io.Writer, lastFrame []byte) error {
+	buf := append([]byte{'\r'}, bytes.Repeat([]byte{' '}, visibleRuneCount(lastFrame))...)
+	buf = append(buf, '\r')
+	_, err := out.Write(buf)
+	return err
+}
+
+// Align defines horizontal alignment of the bar block in terminal
+type Align int
+
+const (
+	// AlignLeft is default alignment
+	AlignLeft Align = iota
+	// AlignRight aligns the bar block to the right edge of terminal
+	AlignRight
+	// AlignCenter centers the bar block in terminal
+	AlignCenter
+)
+
+type alignment struct {
+	align    Align
+	maxWidth int
+}
+
+// SetAlign aligns the bar block in terminal. Bars are aligned as a whole, so
+// their columns stay in line. Non-zero maxWidth caps width, bars are drawn
+// in, which keeps bars readable on ultrawide terminals. Alignment needs known
+// terminal width, so it is noop for non-terminal output.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetAlign(align Align, maxWidth int) *Progress {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.alignCh <- alignment{align, maxWidth}:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// alignBlock shifts lines of ibbs by the same offset, so the widest one is
+// aligned in termWidth
+func alignBlock(ibbs []indexedBarBuffer, termWidth int, align Align) {
+	if align == AlignLeft || termWidth <= 0 {
+		return
+	}
+	var blockWidth int
+	for _, ibb := range ibbs {
+		if w := visibleRuneCount(bytes.TrimRight(ibb.buf, "\n")); w > blockWidth {
+			blockWidth = w
+		}
+	}
+	offset := termWidth - blockWidth
+	if align == AlignCenter {
+		offset /= 2
+	}
+	if offset <= 0 {
+		return
+	}
+	pad := bytes.Repeat([]byte{' '}, offset)
+	for i := range ibbs {
+		ibbs[i].buf = append(pad[:offset:offset], ibbs[i].buf...)
+		if len(ibbs[i].details) > 0 {
+			details := bytes.TrimSuffix(ibbs[i].details, []byte("\n"))
+			details = bytes.Replace(details, []byte("\n"), append([]byte("\n"), pad...), -1)
+			ibbs[i].details = append(append(pad[:offset:offset], details...), '\n')
+		}
+	}
+}
+
+// WrapWriter returns writer, which writes to w in between of frames. Route
+// output of the host app, which goes to the same terminal, i.e. os.Stderr,
+// through it, so stray writes don't slice the bars: the bars are erased,
+// the output is written, and the bars are repainted below it. After Stop it
+// writes to w directly.
+func (p *Progress) WrapWriter(w io.Writer) io.Writer {
+	return &foreignWriter{p, w}
+}
+
+type foreignWriter struct {
+	p *Progress
+	w io.Writer
+}
+
+type foreignWrite struct {
+	w      io.Writer
+	b      []byte
+	result chan foreignResult
+}
+
+type foreignResult struct {
+	n   int
+	err error
+}
+
+func (fw *foreignWriter) Write(b []byte) (int, error) {
+	fw.p.acquire()
+	defer fw.p.release()
+	req := &foreignWrite{fw.w, b, make(chan foreignResult, 1)}
+	select {
+	case fw.p.foreignCh <- req:
+		res := <-req.result
+		return res.n, res.err
+	case <-fw.p.done:
+		return fw.w.Write(b)
+	}
+}
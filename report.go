@@ -0,0 +1,109 @@
+package mpb
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+)
+
+// SetHTMLReport sets w, which standalone HTML report of the run is written
+// to on Stop. Report contains timeline of every bar, with its duration, rate
+// and failures, for archiving results of batch jobs. Write error is reported
+// to Errors channel. Nil disables report, which is default.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetHTMLReport(w io.Writer) *Progress {
+	p.acquire()
+	defer p.release()
+	select {
+	case p.reportCh <- w:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// reportRow is a bar in HTML report
+type reportRow struct {
+	Title     string
+	Start     time.Duration
+	Duration  time.Duration
+	Current   int64
+	Total     int64
+	Failed    int64
+	Rate      string
+	Completed bool
+	// Offset and Width of the timeline bar, in percent of the run
+	Offset, Width float64
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Progress report {{.Started.Format "2006-01-02 15:04:05"}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { padding: 0.3em 0.6em; text-align: left; border-bottom: 1px solid #ddd; }
+.timeline { position: relative; min-width: 20em; height: 1em; background: #eee; }
+.span { position: absolute; height: 100%; background: #4a90d9; }
+.incomplete .span { background: #d9a04a; }
+.failed { color: #c00; }
+</style>
+</head>
+<body>
+<h1>Progress report</h1>
+<p>Started {{.Started.Format "2006-01-02 15:04:05"}}, took {{.Duration}}.</p>
+<table>
+<tr><th>Bar</th><th>Start</th><th>Duration</th><th>Progress</th><th>Failed</th><th>Rate</th><th>Status</th><th>Timeline</th></tr>
+{{range .Rows}}<tr{{if not .Completed}} class="incomplete"{{end}}>
+<td>{{.Title}}</td><td>+{{.Start}}</td><td>{{.Duration}}</td><td>{{.Current}}/{{.Total}}</td>
+<td{{if .Failed}} class="failed"{{end}}>{{.Failed}}</td><td>{{.Rate}}</td>
+<td>{{if .Completed}}completed{{else}}incomplete{{end}}</td>
+<td><div class="timeline"><div class="span" style="left: {{printf "%.2f" .Offset}}%; width: {{printf "%.2f" .Width}}%"></div></div></td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// writeHTMLReport writes report of the run, which took from started till
+// stopped. Incomplete bars end at stopped.
+func writeHTMLReport(w io.Writer, started, stopped time.Time, bars []*Bar) error {
+	run := stopped.Sub(started)
+	rows := make([]reportRow, len(bars))
+	for i, b := range bars {
+		s := b.getState()
+		end := stopped
+		if s.completed && s.updated.After(s.timeStarted) {
+			end = s.updated
+		}
+		stat := newStatistics(&s)
+		row := reportRow{
+			Title:     groupTitle(i, barName(&s)),
+			Start:     round(s.timeStarted.Sub(started)),
+			Duration:  round(end.Sub(s.timeStarted)),
+			Current:   s.current,
+			Total:     s.total,
+			Failed:    s.failed,
+			Rate:      fmt.Sprintf("%.1f/s", stat.Rate()),
+			Completed: s.completed,
+		}
+		if run > 0 {
+			row.Offset = 100 * float64(s.timeStarted.Sub(started)) / float64(run)
+			row.Width = 100 * float64(end.Sub(s.timeStarted)) / float64(run)
+		}
+		rows[i] = row
+	}
+	return reportTemplate.Execute(w, struct {
+		Started  time.Time
+		Duration time.Duration
+		Rows     []reportRow
+	}{started, round(run), rows})
+}
+
+// round rounds d to milliseconds
+func round(d time.Duration) time.Duration {
+	return d / time.Millisecond * time.Millisecond
+}
@@ -0,0 +1,38 @@
+package mpb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTMLReport(t *testing.T) {
+	var report bytes.Buffer
+	p := New().SetOut(ioutil.Discard).SetHTMLReport(&report)
+	a := p.AddBar(10).SetMeta("name", "<download>")
+	b := p.AddBar(10)
+	a.IncrFailed(2)
+	a.Incr(8)
+	b.Incr(4)
+	for b.GetStatistics().Current != 4 {
+		time.Sleep(time.Millisecond)
+	}
+	b.Completed()
+	p.Stop()
+	out := report.String()
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<td>&lt;download&gt;</td>",
+		"<td>10/10</td>",
+		`<td class="failed">2</td>`,
+		"<td>bar #1</td>",
+		"<td>4/10</td>",
+		`<tr class="incomplete">`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Report doesn't contain %q:\n%s\n", want, out)
+		}
+	}
+}
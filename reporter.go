@@ -0,0 +1,62 @@
+package mpb
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/vbauerster/mpb/internal"
+)
+
+// Reporter receives aggregate progress of all bars, after each rendered frame.
+// It is useful to map progress onto desktop integration surfaces, like
+// application icon progress in launchers and taskbars.
+// If Reporter also implements io.Closer, Close is called once, when
+// Progress' goroutine quits.
+type Reporter interface {
+	Report(current, total int64)
+}
+
+// ReporterFunc is an adapter to allow the use of ordinary functions as Reporter.
+type ReporterFunc func(current, total int64)
+
+// Report calls f(current, total)
+func (f ReporterFunc) Report(current, total int64) {
+	f(current, total)
+}
+
+// ErrTaskbarUnsupported is returned by NewWindowsTaskbarReporter, if taskbar
+// progress isn't available, i.e. on other platforms
+var ErrTaskbarUnsupported = errors.New("mpb: taskbar progress isn't supported")
+
+// TaskbarReporter reports progress via "OSC 9;4" escape sequence, which is
+// understood by Windows Terminal and ConEmu, to show progress on the taskbar.
+// See also UnityReporter and NewWindowsTaskbarReporter.
+type TaskbarReporter struct {
+	w       io.Writer
+	percent int
+}
+
+// NewTaskbarReporter creates TaskbarReporter, which writes to w
+func NewTaskbarReporter(w io.Writer) *TaskbarReporter {
+	return &TaskbarReporter{w: w, percent: -1}
+}
+
+// Report writes progress sequence, only if percentage has changed
+func (r *TaskbarReporter) Report(current, total int64) {
+	if total <= 0 {
+		return
+	}
+	p := internal.Percentage(total, current, 100)
+	if p == r.percent {
+		return
+	}
+	r.percent = p
+	fmt.Fprintf(r.w, "%c]9;4;1;%d%c", 27, p, 7)
+}
+
+// Close removes progress from the taskbar
+func (r *TaskbarReporter) Close() error {
+	_, err := fmt.Fprintf(r.w, "%c]9;4;0;0%c", 27, 7)
+	return err
+}
@@ -0,0 +1,10 @@
+// +build !windows
+
+package mpb
+
+// NewWindowsTaskbarReporter creates Reporter, which shows progress on the
+// taskbar button of the console window via ITaskbarList3. It returns
+// ErrTaskbarUnsupported on other platforms.
+func NewWindowsTaskbarReporter() (Reporter, error) {
+	return nil, ErrTaskbarUnsupported
+}
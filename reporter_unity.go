@@ -0,0 +1,392 @@
+package mpb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vbauerster/mpb/internal"
+)
+
+// UnityReporter reports progress via Unity LauncherEntry API, which is
+// understood by Ubuntu Dock, KDE Plasma task manager, Dash to Dock and
+// Plank, to show progress on the application icon.
+//
+// Signals are emitted by a single worker goroutine over one session bus
+// connection, which lives until Close, as docks drop the entry, once its
+// sender is gone. Report never waits for the bus: if the worker is busy, only
+// the latest progress is emitted after it. Failures are delivered via Errors.
+type UnityReporter struct {
+	appURI  string
+	percent int
+
+	mu      sync.Mutex
+	next    *unityUpdate
+	started bool
+	wake    chan struct{}
+	quit    chan struct{}
+	done    chan struct{}
+	errs    chan error
+	err     error // of the last emitted update
+	// dial connects to session bus, it's overridden in tests
+	dial func() (unitySignaler, error)
+}
+
+// unityUpdate is a set of LauncherEntry properties to emit
+type unityUpdate struct {
+	progress float64
+	visible  bool
+}
+
+type unitySignaler interface {
+	update(appURI string, u unityUpdate) error
+	Close() error
+}
+
+// NewUnityReporter creates UnityReporter for application, identified by its
+// desktop file id, i.e. "org.gnome.Terminal.desktop"
+func NewUnityReporter(desktopID string) *UnityReporter {
+	return &UnityReporter{
+		appURI:  "application://" + desktopID,
+		percent: -1,
+		wake:    make(chan struct{}, 1),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+		errs:    make(chan error, 1),
+		dial:    dialSessionBus,
+	}
+}
+
+// Report queues progress signal, only if percentage has changed
+func (r *UnityReporter) Report(current, total int64) {
+	if total <= 0 {
+		return
+	}
+	p := internal.Percentage(total, current, 100)
+	if p == r.percent {
+		return
+	}
+	r.percent = p
+	r.post(&unityUpdate{progress: float64(p) / 100, visible: true})
+}
+
+// Errors returns channel, which receives failures to connect to session bus
+// or to emit a signal. It is closed by Close. If the channel isn't drained,
+// further failures are dropped.
+func (r *UnityReporter) Errors() <-chan error {
+	return r.errs
+}
+
+// Close removes progress from the application icon, waits for the worker to
+// emit it and closes bus connection
+func (r *UnityReporter) Close() error {
+	r.mu.Lock()
+	started := r.started
+	r.started = true
+	r.mu.Unlock()
+	if !started {
+		// nothing has been shown
+		close(r.errs)
+		return nil
+	}
+	r.post(&unityUpdate{})
+	close(r.quit)
+	<-r.done
+	return r.err
+}
+
+// post replaces pending update with u, starting the worker on first use
+func (r *UnityReporter) post(u *unityUpdate) {
+	r.mu.Lock()
+	r.next = u
+	if !r.started {
+		r.started = true
+		go r.run()
+	}
+	r.mu.Unlock()
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (r *UnityReporter) run() {
+	var conn unitySignaler
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+		close(r.errs)
+		close(r.done)
+	}()
+	for {
+		select {
+		case <-r.wake:
+			r.flush(&conn)
+		case <-r.quit:
+			r.flush(&conn)
+			return
+		}
+	}
+}
+
+// flush emits pending update, if any, and keeps its result for Close.
+// Connection is dropped on failure and redialed by the next flush.
+func (r *UnityReporter) flush(conn *unitySignaler) {
+	r.mu.Lock()
+	u := r.next
+	r.next = nil
+	r.mu.Unlock()
+	if u == nil {
+		return
+	}
+	var err error
+	if *conn == nil {
+		*conn, err = r.dial()
+	}
+	if err == nil {
+		err = (*conn).update(r.appURI, *u)
+		if err != nil {
+			(*conn).Close()
+		}
+	}
+	if err != nil {
+		*conn = nil
+		select {
+		case r.errs <- err:
+		default:
+		}
+	}
+	r.err = err
+}
+
+// dbusConn is a minimal D-Bus client, which can only emit LauncherEntry
+// signals. Messages from the bus are discarded.
+type dbusConn struct {
+	conn   net.Conn
+	serial uint32
+}
+
+// dbusTimeout bounds each write, so stalled bus can't hold Close forever
+const dbusTimeout = 5 * time.Second
+
+func dialSessionBus() (unitySignaler, error) {
+	addr := os.Getenv("DBUS_SESSION_BUS_ADDRESS")
+	if addr == "" {
+		if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+			addr = "unix:path=" + dir + "/bus"
+		}
+	}
+	var err error = errors.New("mpb: session bus address isn't set")
+	for _, a := range strings.Split(addr, ";") {
+		var path string
+		if path, err = dbusSocketPath(a); err != nil {
+			continue
+		}
+		var c net.Conn
+		if c, err = net.DialTimeout("unix", path, dbusTimeout); err != nil {
+			continue
+		}
+		d := &dbusConn{conn: c}
+		if err = d.handshake(); err != nil {
+			c.Close()
+			continue
+		}
+		go io.Copy(ioutil.Discard, c)
+		return d, nil
+	}
+	return nil, err
+}
+
+// dbusSocketPath extracts socket path from unix transport address
+func dbusSocketPath(addr string) (string, error) {
+	if !strings.HasPrefix(addr, "unix:") {
+		return "", fmt.Errorf("mpb: unsupported bus address %q", addr)
+	}
+	for _, kv := range strings.Split(addr[len("unix:"):], ",") {
+		switch {
+		case strings.HasPrefix(kv, "path="):
+			return dbusUnescape(kv[len("path="):])
+		case strings.HasPrefix(kv, "abstract="):
+			path, err := dbusUnescape(kv[len("abstract="):])
+			return "@" + path, err
+		}
+	}
+	return "", fmt.Errorf("mpb: unsupported bus address %q", addr)
+}
+
+// dbusUnescape decodes %XX escapes of address value
+func dbusUnescape(s string) (string, error) {
+	if !strings.Contains(s, "%") {
+		return s, nil
+	}
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			b = append(b, s[i])
+			continue
+		}
+		if i+2 >= len(s) {
+			return "", fmt.Errorf("mpb: bad escape in bus address %q", s)
+		}
+		c, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("mpb: bad escape in bus address %q", s)
+		}
+		b = append(b, byte(c))
+		i += 2
+	}
+	return string(b), nil
+}
+
+// handshake authenticates as current user and sends Hello, which registers
+// the connection on the bus
+func (d *dbusConn) handshake() error {
+	d.conn.SetDeadline(time.Now().Add(dbusTimeout))
+	defer d.conn.SetDeadline(time.Time{})
+	uid := fmt.Sprintf("%x", strconv.Itoa(os.Getuid()))
+	if _, err := fmt.Fprintf(d.conn, "\x00AUTH EXTERNAL %s\r\n", uid); err != nil {
+		return err
+	}
+	line, err := bufio.NewReader(io.LimitReader(d.conn, 512)).ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "OK ") {
+		return fmt.Errorf("mpb: bus authentication failed: %q", strings.TrimSpace(line))
+	}
+	if _, err := io.WriteString(d.conn, "BEGIN\r\n"); err != nil {
+		return err
+	}
+	m := d.message(1, []dbusField{
+		{1, "o", "/org/freedesktop/DBus"},
+		{2, "s", "org.freedesktop.DBus"},
+		{3, "s", "Hello"},
+		{6, "s", "org.freedesktop.DBus"},
+	})
+	_, err = d.conn.Write(m.finish())
+	return err
+}
+
+func (d *dbusConn) update(appURI string, u unityUpdate) error {
+	m := d.message(4, []dbusField{
+		{1, "o", "/"},
+		{2, "s", "com.canonical.Unity.LauncherEntry"},
+		{3, "s", "Update"},
+		{8, "g", "sa{sv}"},
+	})
+	m.str(appURI)
+	m.array(func() {
+		if u.visible {
+			m.align(8)
+			m.str("progress")
+			m.sig("d")
+			m.align(8)
+			m.b = appendUint64(m.b, math.Float64bits(u.progress))
+		}
+		m.align(8)
+		m.str("progress-visible")
+		m.sig("b")
+		var v uint32
+		if u.visible {
+			v = 1
+		}
+		m.u32(v)
+	})
+	d.conn.SetWriteDeadline(time.Now().Add(dbusTimeout))
+	_, err := d.conn.Write(m.finish())
+	return err
+}
+
+func (d *dbusConn) Close() error {
+	return d.conn.Close()
+}
+
+// dbusField is a message header field with string-like value
+type dbusField struct {
+	code  byte
+	sig   string
+	value string
+}
+
+// message starts little endian message of type typ with header fields
+func (d *dbusConn) message(typ byte, fields []dbusField) *dbusMessage {
+	d.serial++
+	m := &dbusMessage{b: []byte{'l', typ, 0, 1}}
+	m.u32(0) // body length, set by finish
+	m.u32(d.serial)
+	m.array(func() {
+		for _, f := range fields {
+			m.align(8)
+			m.b = append(m.b, f.code)
+			m.sig(f.sig)
+			if f.sig == "g" {
+				m.sig(f.value)
+			} else {
+				m.str(f.value)
+			}
+		}
+	})
+	m.align(8)
+	m.body = len(m.b)
+	return m
+}
+
+type dbusMessage struct {
+	b    []byte
+	body int
+}
+
+func (m *dbusMessage) align(n int) {
+	for len(m.b)%n != 0 {
+		m.b = append(m.b, 0)
+	}
+}
+
+func (m *dbusMessage) u32(v uint32) {
+	m.align(4)
+	m.b = append(m.b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func (m *dbusMessage) str(s string) {
+	m.u32(uint32(len(s)))
+	m.b = append(m.b, s...)
+	m.b = append(m.b, 0)
+}
+
+func (m *dbusMessage) sig(s string) {
+	m.b = append(m.b, byte(len(s)))
+	m.b = append(m.b, s...)
+	m.b = append(m.b, 0)
+}
+
+// array writes array of 8 aligned elements, written by f
+func (m *dbusMessage) array(f func()) {
+	m.u32(0)
+	at := len(m.b) - 4
+	m.align(8)
+	start := len(m.b)
+	f()
+	binary.LittleEndian.PutUint32(m.b[at:], uint32(len(m.b)-start))
+}
+
+func (m *dbusMessage) finish() []byte {
+	binary.LittleEndian.PutUint32(m.b[4:], uint32(len(m.b)-m.body))
+	return m.b
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
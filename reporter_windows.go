@@ -0,0 +1,118 @@
+// +build windows
+
+package mpb
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"github.com/vbauerster/mpb/internal"
+)
+
+var (
+	ole32                = syscall.NewLazyDLL("ole32.dll")
+	procCoInitializeEx   = ole32.NewProc("CoInitializeEx")
+	procCoCreateInstance = ole32.NewProc("CoCreateInstance")
+	procGetConsoleWindow = syscall.NewLazyDLL("kernel32.dll").NewProc("GetConsoleWindow")
+)
+
+type guid struct {
+	data1 uint32
+	data2 uint16
+	data3 uint16
+	data4 [8]byte
+}
+
+var (
+	clsidTaskbarList = guid{0x56FDF344, 0xFD6D, 0x11D0, [8]byte{0x95, 0x8A, 0x00, 0x60, 0x97, 0xC9, 0xA0, 0x90}}
+	iidTaskbarList3  = guid{0xEA1AFB91, 0x9E28, 0x4B86, [8]byte{0x90, 0xE9, 0x9E, 0x9F, 0x8A, 0x5E, 0xEF, 0xAF}}
+)
+
+const (
+	coinitMultithreaded = 0
+	clsctxInprocServer  = 1
+	tbpfNoProgress      = 0
+	tbpfNormal          = 2
+)
+
+// vtable indices of ITaskbarList3 methods, which follow IUnknown,
+// ITaskbarList and ITaskbarList2 ones
+const (
+	vtRelease          = 2
+	vtHrInit           = 3
+	vtSetProgressValue = 9
+	vtSetProgressState = 10
+)
+
+// windowsTaskbar reports progress to the taskbar button of the console window
+type windowsTaskbar struct {
+	obj     unsafe.Pointer // *ITaskbarList3
+	hwnd    uintptr
+	percent int
+}
+
+// NewWindowsTaskbarReporter creates Reporter, which shows progress on the
+// taskbar button of the console window via ITaskbarList3. It returns
+// ErrTaskbarUnsupported, if the process has no console window, i.e. runs
+// within Windows Terminal, see TaskbarReporter for that.
+func NewWindowsTaskbarReporter() (Reporter, error) {
+	hwnd, _, _ := procGetConsoleWindow.Call()
+	if hwnd == 0 {
+		return nil, ErrTaskbarUnsupported
+	}
+	// S_FALSE and RPC_E_CHANGED_MODE mean COM is initialized already
+	procCoInitializeEx.Call(0, coinitMultithreaded)
+	var obj unsafe.Pointer
+	hr, _, _ := procCoCreateInstance.Call(
+		uintptr(unsafe.Pointer(&clsidTaskbarList)), 0, clsctxInprocServer,
+		uintptr(unsafe.Pointer(&iidTaskbarList3)), uintptr(unsafe.Pointer(&obj)))
+	if int32(hr) < 0 || obj == nil {
+		return nil, fmt.Errorf("mpb: taskbar: CoCreateInstance: %#x", uint32(hr))
+	}
+	t := &windowsTaskbar{obj: obj, hwnd: hwnd, percent: -1}
+	if hr := t.call(vtHrInit); int32(hr) < 0 {
+		t.call(vtRelease)
+		return nil, fmt.Errorf("mpb: taskbar: HrInit: %#x", uint32(hr))
+	}
+	return t, nil
+}
+
+// Report sets progress value, only if percentage has changed
+func (t *windowsTaskbar) Report(current, total int64) {
+	if total <= 0 {
+		return
+	}
+	p := internal.Percentage(total, current, 100)
+	if p == t.percent {
+		return
+	}
+	if t.percent < 0 {
+		t.call(vtSetProgressState, t.hwnd, tbpfNormal)
+	}
+	t.percent = p
+	// ULONGLONG arguments take two words on 32-bit platforms
+	if unsafe.Sizeof(uintptr(0)) == 8 {
+		t.call(vtSetProgressValue, t.hwnd, uintptr(p), 100)
+	} else {
+		t.call(vtSetProgressValue, t.hwnd, uintptr(p), 0, 100, 0)
+	}
+}
+
+// Close removes progress from the taskbar button
+func (t *windowsTaskbar) Close() error {
+	t.call(vtSetProgressState, t.hwnd, tbpfNoProgress)
+	t.call(vtRelease)
+	return nil
+}
+
+// call calls method of ITaskbarList3 at vtable index
+func (t *windowsTaskbar) call(index uintptr, args ...uintptr) uintptr {
+	vtbl := *(*unsafe.Pointer)(t.obj)
+	method := *(*uintptr)(unsafe.Pointer(uintptr(vtbl) + index*unsafe.Sizeof(uintptr(0))))
+	var a [6]uintptr
+	a[0] = uintptr(t.obj)
+	copy(a[1:], args)
+	r, _, _ := syscall.Syscall6(method, uintptr(len(args)+1), a[0], a[1], a[2], a[3], a[4], a[5])
+	return r
+}
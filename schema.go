@@ -0,0 +1,87 @@
+package mpb
+
+// ColumnSchema describes decorator columns of bars, as they're laid out in
+// terminal. Columns are labeled by their ids, see (*Bar).PrependColumn.
+// Column without id has empty label. Exporters use it to label columns
+// consistently with terminal.
+type ColumnSchema struct {
+	Prepend []string `json:"prepend"`
+	Append  []string `json:"append"`
+}
+
+// ColumnSchema returns columns declared by SetColumns. If there are no
+// declared columns, they're derived from decorators of the bars in
+// attachment order.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) ColumnSchema() ColumnSchema {
+	p.acquire()
+	defer p.release()
+	ch := make(chan *config, 1)
+	select {
+	case p.configReqCh <- ch:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	cfg := <-ch
+	schema := ColumnSchema{
+		Prepend: cfg.columns[0],
+		Append:  cfg.columns[1],
+	}
+	if schema.Prepend != nil && schema.Append != nil {
+		return schema
+	}
+	var prepends, appends [][]string
+	for _, b := range cfg.bars {
+		s := b.getState()
+		columns := s.columnSchema()
+		prepends = append(prepends, columns.Prepend)
+		appends = append(appends, columns.Append)
+	}
+	if schema.Prepend == nil {
+		schema.Prepend = mergeLabels(prepends)
+	}
+	if schema.Append == nil {
+		schema.Append = mergeLabels(appends)
+	}
+	return schema
+}
+
+// columnSchema returns columns of the bar, as they're drawn, see
+// prependColumns. Their count is the number of columns to width sync.
+func (s *state) columnSchema() ColumnSchema {
+	schema := ColumnSchema{
+		Prepend: columnLabels(s.prependOrder, s.prependIDs, len(s.prependFuncs)),
+		Append:  columnLabels(s.appendOrder, s.appendIDs, len(s.appendFuncs)),
+	}
+	if s.nameColumn != nil {
+		schema.Prepend = append([]string{"name"}, schema.Prepend...)
+	}
+	return schema
+}
+
+// columnLabels returns ids aligned with n decorators, or declared order, like
+// arrangeColumns does
+func columnLabels(order, ids []string, n int) []string {
+	if order != nil {
+		return order
+	}
+	labels := make([]string, n)
+	copy(labels, ids)
+	return labels
+}
+
+// mergeLabels merges labels of the bars by position, the first non-empty
+// label wins
+func mergeLabels(bars [][]string) []string {
+	merged := []string{}
+	for _, labels := range bars {
+		for i, label := range labels {
+			if i == len(merged) {
+				merged = append(merged, label)
+			} else if merged[i] == "" {
+				merged[i] = label
+			}
+		}
+	}
+	return merged
+}
@@ -0,0 +1,58 @@
+package mpb
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestColumnSchema(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	a := p.AddBar(10).PrependColumn("name", Name("a", 0, 0)).AppendPercentage(0, 0)
+	b := p.AddBar(10).PrependName("b", 0, 0).PrependColumn("eta", ETA(0, 0)).
+		AppendColumn("percent", Percentage(0, 0))
+
+	want := ColumnSchema{
+		Prepend: []string{"name", "eta"},
+		Append:  []string{"percent"},
+	}
+	if got := p.ColumnSchema(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Derived: want %q, got %q\n", want, got)
+	}
+
+	p.SetColumns([]string{"eta", "name"}, nil)
+	want.Prepend = []string{"eta", "name"}
+	if got := p.ColumnSchema(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Declared: want %q, got %q\n", want, got)
+	}
+
+	a.Completed()
+	b.Completed()
+	p.Stop()
+}
+
+func TestStateColumnSchema(t *testing.T) {
+	s := newTestState()
+	s.nameColumn = &nameColumn{}
+	s.prependFuncs = []BytesDecoratorFunc{NameBytes("a", 0, 0), NameBytes("b", 0, 0)}
+	s.prependIDs = []string{"a"}
+	s.appendFuncs = []BytesDecoratorFunc{PercentageBytes(0, 0)}
+	s.appendIDs = []string{"percent"}
+	s.appendOrder = []string{"eta", "percent", "size"}
+
+	columns := s.columnSchema()
+	want := ColumnSchema{
+		Prepend: []string{"name", "a", ""},
+		Append:  []string{"eta", "percent", "size"},
+	}
+	if !reflect.DeepEqual(columns, want) {
+		t.Errorf("Want %q, got %q\n", want, columns)
+	}
+	// column count is what draw syncs
+	prependFuncs, _ := s.prependColumns()
+	appendFuncs, _ := arrangeColumns(s.appendOrder, s.appendIDs, s.appendFuncs, nil)
+	if len(columns.Prepend) != len(prependFuncs) || len(columns.Append) != len(appendFuncs) {
+		t.Errorf("Want %d/%d columns, got %d/%d\n", len(prependFuncs), len(appendFuncs),
+			len(columns.Prepend), len(columns.Append))
+	}
+}
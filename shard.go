@@ -0,0 +1,43 @@
+package mpb
+
+import "io"
+
+// Shard returns new Progress instance, which renders its bars to w. Width of
+// w is detected independently of p, so subsets of bars may be routed to
+// different terminals, i.e. tmux panes or FIFOs. Width, format, refresh rate
+// and cancellation are inherited from p, the rest is configured on the shard
+// as usual. Stop of p stops its shards as well, waiting for their bars.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) Shard(w io.Writer) *Progress {
+	p.acquire()
+	defer p.release()
+	ch := make(chan *config, 1)
+	select {
+	case p.configReqCh <- ch:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	cfg := <-ch
+	shard := New()
+	shard.cancel = p.cancel
+	shard.width = p.width
+	shard.SetOut(w).Format(cfg.format).RefreshRate(cfg.refreshRate)
+
+	h := p.srv
+	h.mu.Lock()
+	h.shards = append(h.shards, shard)
+	h.mu.Unlock()
+	return shard
+}
+
+// stopShards stops shards of p, see Shard
+func (p *Progress) stopShards() {
+	h := p.srv
+	h.mu.Lock()
+	shards := h.shards
+	h.shards = nil
+	h.mu.Unlock()
+	for _, shard := range shards {
+		shard.Stop()
+	}
+}
@@ -0,0 +1,32 @@
+package mpb
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestShard(t *testing.T) {
+	var bufA, bufB bytes.Buffer
+	p := New().SetOut(&bufA).SetWidth(20)
+	shard := p.Shard(&bufB)
+	a := p.AddBar(10).PrependName("alpha", 0, 0)
+	b := shard.AddBar(10).PrependName("beta", 0, 0)
+	a.Incr(10)
+	b.Incr(10)
+	p.Stop()
+
+	if !isClosed(shard.done) {
+		t.Error("Shard isn't stopped along with Progress")
+	}
+	outA, outB := bufA.String(), bufB.String()
+	if !strings.Contains(outA, "alpha") || strings.Contains(outA, "beta") {
+		t.Errorf("Unexpected output of Progress: %q\n", outA)
+	}
+	if !strings.Contains(outB, "beta") || strings.Contains(outB, "alpha") {
+		t.Errorf("Unexpected output of shard: %q\n", outB)
+	}
+	if !strings.Contains(outB, "beta ["+strings.Repeat("=", 12)+"]") {
+		t.Errorf("Shard doesn't inherit width: %q\n", outB)
+	}
+}
@@ -0,0 +1,138 @@
+package mpb
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/vbauerster/mpb/cwriter"
+)
+
+// Renderer composes frames of several Progress instances into a single block
+// on one output, so independent instances, i.e. of different libraries in
+// the same process, don't corrupt each other's output. Each instance renders
+// to its own region, see Writer.
+type Renderer struct {
+	mu      sync.Mutex
+	out     io.Writer
+	cw      *cwriter.Writer
+	tty     bool
+	regions []*sharedWriter
+	last    []byte
+}
+
+var (
+	sharedOnce     sync.Once
+	sharedRenderer *Renderer
+)
+
+// SharedRenderer returns process wide Renderer, which writes to os.Stdout
+func SharedRenderer() *Renderer {
+	sharedOnce.Do(func() {
+		sharedRenderer = NewRenderer(os.Stdout)
+	})
+	return sharedRenderer
+}
+
+// NewRenderer returns Renderer, which writes to w
+func NewRenderer(w io.Writer) *Renderer {
+	return &Renderer{
+		out: w,
+		cw:  cwriter.New(w),
+		tty: cwriter.IsTerminal(w),
+	}
+}
+
+// Writer returns a new region of the block, which is meant to be passed to
+// (*Progress).SetOut. Regions are rendered in order of creation. Lines,
+// printed by (*Progress).Println, are written above the whole block.
+// Non-terminal output gets the whole block appended, so plain output options
+// of Progress, i.e. SetPlainThrottle, don't apply to the region.
+// Closing the returned writer releases the region, which is meant to be done,
+// once its Progress has stopped.
+func (r *Renderer) Writer() io.WriteCloser {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	w := &sharedWriter{r: r}
+	r.regions = append(r.regions, w)
+	return w
+}
+
+// render writes the block. Terminal gets it rewritten in place, other
+// writers get it appended, only if it differs from the last one.
+func (r *Renderer) render() error {
+	var block bytes.Buffer
+	for _, w := range r.regions {
+		block.Write(w.frame)
+	}
+	if r.tty {
+		r.cw.Write(block.Bytes())
+		return r.cw.Flush()
+	}
+	if bytes.Equal(block.Bytes(), r.last) {
+		return nil
+	}
+	r.last = append(r.last[:0], block.Bytes()...)
+	_, err := r.out.Write(block.Bytes())
+	return err
+}
+
+// sharedWriter is a region of Renderer. Every write replaces its frame.
+type sharedWriter struct {
+	r      *Renderer
+	frame  []byte
+	closed bool
+}
+
+func (w *sharedWriter) Write(frame []byte) (int, error) {
+	w.r.mu.Lock()
+	defer w.r.mu.Unlock()
+	if w.closed {
+		return len(frame), nil
+	}
+	w.frame = append(w.frame[:0], frame...)
+	return len(frame), w.r.render()
+}
+
+// Close releases the region, so it's no longer rendered within the block.
+// Terminal gets the last frame of the region written above the block, so it
+// stays on the screen. Writes after Close are discarded. It is safe to call
+// Close more than once.
+func (w *sharedWriter) Close() error {
+	w.r.mu.Lock()
+	defer w.r.mu.Unlock()
+	for i, region := range w.r.regions {
+		if region != w {
+			continue
+		}
+		w.r.regions = append(w.r.regions[:i], w.r.regions[i+1:]...)
+		w.closed = true
+		if w.r.tty && len(w.frame) > 0 {
+			w.r.cw.WriteLog(w.frame)
+		}
+		w.frame = nil
+		return w.r.render()
+	}
+	return nil
+}
+
+// Fd makes size of the shared terminal available to Progress
+func (w *sharedWriter) Fd() uintptr {
+	if f, ok := w.r.out.(cwriter.FdWriter); ok {
+		return f.Fd()
+	}
+	return ^uintptr(0)
+}
+
+// log writes line above the block
+func (w *sharedWriter) log(line []byte) error {
+	w.r.mu.Lock()
+	defer w.r.mu.Unlock()
+	if !w.r.tty {
+		_, err := w.r.out.Write(line)
+		return err
+	}
+	w.r.cw.WriteLog(line)
+	return w.r.render()
+}
@@ -0,0 +1,62 @@
+package mpb
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRenderer(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRenderer(&buf)
+	p1 := New().SetOut(r.Writer()).SetWidth(10)
+	p2 := New().SetOut(r.Writer()).SetWidth(10)
+	a := p1.AddBar(10)
+	b := p2.AddBar(10)
+	a.Incr(10)
+	p1.Stop()
+	p2.Println("log line")
+	b.Incr(10)
+	p2.Stop()
+	out := buf.String()
+	if !strings.Contains(out, "log line\n") {
+		t.Errorf("Log line not found in: %q\n", out)
+	}
+	want := " [======] \n [======] \n"
+	if !strings.HasSuffix(out, want) {
+		t.Errorf("Want suffix %q, got: %q\n", want, out)
+	}
+}
+
+func TestRendererClose(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRenderer(&buf)
+	w1 := r.Writer()
+	p1 := New().SetOut(w1).SetWidth(10)
+	p2 := New().SetOut(r.Writer()).SetWidth(10)
+	p1.AddBar(10).Incr(10)
+	p1.Wait()
+	if err := w1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.regions) != 1 {
+		t.Errorf("Want 1 region, got: %d\n", len(r.regions))
+	}
+	b := p2.AddBar(10)
+	b.Incr(5)
+	b.Incr(5)
+	p2.Wait()
+	// the released region isn't in the block anymore
+	if want := " [======] \n"; string(r.last) != want {
+		t.Errorf("Want last block %q, got: %q\n", want, r.last)
+	}
+}
+
+func TestSharedRenderer(t *testing.T) {
+	if SharedRenderer() != SharedRenderer() {
+		t.Error("SharedRenderer isn't process wide")
+	}
+}
@@ -0,0 +1,50 @@
+package mpb
+
+// SchemaVersion is version of JSON schema of BarSnapshot, Statistics and
+// ColumnSchema. Within a version fields are only added, never renamed or
+// removed, so external consumers may rely on it across releases.
+const SchemaVersion = 1
+
+// BarSnapshot is a point in time copy of bar's progress. Fields of Statistics
+// are inlined in JSON encoding.
+type BarSnapshot struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Statistics
+	// Rate is estimated rate in items per second
+	Rate      float64 `json:"rate"`
+	Completed bool    `json:"completed"`
+}
+
+// Snapshot returns copy of statistics of all bars in the container, without
+// touching the renderer. It is cheap enough to be polled by health endpoints
+// and schedulers. Name is taken from bar.Meta("name").
+// Pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) Snapshot() []BarSnapshot {
+	p.acquire()
+	defer p.release()
+	respCh := make(chan []*Bar, 1)
+	select {
+	case p.barsReqCh <- respCh:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return snapshots(<-respCh)
+}
+
+func snapshots(bars []*Bar) []BarSnapshot {
+	snapshots := make([]BarSnapshot, len(bars))
+	for i, b := range bars {
+		s := b.getState()
+		stat := newStatistics(&s)
+		name, _ := s.meta["name"].(string)
+		snapshots[i] = BarSnapshot{
+			ID:         s.id,
+			Name:       name,
+			Statistics: *stat,
+			Rate:       stat.Rate(),
+			Completed:  s.completed,
+		}
+	}
+	return snapshots
+}
@@ -0,0 +1,62 @@
+package mpb
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	a := p.AddBarWithID(1, 10).SetMeta("name", "a")
+	b := p.AddBarWithID(2, 20)
+	a.Incr(10)
+	b.Incr(5)
+	for a.InProgress() || b.GetStatistics().Current != 5 {
+		time.Sleep(time.Millisecond)
+	}
+	snapshots := p.Snapshot()
+	if len(snapshots) != 2 {
+		t.Fatalf("Want snapshots: %d, Got: %d\n", 2, len(snapshots))
+	}
+	if got := snapshots[0]; got.ID != 1 || got.Name != "a" || !got.Completed {
+		t.Errorf("Unexpected snapshot: %+v\n", got)
+	}
+	if got := snapshots[1]; got.ID != 2 || got.Current != 5 || got.Total != 20 || got.Completed {
+		t.Errorf("Unexpected snapshot: %+v\n", got)
+	}
+	b.Completed()
+	p.Stop()
+}
+
+func TestSnapshotJSON(t *testing.T) {
+	s := BarSnapshot{
+		ID:         3,
+		Name:       "a",
+		Statistics: Statistics{Total: 10, Current: 4, TimeElapsed: time.Second},
+		Rate:       2,
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"id":           float64(3),
+		"name":         "a",
+		"total":        float64(10),
+		"current":      float64(4),
+		"time_elapsed": float64(time.Second),
+		"rate":         float64(2),
+		"completed":    false,
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("Field %q want: %v, got: %v\n", k, v, fields[k])
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package mpb
+
+import (
+	"sort"
+
+	"github.com/vbauerster/mpb/internal"
+)
+
+// BarLess reports whether bar with statistics a should be rendered before
+// bar with statistics b. It is used with (*Progress).SortBy.
+type BarLess func(a, b *Statistics) bool
+
+// SortByPriority renders bars with higher priority first
+func SortByPriority(a, b *Statistics) bool {
+	return a.Priority > b.Priority
+}
+
+// SortByPercentage renders most complete bars first
+func SortByPercentage(a, b *Statistics) bool {
+	return internal.Percentage(a.Total, a.Current, 100) > internal.Percentage(b.Total, b.Current, 100)
+}
+
+// SortByRate renders fastest bars first. Bars without rate estimate yet
+// are rendered last.
+func SortByRate(a, b *Statistics) bool {
+	if a.TimePerItemEstimate <= 0 {
+		return false
+	}
+	if b.TimePerItemEstimate <= 0 {
+		return true
+	}
+	return a.TimePerItemEstimate < b.TimePerItemEstimate
+}
+
+// SortCompletedLast renders completed bars after bars in progress
+func SortCompletedLast(a, b *Statistics) bool {
+	return !isCompleted(a) && isCompleted(b)
+}
+
+// SortRecentlyUpdated renders recently incremented bars first
+func SortRecentlyUpdated(a, b *Statistics) bool {
+	return a.Updated.After(b.Updated)
+}
+
+func isCompleted(s *Statistics) bool {
+	return s.Total > 0 && s.Current >= s.Total
+}
+
+type barSorter struct {
+	bars  []*Bar
+	stats []*Statistics
+	less  BarLess
+}
+
+func (bs *barSorter) Len() int { return len(bs.bars) }
+
+func (bs *barSorter) Less(i, j int) bool { return bs.less(bs.stats[i], bs.stats[j]) }
+
+func (bs *barSorter) Swap(i, j int) {
+	bs.bars[i], bs.bars[j] = bs.bars[j], bs.bars[i]
+	bs.stats[i], bs.stats[j] = bs.stats[j], bs.stats[i]
+}
+
+// reverseBars returns reversed copy of bars
+func reverseBars(bars []*Bar) []*Bar {
+	result := make([]*Bar, len(bars))
+	for i, b := range bars {
+		result[len(bars)-1-i] = b
+	}
+	return result
+}
+
+// insertByPriority inserts b after bars with the same or higher priority.
+// Bars are kept in priority order this way, with ties in insertion order.
+// It must be called in Progress' goroutine, which owns (*Bar).priority.
+func insertByPriority(bars []*Bar, b *Bar, priority int) []*Bar {
+	b.priority = priority
+	i := len(bars)
+	for i > 0 && bars[i-1].priority < priority {
+		i--
+	}
+	bars = append(bars, nil)
+	copy(bars[i+1:], bars[i:])
+	bars[i] = b
+	return bars
+}
+
+// sortBars returns sorted copy of bars. Sort is stable, so bars which are
+// equal keep their insertion order. Pinned bars keep their positions.
+func sortBars(bars []*Bar, less BarLess) []*Bar {
+	result := make([]*Bar, len(bars))
+	bs := &barSorter{less: less}
+	var unpinned []int
+	for i, b := range bars {
+		s := b.GetStatistics()
+		if s.Pinned {
+			result[i] = b
+			continue
+		}
+		unpinned = append(unpinned, i)
+		bs.bars = append(bs.bars, b)
+		bs.stats = append(bs.stats, s)
+	}
+	sort.Stable(bs)
+	for i, b := range bs.bars {
+		result[unpinned[i]] = b
+	}
+	return result
+}
@@ -0,0 +1,84 @@
+package mpb
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSortBars(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	bars := make([]*Bar, 3)
+	for i, n := range []int{20, 80, 50} {
+		bars[i] = p.AddBarWithID(i, 100).SetPriority(i % 2)
+		bars[i].Incr(n)
+		for bars[i].GetStatistics().Current != int64(n) {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	tests := []struct {
+		less BarLess
+		want []int
+	}{
+		{SortByPercentage, []int{1, 2, 0}},
+		{SortByPriority, []int{1, 0, 2}},
+	}
+	for _, test := range tests {
+		sorted := sortBars(bars, test.less)
+		for i, b := range sorted {
+			if id := b.GetID(); id != test.want[i] {
+				t.Errorf("Position %d: want id %d, got %d\n", i, test.want[i], id)
+			}
+		}
+	}
+
+	bars[2].SetPinned(true)
+	sorted := sortBars(bars, SortByPercentage)
+	for i, want := range []int{1, 0, 2} {
+		if id := sorted[i].GetID(); id != want {
+			t.Errorf("Pinned position %d: want id %d, got %d\n", i, want, id)
+		}
+	}
+
+	for _, b := range bars {
+		b.Completed()
+	}
+	p.Stop()
+}
+
+func TestBarPriority(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	a := p.AddBarWithID(1, 10)
+	b := p.AddBarWithID(2, 10, BarPriority(5))
+	c := p.AddBarWithID(3, 10, BarPriority(1))
+	ids := func() []int {
+		var ids []int
+		for _, s := range p.Snapshot() {
+			ids = append(ids, s.ID)
+		}
+		return ids
+	}
+	if got := ids(); !reflect.DeepEqual(got, []int{2, 3, 1}) {
+		t.Errorf("Want order: %v, got: %v\n", []int{2, 3, 1}, got)
+	}
+	if !p.UpdateBarPriority(a, 5) {
+		t.Error("UpdateBarPriority of bar in container returned false")
+	}
+	if got := ids(); !reflect.DeepEqual(got, []int{2, 1, 3}) {
+		t.Errorf("Want order: %v, got: %v\n", []int{2, 1, 3}, got)
+	}
+	if got := a.GetStatistics().Priority; got != 5 {
+		t.Errorf("Want priority 5, got: %d\n", got)
+	}
+	// SetPriority reorders the same way
+	c.SetPriority(9)
+	if got := ids(); !reflect.DeepEqual(got, []int{3, 2, 1}) {
+		t.Errorf("Want order: %v, got: %v\n", []int{3, 2, 1}, got)
+	}
+	for _, bar := range []*Bar{a, b, c} {
+		bar.Incr(10)
+	}
+	p.Stop()
+}
@@ -0,0 +1,160 @@
+package mpb
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SpinnerSet is a set of spinner frames with recommended duration of a frame.
+// Frames may consist of several runes, see SpinnerBounce.
+type SpinnerSet struct {
+	Frames   []string
+	Interval time.Duration
+}
+
+// Spinner sets, which don't rely on braille characters, as many fonts lack
+// them
+var (
+	SpinnerLine   = SpinnerSet{[]string{"-", `\`, "|", "/"}, 130 * time.Millisecond}
+	SpinnerPipe   = SpinnerSet{[]string{"┤", "┘", "┴", "└", "├", "┌", "┬", "┐"}, 100 * time.Millisecond}
+	SpinnerCircle = SpinnerSet{[]string{"◐", "◓", "◑", "◒"}, 120 * time.Millisecond}
+	SpinnerArc    = SpinnerSet{[]string{"◜", "◠", "◝", "◞", "◡", "◟"}, 100 * time.Millisecond}
+	SpinnerArrow  = SpinnerSet{[]string{"←", "↖", "↑", "↗", "→", "↘", "↓", "↙"}, 100 * time.Millisecond}
+	SpinnerStar   = SpinnerSet{[]string{"+", "x", "*"}, 100 * time.Millisecond}
+	SpinnerBounce = SpinnerSet{[]string{"[=   ]", "[ =  ]", "[  = ]", "[   =]", "[  = ]", "[ =  ]"}, 120 * time.Millisecond}
+)
+
+// SpinnerFrames returns decorator, which advances a frame of set every
+// set.Interval, while the bar is being incremented, and freezes, when the bar
+// has been idle for longer than idle. Zero Interval advances a frame on every
+// render. Frames are padded to the widest one, so the decorator doesn't
+// change its width. Decorator keeps its state, so it must not be shared
+// between bars.
+func SpinnerFrames(set SpinnerSet, idle time.Duration) DecoratorFunc {
+	return spinnerFrames(set, idle, time.Now)
+}
+
+func spinnerFrames(set SpinnerSet, idle time.Duration, now func() time.Time) DecoratorFunc {
+	frames := padFrames(set.Frames)
+	var index int
+	var last time.Time
+	return func(s *Statistics, myWidth chan<- int, maxWidth <-chan int) string {
+		if len(frames) == 0 {
+			return ""
+		}
+		if s.Measuring() {
+			return frames[index]
+		}
+		t := now()
+		switch {
+		case isCompleted(s) || t.Sub(s.Updated) >= idle:
+			// don't catch up frames, which were missed while idle
+			last = t
+		case set.Interval <= 0:
+			index = (index + 1) % len(frames)
+		case last.IsZero():
+			last = t
+		default:
+			n := int(t.Sub(last) / set.Interval)
+			index = (index + n) % len(frames)
+			last = last.Add(time.Duration(n) * set.Interval)
+		}
+		return frames[index]
+	}
+}
+
+// PrependSpinnerFrames prepends SpinnerFrames decorator to the bar
+func (b *Bar) PrependSpinnerFrames(set SpinnerSet, idle time.Duration) *Bar {
+	return b.PrependFunc(SpinnerFrames(set, idle))
+}
+
+// AppendSpinnerFrames appends SpinnerFrames decorator to the bar
+func (b *Bar) AppendSpinnerFrames(set SpinnerSet, idle time.Duration) *Bar {
+	return b.AppendFunc(SpinnerFrames(set, idle))
+}
+
+// defaultSpinnerInterval is used, when SpinnerSet has no Interval
+const defaultSpinnerInterval = 100 * time.Millisecond
+
+// AddSpinner creates a new bar, which renders a spinner of set instead of a
+// fill, and adds it to the container. Spinner rotates, regardless of
+// progress, so it suits work without measurable progress, i.e. connecting or
+// waiting on a server. It completes, when current reaches positive total,
+// otherwise by (*Bar).Completed. Completed spinner shows its first frame.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) AddSpinner(total int64, set SpinnerSet, options ...BarOption) *Bar {
+	frames := padFrames(set.Frames)
+	if len(frames) == 0 {
+		frames = padFrames(SpinnerLine.Frames)
+	}
+	set.Frames = frames
+	if set.Interval <= 0 {
+		set.Interval = defaultSpinnerInterval
+	}
+	spinner := func(s *state) {
+		s.spinnerSet = &set
+		s.spinnerStart = time.Now()
+	}
+	return p.AddBar(total, append([]BarOption{spinner}, options...)...)
+}
+
+// spinnerFrame returns frame of spinner bar at now, see AddSpinner
+func (s *state) spinnerFrame(now time.Time) string {
+	frames := s.spinnerSet.Frames
+	if s.completed {
+		return frames[0]
+	}
+	i := int(now.Sub(s.spinnerStart)/s.spinnerSet.Interval) % len(frames)
+	return frames[i]
+}
+
+// padFrames pads frames to the widest one. Width is measured in terminal
+// columns, as frames are often made of emoji, see displayWidth.
+func padFrames(frames []string) []string {
+	var width int
+	for _, f := range frames {
+		if w := displayWidth(f); w > width {
+			width = w
+		}
+	}
+	padded := make([]string, len(frames))
+	for i, f := range frames {
+		padded[i] = f + strings.Repeat(" ", width-displayWidth(f))
+	}
+	return padded
+}
+
+// displayWidth returns count of terminal columns, which str occupies. East
+// Asian wide runes and emoji take two columns, combining marks, zero width
+// joiner and variation selectors take none.
+func displayWidth(str string) int {
+	var width int
+	for _, r := range str {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+func runeWidth(r rune) int {
+	switch {
+	case r == 0x200D || r >= 0xFE00 && r <= 0xFE0F || unicode.Is(unicode.Mn, r):
+		return 0
+	case r >= 0x1100 && r <= 0x115F, // Hangul Jamo
+		r >= 0x2E80 && r <= 0x303E, // CJK radicals, punctuation
+		r >= 0x3041 && r <= 0x33FF, // Kana, CJK symbols
+		r >= 0x3400 && r <= 0x4DBF, // CJK extension A
+		r >= 0x4E00 && r <= 0x9FFF, // CJK unified ideographs
+		r >= 0xA000 && r <= 0xA4CF, // Yi
+		r >= 0xAC00 && r <= 0xD7A3, // Hangul syllables
+		r >= 0xF900 && r <= 0xFAFF, // CJK compatibility ideographs
+		r >= 0xFE30 && r <= 0xFE4F, // CJK compatibility forms
+		r >= 0xFF00 && r <= 0xFF60, // fullwidth forms
+		r >= 0xFFE0 && r <= 0xFFE6,
+		r >= 0x1F300 && r <= 0x1F64F, // emoji, pictographs
+		r >= 0x1F900 && r <= 0x1F9FF,
+		r >= 0x20000 && r <= 0x3FFFD: // CJK extensions
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,79 @@
+package mpb
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vbauerster/mpb/decor"
+)
+
+func TestSpinnerFrames(t *testing.T) {
+	start := time.Now()
+	now := start
+	f := spinnerFrames(SpinnerSet{[]string{"a", "bb", "c"}, 100 * time.Millisecond}, time.Second,
+		func() time.Time { return now })
+	s := &Statistics{Total: 10, Current: 5, Updated: start}
+	steps := []struct {
+		after time.Duration
+		want  string
+	}{
+		{0, "a "},
+		{50 * time.Millisecond, "a "},
+		{100 * time.Millisecond, "bb"},
+		{320 * time.Millisecond, "a "},
+		{400 * time.Millisecond, "bb"},
+		// idle
+		{5 * time.Second, "bb"},
+	}
+	for i, step := range steps {
+		now = start.Add(step.after)
+		if got := f(s, nil, nil); got != step.want {
+			t.Errorf("Step %d: want %q, got %q\n", i, step.want, got)
+		}
+	}
+	// resumed spinner doesn't catch up idle time
+	s.Updated = now
+	now = now.Add(100 * time.Millisecond)
+	if got := f(s, nil, nil); got != "c " {
+		t.Errorf("Resumed: want %q, got %q\n", "c ", got)
+	}
+}
+
+func TestSpinnerFrame(t *testing.T) {
+	start := time.Now()
+	set := &SpinnerSet{padFrames([]string{"a", "bb", "c"}), 10 * time.Millisecond}
+	s := &state{spinnerSet: set, spinnerStart: start}
+	for i, want := range []string{"a ", "bb", "c ", "a "} {
+		if got := s.spinnerFrame(start.Add(time.Duration(i) * 10 * time.Millisecond)); got != want {
+			t.Errorf("Frame %d: want %q, got %q\n", i, want, got)
+		}
+	}
+	s.completed = true
+	if got := s.spinnerFrame(start.Add(15 * time.Millisecond)); got != "a " {
+		t.Errorf("Completed spinner: want %q, got %q\n", "a ", got)
+	}
+}
+
+func TestPadFramesDisplayWidth(t *testing.T) {
+	got := padFrames([]string{"🌑", "a", "日本", "e\u0301"})
+	want := []string{"🌑  ", "a   ", "日本", "e\u0301   "}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Frame %d: want %q, got %q\n", i, want[i], got[i])
+		}
+	}
+}
+
+func TestAddSpinner(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).RefreshRate(10 * time.Millisecond)
+	bar := p.AddSpinner(0, SpinnerSet{Frames: []string{"*"}}, AppendDecorators(decor.Elapsed()))
+	time.Sleep(50 * time.Millisecond)
+	bar.Completed()
+	p.Stop()
+	if !strings.HasPrefix(buf.String(), " * 0s") {
+		t.Errorf("Unexpected output: %q\n", buf.String())
+	}
+}
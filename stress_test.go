@@ -0,0 +1,83 @@
+package mpb
+
+import (
+	"io/ioutil"
+	"sync"
+	"testing"
+)
+
+func TestStressConcurrentAPI(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			p.Format("[=>-]")
+			p := p.SetWidth(40 + i)
+			b := p.AddBar(50).PrependName("bar", 0, DwidthSync).AppendPercentage(5, 0)
+			b.SetEtaAlpha(0.3)
+			for j := 0; j < 50; j++ {
+				b.Incr(1)
+				if j == 10 {
+					p.SetOut(ioutil.Discard)
+				}
+				if i%3 == 0 && j == 25 {
+					p.RemoveBar(b)
+				}
+				b.Incr(1)
+				b.GetStatistics()
+				p.BarCount()
+			}
+		}(i)
+	}
+	wg.Wait()
+	p.Stop()
+}
+
+func TestStressConcurrentStop(t *testing.T) {
+	p := New().SetOut(ioutil.Discard).SetMaxDrawers(2)
+	bars := make([]*Bar, 4)
+	for i := range bars {
+		bars[i] = p.AddBar(100).AppendPercentage(5, DwidthSync)
+	}
+	var wg sync.WaitGroup
+	for _, b := range bars {
+		wg.Add(1)
+		go func(b *Bar) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				b.Incr(1)
+			}
+		}(b)
+	}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.Stop()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestStressCancel(t *testing.T) {
+	cancel := make(chan struct{})
+	p := New().SetOut(ioutil.Discard).WithCancel(cancel)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		b := p.AddBar(1000)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				b.Incr(1)
+				b.SetWidth(50)
+				b.Format("[=>-]")
+			}
+		}()
+	}
+	close(cancel)
+	wg.Wait()
+	p.Stop()
+}
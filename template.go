@@ -0,0 +1,80 @@
+package mpb
+
+import "unicode/utf8"
+
+// BarTemplate describes a bar, which can be stamped out many times by
+// (*Progress).AddFromTemplate. All settings are applied at once, which is
+// cheaper than chained setters, when hundreds of uniform bars are created.
+// Decorators are shared between bars, so they must be stateless, i.e. Spinner
+// must not be used in template.
+type BarTemplate struct {
+	// Width of the bar, zero means width of the container
+	Width int
+	// Format of the bar, empty means format of the container
+	Format         string
+	TrimLeftSpace  bool
+	TrimRightSpace bool
+	// NameConf is conf of the name column, which is prepended first
+	NameConf byte
+	Prepend  []DecoratorFunc
+	Append   []DecoratorFunc
+	// PrependBytes and AppendBytes follow Prepend and Append respectively
+	PrependBytes []BytesDecoratorFunc
+	AppendBytes  []BytesDecoratorFunc
+	Color        string
+	MinWidth     int
+	Priority     int
+	// EtaAlpha, zero means default
+	EtaAlpha float64
+}
+
+// AddFromTemplate creates a new progress bar from tpl and adds it to the
+// container. The name is prepended as the first column and is available via
+// bar.Meta("name").
+// Pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) AddFromTemplate(tpl *BarTemplate, total int64, name string) *Bar {
+	p.acquire()
+	defer p.release()
+	req := &barRequest{
+		total:  total,
+		width:  p.width,
+		cancel: p.cancel,
+		wg:     p.uwg,
+		tpl:    tpl,
+		name:   name,
+		result: make(chan *Bar),
+	}
+	select {
+	case p.addBarReqCh <- req:
+		return <-req.result
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+}
+
+func (s *state) applyTemplate(tpl *BarTemplate, name string) {
+	if tpl.Width >= 2 {
+		s.width = tpl.Width
+	}
+	if utf8.RuneCountInString(tpl.Format) == numFmtRunes {
+		s.updateFormat(tpl.Format)
+	}
+	s.trimLeftSpace = tpl.TrimLeftSpace
+	s.trimRightSpace = tpl.TrimRightSpace
+	s.prependFuncs = make([]BytesDecoratorFunc, 0, len(tpl.Prepend)+len(tpl.PrependBytes)+1)
+	s.prependFuncs = append(s.prependFuncs, NameBytes(name, 0, tpl.NameConf))
+	s.prependFuncs = append(s.prependFuncs, toBytesFuncs(tpl.Prepend)...)
+	s.prependFuncs = append(s.prependFuncs, tpl.PrependBytes...)
+	s.prependLayouts = make([]Layout, len(s.prependFuncs))
+	s.appendFuncs = make([]BytesDecoratorFunc, 0, len(tpl.Append)+len(tpl.AppendBytes))
+	s.appendFuncs = append(s.appendFuncs, toBytesFuncs(tpl.Append)...)
+	s.appendFuncs = append(s.appendFuncs, tpl.AppendBytes...)
+	s.appendLayouts = make([]Layout, len(s.appendFuncs))
+	s.sgr = tpl.Color
+	s.minWidth = tpl.MinWidth
+	s.priority = tpl.Priority
+	if tpl.EtaAlpha > 0 {
+		s.etaAlpha = tpl.EtaAlpha
+	}
+	s.meta = map[string]interface{}{"name": name}
+}
@@ -0,0 +1,47 @@
+package mpb
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"testing"
+)
+
+func TestAddFromTemplate(t *testing.T) {
+	p := New().SetOut(ioutil.Discard)
+	tpl := &BarTemplate{
+		Width:          20,
+		Format:         "[#>_]",
+		TrimLeftSpace:  true,
+		TrimRightSpace: true,
+		NameConf:       DwidthSync | DidentRight,
+		Append:         []DecoratorFunc{Percentage(5, 0)},
+		PrependBytes:   []BytesDecoratorFunc{NameBytes("|", 0, 0)},
+		AppendBytes:    []BytesDecoratorFunc{ETABytes(0, 0)},
+	}
+	bars := make([]*Bar, 3)
+	for i := range bars {
+		bars[i] = p.AddFromTemplate(tpl, 10, fmt.Sprintf("task#%d", i))
+	}
+	for i, bar := range bars {
+		name := fmt.Sprintf("task#%d", i)
+		if got := bar.Meta("name"); got != name {
+			t.Errorf("Want: %q, Got: %v\n", name, got)
+		}
+		if n := bar.NumOfPrependers(); n != 2 {
+			t.Errorf("Want prependers: %d, Got: %d\n", 2, n)
+		}
+		if n := bar.NumOfAppenders(); n != 2 {
+			t.Errorf("Want appenders: %d, Got: %d\n", 2, n)
+		}
+		s := bar.getState()
+		buf := draw(&s, 80, newWidthSync(nil, 1, 2), newWidthSync(nil, 1, 2))
+		if !bytes.HasPrefix(buf, []byte(name+"|[___")) {
+			t.Errorf("Unexpected line: %q\n", buf)
+		}
+	}
+	for _, bar := range bars {
+		bar.Completed()
+	}
+	p.Stop()
+}
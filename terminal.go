@@ -0,0 +1,64 @@
+package mpb
+
+import (
+	"io"
+	"sync"
+
+	"github.com/vbauerster/mpb/cwriter"
+)
+
+// alteredTerm is a terminal, which state is altered by running Progress
+type alteredTerm struct {
+	w io.Writer
+}
+
+// alteredTerms are tracked globally, because goroutines of Progress don't
+// get a chance to restore terminal, when host app panics
+var alteredTerms struct {
+	sync.Mutex
+	list []*alteredTerm
+}
+
+func hideCursor(w io.Writer) *alteredTerm {
+	io.WriteString(w, cwriter.HideCursor)
+	t := &alteredTerm{w}
+	alteredTerms.Lock()
+	alteredTerms.list = append(alteredTerms.list, t)
+	alteredTerms.Unlock()
+	return t
+}
+
+// restore shows cursor, unless it was restored by RestoreTerminal already
+func (t *alteredTerm) restore() {
+	alteredTerms.Lock()
+	defer alteredTerms.Unlock()
+	for i, at := range alteredTerms.list {
+		if at == t {
+			alteredTerms.list = append(alteredTerms.list[:i], alteredTerms.list[i+1:]...)
+			io.WriteString(t.w, cwriter.ShowCursor)
+			return
+		}
+	}
+}
+
+// RestoreTerminal restores state of terminals, altered by running Progress
+// instances, i.e. shows cursor hidden by (*Progress).SetHideCursor.
+func RestoreTerminal() {
+	alteredTerms.Lock()
+	defer alteredTerms.Unlock()
+	for _, t := range alteredTerms.list {
+		io.WriteString(t.w, cwriter.ShowCursor)
+	}
+	alteredTerms.list = nil
+}
+
+// RestoreTerminalOnPanic restores terminal, see RestoreTerminal, if host app
+// panics, then re-panics. It must be deferred directly in main goroutine:
+//
+//	defer mpb.RestoreTerminalOnPanic()
+func RestoreTerminalOnPanic() {
+	if r := recover(); r != nil {
+		RestoreTerminal()
+		panic(r)
+	}
+}
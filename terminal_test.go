@@ -0,0 +1,38 @@
+package mpb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/vbauerster/mpb/cwriter"
+)
+
+func TestRestoreTerminal(t *testing.T) {
+	var buf1, buf2 bytes.Buffer
+	t1 := hideCursor(&buf1)
+	hideCursor(&buf2)
+	t1.restore()
+	RestoreTerminal()
+	// already restored, must be no-op
+	t1.restore()
+	for _, buf := range []*bytes.Buffer{&buf1, &buf2} {
+		if got, want := buf.String(), cwriter.HideCursor+cwriter.ShowCursor; got != want {
+			t.Errorf("Want: %q, Got: %q\n", want, got)
+		}
+	}
+}
+
+func TestRestoreTerminalOnPanic(t *testing.T) {
+	var buf bytes.Buffer
+	hideCursor(&buf)
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("Want re-panic with %q, Got: %v\n", "boom", r)
+		}
+		if !bytes.HasSuffix(buf.Bytes(), []byte(cwriter.ShowCursor)) {
+			t.Errorf("Cursor isn't restored: %q\n", buf.Bytes())
+		}
+	}()
+	defer RestoreTerminalOnPanic()
+	panic("boom")
+}
@@ -0,0 +1,82 @@
+package mpb
+
+import (
+	"math/rand"
+	"time"
+)
+
+// SetRefreshJitter randomizes each refresh interval by up to jitter fraction
+// of refresh rate in either direction, i.e. 0.1 for ±10%, so several
+// processes sharing a terminal multiplexer don't redraw in lockstep. Jitter
+// is clamped to [0, 1], zero disables it, which is default.
+// pancis, if called on stopped Progress instance, i.e after (*Progress).Stop()
+func (p *Progress) SetRefreshJitter(jitter float64) *Progress {
+	p.acquire()
+	defer p.release()
+	if jitter < 0 {
+		jitter = 0
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+	select {
+	case p.jitterCh <- jitter:
+	case <-p.done:
+		panic(ErrCallAfterStop)
+	}
+	return p
+}
+
+// refreshTicker is time.Ticker with optionally jittered interval
+type refreshTicker struct {
+	C      <-chan time.Time
+	ticker *time.Ticker
+	quit   chan struct{}
+}
+
+func newRefreshTicker(d time.Duration, jitter float64) *refreshTicker {
+	if jitter <= 0 {
+		ticker := time.NewTicker(d)
+		return &refreshTicker{C: ticker.C, ticker: ticker}
+	}
+	c := make(chan time.Time, 1)
+	t := &refreshTicker{C: c, quit: make(chan struct{})}
+	// own source, so processes started at once don't share the sequence
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	go func() {
+		timer := time.NewTimer(jittered(d, jitter, rnd.Float64()))
+		defer timer.Stop()
+		for {
+			select {
+			case now := <-timer.C:
+				// drop tick, if the previous one isn't consumed, like time.Ticker
+				select {
+				case c <- now:
+				default:
+				}
+				timer.Reset(jittered(d, jitter, rnd.Float64()))
+			case <-t.quit:
+				return
+			}
+		}
+	}()
+	return t
+}
+
+// Stop turns off the ticker
+func (t *refreshTicker) Stop() {
+	if t.ticker != nil {
+		t.ticker.Stop()
+		return
+	}
+	close(t.quit)
+}
+
+// jittered returns d shifted by up to jitter fraction of d, r is in [0, 1)
+func jittered(d time.Duration, jitter, r float64) time.Duration {
+	shift := time.Duration(float64(d) * jitter * (2*r - 1))
+	if d+shift <= 0 {
+		return time.Millisecond
+	}
+	return d + shift
+}
@@ -0,0 +1,50 @@
+package mpb
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJittered(t *testing.T) {
+	d := 100 * time.Millisecond
+	tests := []struct {
+		jitter, r float64
+		want      time.Duration
+	}{
+		{0, 0.3, d},
+		{0.1, 0.5, d},
+		{0.1, 0, 90 * time.Millisecond},
+		{0.1, 1, 110 * time.Millisecond},
+		{1, 0, time.Millisecond},
+	}
+	for _, test := range tests {
+		if got := jittered(d, test.jitter, test.r); got != test.want {
+			t.Errorf("jittered(%v, %v, %v): want %v, got %v\n", d, test.jitter, test.r, test.want, got)
+		}
+	}
+}
+
+func TestRefreshTickerJitter(t *testing.T) {
+	ticker := newRefreshTicker(5*time.Millisecond, 0.5)
+	defer ticker.Stop()
+	for i := 0; i < 3; i++ {
+		select {
+		case <-ticker.C:
+		case <-time.After(time.Second):
+			t.Fatal("Ticker doesn't tick")
+		}
+	}
+}
+
+func TestSetRefreshJitter(t *testing.T) {
+	var buf bytes.Buffer
+	p := New().SetOut(&buf).RefreshRate(10 * time.Millisecond).SetRefreshJitter(0.2)
+	bar := p.AddBar(10).AppendPercentage(0, 0)
+	bar.Incr(10)
+	p.Stop()
+	if !strings.Contains(buf.String(), "100 %") {
+		t.Errorf("Expected 100 %% in output, got: %q\n", buf.String())
+	}
+}